@@ -25,6 +25,11 @@ misspell .
 
 go mod tidy
 
+# adapters with third-party dependencies are nested modules
+for mod in */go.mod; do
+    (cd "$(dirname "$mod")" && go mod tidy && go vet ./...)
+done
+
 govulncheck ./...
 
 deadcode ./examples/*
@@ -33,6 +38,10 @@ go env -w CGO_ENABLED=1
 
 go test -race ./...
 
+for mod in */go.mod; do
+    (cd "$(dirname "$mod")" && go test -race ./...)
+done
+
 go env -w CGO_ENABLED=0
 
 go install ./...
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func newSDKClient(cfg aws.Config, endpoint string) *cloudwatchlogs.Client {
+	return cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+}
+
+// timingClient records the latency and SDK retries of PutLogEvents calls.
+type timingClient struct {
+	cwlog.CloudWatchLogClient
+	rec *recorder
+}
+
+func (c *timingClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	begin := time.Now()
+	out, err := c.CloudWatchLogClient.PutLogEvents(ctx, params, optFns...)
+	var retries int
+	if out != nil {
+		if attempts, ok := retry.GetAttemptResults(out.ResultMetadata); ok {
+			retries = max(len(attempts.Results)-1, 0)
+		}
+	}
+	c.rec.observePut(time.Since(begin), retries, err)
+	return out, err
+}
+
+// fakeClient is an in-memory backend accepting every event after a
+// fixed latency, throttling a fraction of the calls.
+type fakeClient struct {
+	latency  time.Duration
+	throttle float64
+}
+
+func newFakeClient(latency time.Duration, throttle float64) *fakeClient {
+	return &fakeClient{latency: latency, throttle: throttle}
+}
+
+func (c *fakeClient) CreateLogGroup(_ context.Context,
+	_ *cloudwatchlogs.CreateLogGroupInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (c *fakeClient) PutRetentionPolicy(_ context.Context,
+	_ *cloudwatchlogs.PutRetentionPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+func (c *fakeClient) CreateLogStream(_ context.Context,
+	_ *cloudwatchlogs.CreateLogStreamInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (c *fakeClient) PutLogEvents(ctx context.Context,
+	_ *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(c.latency):
+	}
+	if c.throttle > 0 && rand.Float64() < c.throttle {
+		return nil, &types.ThrottlingException{Message: aws.String("Rate exceeded")}
+	}
+	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// generate runs the workers until opt.duration elapses or ctx is
+// cancelled, calling progress every opt.reportInterval.
+func generate(ctx context.Context, cw *cwlog.Log, rec *recorder, opt benchOptions, progress func()) {
+	ctx, cancel := context.WithTimeout(ctx, opt.duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range opt.workers {
+		wg.Go(func() { worker(ctx, cw, rec, opt, i) })
+	}
+
+	if opt.reportInterval > 0 {
+		ticker := time.NewTicker(opt.reportInterval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+				progress()
+			}
+		}
+	}
+	wg.Wait()
+}
+
+// worker sends events paced at its share of opt.rate.
+func worker(ctx context.Context, cw *cwlog.Log, rec *recorder, opt benchOptions, id int) {
+	var interval time.Duration
+	if opt.rate > 0 {
+		interval = time.Duration(opt.workers) * time.Second / time.Duration(opt.rate)
+	}
+	filler := strings.Repeat("x", opt.size)
+	start := time.Now()
+
+	for seq := 0; ctx.Err() == nil; seq++ {
+		if interval > 0 {
+			// schedule against the start time so pauses are caught up
+			if wait := time.Until(start.Add(time.Duration(seq) * interval)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+		}
+		prefix := fmt.Sprintf("worker=%d seq=%d ", id, seq)
+		msg := prefix
+		if n := opt.size - len(prefix); n > 0 {
+			msg += filler[:n]
+		}
+		rec.observeGenerated(cw.PutSimple(msg))
+	}
+}
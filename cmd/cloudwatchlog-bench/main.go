@@ -0,0 +1,130 @@
+// Package main implements cloudwatchlog-bench, a soak and throughput
+// benchmark for sizing the cwlog buffered mode.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/udhos/boilerplate/awsconfig"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+type benchOptions struct {
+	group          string
+	stream         string
+	rate           int
+	size           int
+	workers        int
+	duration       time.Duration
+	reportInterval time.Duration
+	bufferEvents   int
+	flushInterval  time.Duration
+	adaptive       bool
+
+	fake         bool
+	fakeLatency  time.Duration
+	fakeThrottle float64
+
+	region   string
+	roleArn  string
+	endpoint string
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("cloudwatchlog-bench: ")
+
+	var opt benchOptions
+	flag.StringVar(&opt.group, "group", "/cloudwatchlog/bench", "log group")
+	flag.StringVar(&opt.stream, "stream", "", "log stream, defaults to the group")
+	flag.IntVar(&opt.rate, "rate", 1000, "events per second across all workers, 0 for unlimited")
+	flag.IntVar(&opt.size, "size", 200, "event message size in bytes")
+	flag.IntVar(&opt.workers, "workers", 4, "concurrent event producers")
+	flag.DurationVar(&opt.duration, "duration", time.Minute, "benchmark duration")
+	flag.DurationVar(&opt.reportInterval, "report-interval", 10*time.Second, "progress report period, 0 to disable")
+	flag.IntVar(&opt.bufferEvents, "buffer-events", 0, "cwlog.Options.BufferEvents, 0 for the default")
+	flag.DurationVar(&opt.flushInterval, "flush-interval", 0, "cwlog.Options.FlushInterval, 0 for the default")
+	flag.BoolVar(&opt.adaptive, "adaptive", false, "enable cwlog.Options.AdaptiveBatching")
+	flag.BoolVar(&opt.fake, "fake", false, "send to an in-memory backend instead of AWS")
+	flag.DurationVar(&opt.fakeLatency, "fake-latency", 20*time.Millisecond, "fake backend PutLogEvents latency")
+	flag.Float64Var(&opt.fakeThrottle, "fake-throttle", 0, "fraction of fake backend calls throttled, from 0 to 1")
+	flag.StringVar(&opt.region, "region", "", "AWS region, defaults to the SDK configuration")
+	flag.StringVar(&opt.roleArn, "role-arn", "", "optional role to assume")
+	flag.StringVar(&opt.endpoint, "endpoint-url", "", "optional endpoint URL, like LocalStack")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, opt, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, opt benchOptions, w io.Writer) error {
+	if opt.size < 1 {
+		return errors.New("-size must be positive")
+	}
+	if opt.workers < 1 {
+		return errors.New("-workers must be positive")
+	}
+
+	var base cwlog.CloudWatchLogClient
+	if opt.fake {
+		base = newFakeClient(opt.fakeLatency, opt.fakeThrottle)
+	} else {
+		cfg, err := awsConfig(opt)
+		if err != nil {
+			return err
+		}
+		base = newSDKClient(cfg, opt.endpoint)
+	}
+	rec := newRecorder()
+	client := &timingClient{CloudWatchLogClient: base, rec: rec}
+
+	cw, err := cwlog.New(cwlog.Options{
+		Client:           client,
+		LogGroup:         opt.group,
+		LogStream:        opt.stream,
+		RetentionInDays:  1,
+		Async:            true,
+		BufferEvents:     opt.bufferEvents,
+		FlushInterval:    opt.flushInterval,
+		AdaptiveBatching: opt.adaptive,
+		ObserveBatch:     rec.observeBatch,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "group=%s rate=%d size=%d workers=%d duration=%v fake=%t\n",
+		opt.group, opt.rate, opt.size, opt.workers, opt.duration, opt.fake)
+
+	start := time.Now()
+	generate(ctx, cw, rec, opt, func() { report(w, "progress", cw.Stats(), rec, time.Since(start)) })
+	errClose := cw.Close()
+	report(w, "final", cw.Stats(), rec, time.Since(start))
+	return errClose
+}
+
+func awsConfig(opt benchOptions) (aws.Config, error) {
+	out, err := awsconfig.AwsConfig(awsconfig.Options{
+		Region:      opt.region,
+		RoleArn:     opt.roleArn,
+		EndpointURL: opt.endpoint,
+		Printf:      func(string, ...any) {},
+	})
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("aws sdk config error: %w", err)
+	}
+	return out.AwsConfig, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplesPercentiles(t *testing.T) {
+	var s samples
+	for i := 1; i <= 100; i++ {
+		s.add(time.Duration(i) * time.Millisecond)
+	}
+	got := s.percentiles(50, 99, 100)
+	expected := []time.Duration{50 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("percentile %d: expected=%v got=%v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestRunFake(t *testing.T) {
+	opt := benchOptions{
+		group:         "bench",
+		rate:          1000,
+		size:          100,
+		workers:       2,
+		duration:      300 * time.Millisecond,
+		flushInterval: 50 * time.Millisecond,
+		fake:          true,
+		fakeLatency:   time.Millisecond,
+	}
+	var out bytes.Buffer
+	if err := run(context.Background(), opt, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "final: ") {
+		t.Fatalf("missing final report: %s", out.String())
+	}
+	if strings.Contains(out.String(), " delivered=0 ") {
+		t.Errorf("nothing delivered: %s", out.String())
+	}
+}
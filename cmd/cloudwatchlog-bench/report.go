@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// maxSamples bounds the memory used by each latency reservoir.
+const maxSamples = 100000
+
+// samples keeps a uniform random sample of latencies.
+type samples struct {
+	seen   int
+	values []time.Duration
+}
+
+func (s *samples) add(d time.Duration) {
+	s.seen++
+	if len(s.values) < maxSamples {
+		s.values = append(s.values, d)
+		return
+	}
+	if i := rand.IntN(s.seen); i < maxSamples {
+		s.values[i] = d
+	}
+}
+
+// percentiles returns the given percentiles, from 0 to 100.
+func (s *samples) percentiles(ps ...float64) []time.Duration {
+	result := make([]time.Duration, len(ps))
+	if len(s.values) == 0 {
+		return result
+	}
+	sorted := slices.Clone(s.values)
+	slices.Sort(sorted)
+	for i, p := range ps {
+		k := int(p / 100 * float64(len(sorted)-1))
+		result[i] = sorted[k]
+	}
+	return result
+}
+
+// recorder accumulates the benchmark measurements.
+type recorder struct {
+	mu         sync.Mutex
+	generated  int64
+	rejected   int64
+	delivered  int64
+	bytes      int64
+	puts       int64
+	putErrors  int64
+	retries    int64
+	putLatency samples
+	delivery   samples
+}
+
+func newRecorder() *recorder {
+	return &recorder{}
+}
+
+// observeGenerated counts an event handed to cwlog.
+func (r *recorder) observeGenerated(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generated++
+	if err != nil {
+		r.rejected++
+	}
+}
+
+// observePut records one PutLogEvents call.
+func (r *recorder) observePut(d time.Duration, retries int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.puts++
+	r.retries += int64(retries)
+	if err != nil {
+		r.putErrors++
+	}
+	r.putLatency.add(d)
+}
+
+// observeBatch records the delivery latency of accepted events,
+// from their timestamp to the end of the put.
+func (r *recorder) observeBatch(_, _ string, events []types.InputLogEvent) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range events {
+		r.delivered++
+		r.bytes += int64(cwlog.EventSize(e))
+		r.delivery.add(now.Sub(time.UnixMilli(*e.Timestamp)))
+	}
+}
+
+var reportPercentiles = []float64{50, 90, 99, 100}
+
+// report prints throughput, latency percentiles and loss counters.
+func report(w io.Writer, label string, stats cwlog.Stats, r *recorder, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	secs := max(elapsed.Seconds(), 1e-9)
+	fmt.Fprintf(w, "%s: elapsed=%v generated=%d (%.0f/s) delivered=%d (%.0f/s, %.2f MiB/s)\n",
+		label, elapsed.Round(time.Millisecond), r.generated, float64(r.generated)/secs,
+		r.delivered, float64(r.delivered)/secs, float64(r.bytes)/secs/(1<<20))
+	fmt.Fprintf(w, "  puts=%d put_errors=%d sdk_retries=%d rejected=%d dropped=%d failed=%d throttled_batches=%d\n",
+		r.puts, r.putErrors, r.retries, r.rejected, stats.DroppedEvents, stats.FailedEvents,
+		stats.ThrottledBatches)
+	fmt.Fprintf(w, "  buffered=%d (%d bytes) peak=%d (%d bytes) flush_interval=%v flush_events=%d\n",
+		stats.BufferedEvents, stats.BufferedBytes, stats.PeakBufferedEvents,
+		stats.PeakBufferedBytes, stats.FlushInterval, stats.FlushEvents)
+	fmt.Fprintf(w, "  put latency:      %s\n", formatPercentiles(r.putLatency.percentiles(reportPercentiles...)))
+	fmt.Fprintf(w, "  delivery latency: %s\n", formatPercentiles(r.delivery.percentiles(reportPercentiles...)))
+}
+
+func formatPercentiles(values []time.Duration) string {
+	return fmt.Sprintf("p50=%v p90=%v p99=%v max=%v",
+		values[0].Round(time.Microsecond), values[1].Round(time.Microsecond),
+		values[2].Round(time.Microsecond), values[3].Round(time.Microsecond))
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// adminFlags parses the flags shared by group lifecycle commands.
+func adminFlags(name string, args []string, define func(fs *flag.FlagSet)) (*cloudwatchlogs.Client, string, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	group := fs.String("group", "", "log group, required")
+	if define != nil {
+		define(fs)
+	}
+	fs.Parse(args)
+
+	if *group == "" {
+		return nil, "", errors.New("-group is required")
+	}
+	cfg, err := aws.config()
+	if err != nil {
+		return nil, "", err
+	}
+	return cloudwatchlogs.NewFromConfig(cfg), *group, nil
+}
+
+// runCreateGroup creates a log group, optionally setting retention.
+func runCreateGroup(args []string) error {
+	var class string
+	var retention int
+	client, group, err := adminFlags("create-group", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&class, "class", "", "optional group class: STANDARD or INFREQUENT_ACCESS")
+		fs.IntVar(&retention, "retention", 0, "optional retention in days")
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	input := &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(group)}
+	if class != "" {
+		input.LogGroupClass = types.LogGroupClass(class)
+	}
+	if _, err := client.CreateLogGroup(ctx, input); err != nil {
+		return fmt.Errorf("CreateLogGroup error: group=%s: %w", group, err)
+	}
+	if retention > 0 {
+		return setRetention(ctx, client, group, retention)
+	}
+	return nil
+}
+
+// runSetRetention changes the retention of a log group.
+func runSetRetention(args []string) error {
+	var days int
+	client, group, err := adminFlags("set-retention", args, func(fs *flag.FlagSet) {
+		fs.IntVar(&days, "days", 30, "retention in days")
+	})
+	if err != nil {
+		return err
+	}
+	return setRetention(context.Background(), client, group, days)
+}
+
+func setRetention(ctx context.Context, client *cloudwatchlogs.Client, group string, days int) error {
+	_, err := client.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    aws.String(group),
+		RetentionInDays: aws.Int32(int32(days)),
+	})
+	if err != nil {
+		return fmt.Errorf("PutRetentionPolicy error: group=%s retention=%d: %w", group, days, err)
+	}
+	return nil
+}
+
+// runTag adds tags to a log group.
+func runTag(args []string) error {
+	var tags string
+	client, group, err := adminFlags("tag", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&tags, "tags", "", "comma-separated key=value pairs, required")
+	})
+	if err != nil {
+		return err
+	}
+	parsed, errTags := parseTags(tags)
+	if errTags != nil {
+		return errTags
+	}
+
+	ctx := context.Background()
+	arn, errArn := cwlog.LookupGroupARN(ctx, client, group)
+	if errArn != nil {
+		return errArn
+	}
+	_, err = client.TagResource(ctx, &cloudwatchlogs.TagResourceInput{
+		ResourceArn: aws.String(arn),
+		Tags:        parsed,
+	})
+	if err != nil {
+		return fmt.Errorf("TagResource error: group=%s: %w", group, err)
+	}
+	return nil
+}
+
+func parseTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	for pair := range strings.SplitSeq(s, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("bad tag, expecting key=value: %q", pair)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// runDeleteGroup deletes a log group and all its events.
+func runDeleteGroup(args []string) error {
+	var yes bool
+	client, group, err := adminFlags("delete-group", args, func(fs *flag.FlagSet) {
+		fs.BoolVar(&yes, "yes", false, "confirm deletion, required")
+	})
+	if err != nil {
+		return err
+	}
+	if !yes {
+		return errors.New("refusing to delete group without -yes")
+	}
+	_, err = client.DeleteLogGroup(context.Background(),
+		&cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String(group)})
+	if err != nil {
+		return fmt.Errorf("DeleteLogGroup error: group=%s: %w", group, err)
+	}
+	return nil
+}
+
+// streamClient is the subset of the CloudWatch Logs client used by purge-streams.
+type streamClient interface {
+	DescribeLogStreams(ctx context.Context,
+		params *cloudwatchlogs.DescribeLogStreamsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error)
+	DeleteLogStream(ctx context.Context,
+		params *cloudwatchlogs.DeleteLogStreamInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error)
+}
+
+type purgeOptions struct {
+	group     string
+	prefix    string
+	olderThan time.Duration
+	dryRun    bool
+}
+
+// runPurgeStreams deletes streams without recent events.
+func runPurgeStreams(args []string) error {
+	var opt purgeOptions
+	client, group, err := adminFlags("purge-streams", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&opt.prefix, "prefix", "", "only streams with this name prefix")
+		fs.DurationVar(&opt.olderThan, "older-than", 30*24*time.Hour, "delete streams idle for longer than this")
+		fs.BoolVar(&opt.dryRun, "dry-run", false, "only print streams that would be deleted")
+	})
+	if err != nil {
+		return err
+	}
+	opt.group = group
+	return purgeStreams(context.Background(), client, os.Stdout, opt, time.Now())
+}
+
+// purgeStreams deletes streams whose last event, or creation time for
+// empty streams, is older than opt.olderThan, printing their names.
+func purgeStreams(ctx context.Context, client streamClient, w io.Writer, opt purgeOptions,
+	now time.Time) error {
+
+	input := &cloudwatchlogs.DescribeLogStreamsInput{LogGroupName: aws.String(opt.group)}
+	if opt.prefix != "" {
+		input.LogStreamNamePrefix = aws.String(opt.prefix)
+	}
+	cutoff := now.Add(-opt.olderThan).UnixMilli()
+
+	var idle []string
+	pages := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, input)
+	for pages.HasMorePages() {
+		out, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("DescribeLogStreams error: group=%s: %w", opt.group, err)
+		}
+		for _, s := range out.LogStreams {
+			last := aws.ToInt64(s.LastEventTimestamp)
+			if last == 0 {
+				last = aws.ToInt64(s.CreationTime)
+			}
+			if last < cutoff {
+				idle = append(idle, aws.ToString(s.LogStreamName))
+			}
+		}
+	}
+
+	for _, stream := range idle {
+		fmt.Fprintln(w, stream)
+		if opt.dryRun {
+			continue
+		}
+		_, err := client.DeleteLogStream(ctx, &cloudwatchlogs.DeleteLogStreamInput{
+			LogGroupName:  aws.String(opt.group),
+			LogStreamName: aws.String(stream),
+		})
+		if err != nil {
+			return fmt.Errorf("DeleteLogStream error: group=%s stream=%s: %w",
+				opt.group, stream, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type streamMock struct {
+	streams []types.LogStream
+	deleted []string
+}
+
+func (m *streamMock) DescribeLogStreams(_ context.Context,
+	_ *cloudwatchlogs.DescribeLogStreamsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	return &cloudwatchlogs.DescribeLogStreamsOutput{LogStreams: m.streams}, nil
+}
+
+func (m *streamMock) DeleteLogStream(_ context.Context,
+	params *cloudwatchlogs.DeleteLogStreamInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+	m.deleted = append(m.deleted, *params.LogStreamName)
+	return &cloudwatchlogs.DeleteLogStreamOutput{}, nil
+}
+
+func TestPurgeStreams(t *testing.T) {
+	now := time.UnixMilli(100000)
+	client := &streamMock{streams: []types.LogStream{
+		{LogStreamName: aws.String("idle"), LastEventTimestamp: aws.Int64(1000)},
+		{LogStreamName: aws.String("active"), LastEventTimestamp: aws.Int64(99000)},
+		{LogStreamName: aws.String("empty-old"), CreationTime: aws.Int64(2000)},
+		{LogStreamName: aws.String("empty-new"), CreationTime: aws.Int64(99500)},
+	}}
+	opt := purgeOptions{group: "g", olderThan: 10 * time.Second}
+
+	var out bytes.Buffer
+	if err := purgeStreams(context.Background(), client, &out, opt, now); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"idle", "empty-old"}; !reflect.DeepEqual(client.deleted, expected) {
+		t.Errorf("deleted: expected=%v got=%v", expected, client.deleted)
+	}
+	if out.String() != "idle\nempty-old\n" {
+		t.Errorf("output: %q", out.String())
+	}
+
+	client.deleted = nil
+	opt.dryRun = true
+	if err := purgeStreams(context.Background(), client, &out, opt, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.deleted) != 0 {
+		t.Errorf("dry run deleted: %v", client.deleted)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags("team=core,env=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]string{"team": "core", "env": "prod"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected=%v got=%v", expected, tags)
+	}
+	if _, err := parseTags("bad"); err == nil {
+		t.Error("expected error for tag without value")
+	}
+}
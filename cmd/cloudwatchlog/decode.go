@@ -0,0 +1,16 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// runDecode expands messages compressed by cwlog.Options.CompressLarge,
+// for example piping the output of the tail command.
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	fs.Parse(args)
+	return cwlog.DecodeLines(os.Stdout, os.Stdin)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlognet"
+)
+
+// runListen ships newline-delimited text received over TCP or UDP.
+func runListen(args []string) error {
+	fs := flag.NewFlagSet("listen", flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	group := fs.String("group", "", "log group, required")
+	stream := fs.String("stream", "", "log stream, defaults to the group")
+	tcpAddr := fs.String("tcp", "", "TCP listen address, like :5170")
+	udpAddr := fs.String("udp", "", "UDP listen address, like :5170")
+	flushInterval := fs.Duration("flush-interval", 5*time.Second, "send buffered lines at least this often")
+	fs.Parse(args)
+
+	if *group == "" {
+		return errors.New("-group is required")
+	}
+	if *tcpAddr == "" && *udpAddr == "" {
+		return errors.New("-tcp or -udp is required")
+	}
+
+	cfg, errConfig := aws.config()
+	if errConfig != nil {
+		return errConfig
+	}
+	cw, errLog := cwlog.New(cwlog.Options{
+		AwsConfig:     cfg,
+		LogGroup:      *group,
+		LogStream:     *stream,
+		Async:         true,
+		FlushInterval: cliFlushInterval(*flushInterval),
+	})
+	if errLog != nil {
+		return errLog
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 2)
+	if *tcpAddr != "" {
+		ln, err := net.Listen("tcp", *tcpAddr)
+		if err != nil {
+			return errors.Join(err, cw.Close())
+		}
+		defer ln.Close()
+		go func() { errs <- cwlognet.ServeTCP(cw, ln) }()
+	}
+	if *udpAddr != "" {
+		pc, err := net.ListenPacket("udp", *udpAddr)
+		if err != nil {
+			return errors.Join(err, cw.Close())
+		}
+		defer pc.Close()
+		go func() { errs <- cwlognet.ServeUDP(cw, pc) }()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	return errors.Join(err, cw.Close())
+}
+
+// cliFlushInterval maps the -flush-interval flag, where zero disables
+// periodic flushing, into cwlog.Options.FlushInterval.
+func cliFlushInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return -1
+	}
+	return d
+}
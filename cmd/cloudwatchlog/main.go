@@ -0,0 +1,90 @@
+// Package main implements the cloudwatchlog command line tool.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/udhos/boilerplate/awsconfig"
+)
+
+// command runs a subcommand with its own arguments.
+type command struct {
+	run   func(args []string) error
+	usage string
+}
+
+var commands = map[string]command{
+	"put":           {runPut, "send stdin lines to a log group"},
+	"listen":        {runListen, "send lines received over TCP or UDP to a log group"},
+	"tail":          {runTail, "print events from a log group, optionally following"},
+	"query":         {runQuery, "run an Insights query and print the results"},
+	"decode":        {runDecode, "expand compressed large messages read from stdin"},
+	"replay":        {runReplay, "send again events saved by a fallback writer"},
+	"create-group":  {runCreateGroup, "create a log group"},
+	"set-retention": {runSetRetention, "change the retention of a log group"},
+	"tag":           {runTag, "add tags to a log group"},
+	"purge-streams": {runPurgeStreams, "delete streams without recent events"},
+	"delete-group":  {runDeleteGroup, "delete a log group and its events"},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("cloudwatchlog: ")
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, found := commands[os.Args[1]]
+	if !found {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s command [flags]\n\ncommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun '%s command -h' for command flags\n", os.Args[0])
+}
+
+// awsFlags are the AWS flags shared by all commands.
+type awsFlags struct {
+	region   string
+	roleArn  string
+	endpoint string
+}
+
+func (f *awsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.region, "region", "", "AWS region, defaults to the SDK configuration")
+	fs.StringVar(&f.roleArn, "role-arn", "", "optional role to assume")
+	fs.StringVar(&f.endpoint, "endpoint-url", "", "optional endpoint URL, like LocalStack")
+}
+
+func (f *awsFlags) config() (aws.Config, error) {
+	out, err := awsconfig.AwsConfig(awsconfig.Options{
+		Region:      f.region,
+		RoleArn:     f.roleArn,
+		EndpointURL: f.endpoint,
+		Printf:      func(string, ...any) {},
+	})
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("aws sdk config error: %w", err)
+	}
+	return out.AwsConfig, nil
+}
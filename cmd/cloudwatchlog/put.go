@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// putCopyBytes is the stdin read size, so lines arriving together are
+// sent in one PutLogEvents call.
+const putCopyBytes = 256 * 1024
+
+// runPut streams stdin lines into a log group, like a shell pipeline sink.
+// Puts are synchronous, so a slow destination holds back reading stdin
+// instead of dropping lines.
+func runPut(args []string) error {
+	fs := flag.NewFlagSet("put", flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	group := fs.String("group", "", "log group, required")
+	stream := fs.String("stream", "", "log stream, defaults to the group")
+	template := fs.String("stream-template", "", "log stream template, see cwlog.Options.LogStreamTemplate")
+	retention := fs.Int("retention", 30, "group retention in days")
+	fs.Parse(args)
+
+	if *group == "" {
+		return errors.New("-group is required")
+	}
+
+	cfg, errConfig := aws.config()
+	if errConfig != nil {
+		return errConfig
+	}
+
+	cw, errLog := cwlog.New(cwlog.Options{
+		AwsConfig:         cfg,
+		LogGroup:          *group,
+		LogStream:         *stream,
+		LogStreamTemplate: *template,
+		RetentionInDays:   int32(*retention),
+	})
+	if errLog != nil {
+		return errLog
+	}
+
+	w := cwlog.NewWriter(cw, cwlog.WriterOptions{})
+	// hide os.File.WriteTo, which would ignore the buffer
+	stdin := struct{ io.Reader }{os.Stdin}
+	_, errCopy := io.CopyBuffer(w, stdin, make([]byte, putCopyBytes))
+
+	return errors.Join(errCopy, w.Close(), cw.Close())
+}
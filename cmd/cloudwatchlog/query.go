@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// runQuery runs an Insights query and prints the results.
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	groups := fs.String("group", "", "comma-separated log groups, required")
+	query := fs.String("query", "", "Insights query, required")
+	since := fs.Duration("since", time.Hour, "query events newer than this")
+	limit := fs.Int("limit", 0, "optional maximum number of rows")
+	output := fs.String("output", "table", "output format: table, json or csv")
+	fs.Parse(args)
+
+	if *groups == "" || *query == "" {
+		return errors.New("-group and -query are required")
+	}
+	format, found := formats[*output]
+	if !found {
+		return fmt.Errorf("unknown output format: %s", *output)
+	}
+
+	cfg, errConfig := aws.config()
+	if errConfig != nil {
+		return errConfig
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	end := time.Now()
+	result, errQuery := cwlog.RunQuery(ctx, cloudwatchlogs.NewFromConfig(cfg), cwlog.QueryOptions{
+		LogGroups: strings.Split(*groups, ","),
+		Query:     *query,
+		Start:     end.Add(-*since),
+		End:       end,
+		Limit:     int32(*limit),
+	})
+	if errQuery != nil {
+		return errQuery
+	}
+
+	return format(os.Stdout, result)
+}
+
+var formats = map[string]func(io.Writer, cwlog.QueryResult) error{
+	"table": writeTable,
+	"json":  writeJSON,
+	"csv":   writeCSV,
+}
+
+func writeTable(w io.Writer, result cwlog.QueryResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(result.Fields, "\t"))
+	for _, row := range result.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, result cwlog.QueryResult) error {
+	rows := make([]map[string]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		obj := map[string]string{}
+		for i, v := range row {
+			if v != "" {
+				obj[result.Fields[i]] = v
+			}
+		}
+		rows = append(rows, obj)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+func writeCSV(w io.Writer, result cwlog.QueryResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write(result.Fields)
+	cw.WriteAll(result.Rows)
+	return cw.Error()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func TestQueryFormats(t *testing.T) {
+	result := cwlog.QueryResult{
+		Fields: []string{"@timestamp", "@message"},
+		Rows:   [][]string{{"t1", "hello, world"}, {"t2", ""}},
+	}
+
+	table := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "@timestamp  @message\nt1          hello, world\nt2          \n"},
+		{"json", "[\n  {\n    \"@message\": \"hello, world\",\n    \"@timestamp\": \"t1\"\n  },\n  {\n    \"@timestamp\": \"t2\"\n  }\n]\n"},
+		{"csv", "@timestamp,@message\nt1,\"hello, world\"\nt2,\n"},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.format)
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := formats[data.format](&out, result); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != data.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", data.expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// runReplay re-ingests events written by cwlog.Options.FallbackWriter.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	group := fs.String("group", "", "log group, required")
+	stream := fs.String("stream", "", "log stream, defaults to the group")
+	template := fs.String("stream-template", "", "log stream template, see cwlog.Options.LogStreamTemplate")
+	fs.Parse(args)
+
+	if *group == "" {
+		return errors.New("-group is required")
+	}
+	if fs.NArg() == 0 {
+		return errors.New("missing fallback file arguments")
+	}
+
+	cfg, errConfig := aws.config()
+	if errConfig != nil {
+		return errConfig
+	}
+
+	cw, errLog := cwlog.New(cwlog.Options{
+		AwsConfig:         cfg,
+		LogGroup:          *group,
+		LogStream:         *stream,
+		LogStreamTemplate: *template,
+	})
+	if errLog != nil {
+		return errLog
+	}
+	defer cw.Close()
+
+	for _, path := range fs.Args() {
+		report, err := cw.ReplayFile(path)
+		fmt.Fprintf(os.Stderr, "%s: replayed=%d expired=%d other_group=%d skipped=%d\n",
+			path, report.Replayed, report.Expired, report.OtherGroup, report.Skipped)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
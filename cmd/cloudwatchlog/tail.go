@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// filterClient is the subset of the CloudWatch Logs client used by tail.
+type filterClient interface {
+	FilterLogEvents(ctx context.Context,
+		params *cloudwatchlogs.FilterLogEventsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+}
+
+type tailOptions struct {
+	group         string
+	streamPrefix  string
+	filterPattern string
+	since         time.Duration
+	follow        bool
+	pollInterval  time.Duration
+}
+
+// runTail prints events from a log group, optionally following new ones.
+func runTail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	var aws awsFlags
+	aws.register(fs)
+	var opt tailOptions
+	fs.StringVar(&opt.group, "group", "", "log group, required")
+	fs.StringVar(&opt.streamPrefix, "stream-prefix", "", "only streams with this name prefix")
+	fs.StringVar(&opt.filterPattern, "filter-pattern", "", "CloudWatch Logs filter pattern")
+	fs.DurationVar(&opt.since, "since", 10*time.Minute, "print events newer than this")
+	fs.BoolVar(&opt.follow, "follow", false, "keep printing new events until interrupted")
+	fs.DurationVar(&opt.pollInterval, "poll-interval", 2*time.Second, "follow polling interval")
+	fs.Parse(args)
+
+	if opt.group == "" {
+		return errors.New("-group is required")
+	}
+
+	cfg, errConfig := aws.config()
+	if errConfig != nil {
+		return errConfig
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return tail(ctx, cloudwatchlogs.NewFromConfig(cfg), os.Stdout, opt, time.Now())
+}
+
+// tail prints matching events since opt.since before now.
+// When following, it polls for new events, skipping the ones
+// already printed at the boundary timestamp.
+func tail(ctx context.Context, client filterClient, w io.Writer, opt tailOptions,
+	now time.Time) error {
+
+	start := now.Add(-opt.since).UnixMilli()
+	seen := map[string]bool{}
+
+	for {
+		var err error
+		start, seen, err = tailOnce(ctx, client, w, opt, start, seen)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil // interrupted
+			}
+			return err
+		}
+		if !opt.follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opt.pollInterval):
+		}
+	}
+}
+
+// tailOnce prints events from start on, returning the next start and the
+// IDs of events printed at that timestamp.
+func tailOnce(ctx context.Context, client filterClient, w io.Writer, opt tailOptions,
+	start int64, seen map[string]bool) (int64, map[string]bool, error) {
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(opt.group),
+		StartTime:    aws.Int64(start),
+	}
+	if opt.streamPrefix != "" {
+		input.LogStreamNamePrefix = aws.String(opt.streamPrefix)
+	}
+	if opt.filterPattern != "" {
+		input.FilterPattern = aws.String(opt.filterPattern)
+	}
+
+	next := start
+	nextSeen := seen
+	pages := cloudwatchlogs.NewFilterLogEventsPaginator(client, input)
+	for pages.HasMorePages() {
+		out, err := pages.NextPage(ctx)
+		if err != nil {
+			return start, seen, fmt.Errorf("FilterLogEvents error: group=%s: %w", opt.group, err)
+		}
+		for _, e := range out.Events {
+			id := aws.ToString(e.EventId)
+			ts := aws.ToInt64(e.Timestamp)
+			if seen[id] {
+				continue
+			}
+			if ts > next {
+				next = ts
+				nextSeen = map[string]bool{}
+			}
+			if ts == next {
+				nextSeen[id] = true
+			}
+			fmt.Fprintf(w, "%s %s %s\n", time.UnixMilli(ts).UTC().Format(time.RFC3339Nano),
+				aws.ToString(e.LogStreamName), aws.ToString(e.Message))
+		}
+	}
+	return next, nextSeen, nil
+}
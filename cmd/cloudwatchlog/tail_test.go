@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// filterMock returns events at or after StartTime.
+type filterMock struct {
+	events []types.FilteredLogEvent
+	calls  int
+}
+
+func (m *filterMock) FilterLogEvents(_ context.Context,
+	params *cloudwatchlogs.FilterLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	m.calls++
+	var out cloudwatchlogs.FilterLogEventsOutput
+	for _, e := range m.events {
+		if *e.Timestamp >= *params.StartTime {
+			out.Events = append(out.Events, e)
+		}
+	}
+	return &out, nil
+}
+
+func event(id string, ts int64, msg string) types.FilteredLogEvent {
+	return types.FilteredLogEvent{
+		EventId:       aws.String(id),
+		Timestamp:     aws.Int64(ts),
+		LogStreamName: aws.String("s"),
+		Message:       aws.String(msg),
+	}
+}
+
+func TestTailFollow(t *testing.T) {
+	now := time.UnixMilli(10000)
+	client := &filterMock{events: []types.FilteredLogEvent{
+		event("1", 1000, "too old"),
+		event("2", 9000, "a"),
+		event("3", 9500, "b"),
+	}}
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	opt := tailOptions{group: "g", since: 5 * time.Second, follow: true, pollInterval: time.Millisecond}
+
+	done := make(chan error)
+	go func() { done <- tail(ctx, client, &out, opt, now) }()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1970-01-01T00:00:09Z s a\n1970-01-01T00:00:09.5Z s b\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+	if client.calls < 2 {
+		t.Errorf("expected polling, calls=%d", client.calls)
+	}
+}
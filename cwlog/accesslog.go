@@ -0,0 +1,100 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogEntry is a parsed Common or Combined Log Format line,
+// as written by Apache httpd and NGINX.
+type AccessLogEntry struct {
+	RemoteAddr string `json:"remote_addr"`
+	Ident      string `json:"ident,omitempty"`
+	User       string `json:"user,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	Request    string `json:"request,omitempty"` // only when not method/path/protocol
+	Status     int    `json:"status"`
+	Bytes      int64  `json:"bytes"`
+	Referer    string `json:"referer,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+
+	// Time is the request time. It becomes the event timestamp.
+	Time time.Time `json:"-"`
+}
+
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+var accessLogPattern = regexp.MustCompile(
+	`^(\S+) (\S+) (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\S+)` +
+		`(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+// ParseAccessLog parses a Common or Combined Log Format line like
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/4.08".
+// Fields holding "-" are left empty.
+func ParseAccessLog(line string) (AccessLogEntry, bool) {
+	m := accessLogPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return AccessLogEntry{}, false
+	}
+	t, errTime := time.Parse(accessLogTimeLayout, m[4])
+	if errTime != nil {
+		return AccessLogEntry{}, false
+	}
+	status, _ := strconv.Atoi(m[6])
+	bytes, _ := strconv.ParseInt(m[7], 10, 64) // "-" means zero
+	e := AccessLogEntry{
+		RemoteAddr: m[1],
+		Ident:      dash(m[2]),
+		User:       dash(m[3]),
+		Status:     status,
+		Bytes:      bytes,
+		Referer:    dash(unescapeQuoted(m[8])),
+		UserAgent:  dash(unescapeQuoted(m[9])),
+		Time:       t,
+	}
+	request := unescapeQuoted(m[5])
+	if parts := strings.Split(request, " "); len(parts) == 3 {
+		e.Method, e.Path, e.Protocol = parts[0], parts[1], parts[2]
+	} else {
+		e.Request = dash(request)
+	}
+	return e, true
+}
+
+// AccessLogEvent converts an access log line into a JSON message timestamped
+// by the request time. ok is false when line is not in access log format.
+func AccessLogEvent(line string) (message string, timestamp time.Time, ok bool) {
+	e, found := ParseAccessLog(line)
+	if !found {
+		return "", time.Time{}, false
+	}
+	data, _ := json.Marshal(e)
+	return string(data), e.Time, true
+}
+
+// PutAccessLog reads r until EOF just like PutReader, converting each access
+// log line into a JSON event with AccessLogEvent. Lines not in access log
+// format are sent as is.
+func (l *Log) PutAccessLog(r io.Reader) error {
+	return l.putReader(r, AccessLogEvent)
+}
+
+func dash(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func unescapeQuoted(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,74 @@
+package cwlog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestParseAccessLog(t *testing.T) {
+	ts := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+
+	table := []struct {
+		name     string
+		line     string
+		ok       bool
+		expected AccessLogEntry
+	}{
+		{"common", `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`, true,
+			AccessLogEntry{RemoteAddr: "127.0.0.1", User: "frank", Method: "GET", Path: "/apache_pb.gif",
+				Protocol: "HTTP/1.0", Status: 200, Bytes: 2326, Time: ts}},
+		{"combined", `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "POST /x?q=\"a\" HTTP/1.1" 404 - "http://example.com/" "Mozilla/4.08 [en]"` + "\n", true,
+			AccessLogEntry{RemoteAddr: "127.0.0.1", Method: "POST", Path: `/x?q="a"`, Protocol: "HTTP/1.1",
+				Status: 404, Referer: "http://example.com/", UserAgent: "Mozilla/4.08 [en]", Time: ts}},
+		{"bad request", `10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "\x16\x03" 400 0 "-" "-"`, true,
+			AccessLogEntry{RemoteAddr: "10.0.0.1", Request: `\x16\x03`, Status: 400, Time: ts}},
+		{"bad time", `127.0.0.1 - - [yesterday] "GET / HTTP/1.0" 200 1`, false, AccessLogEntry{}},
+		{"garbage", "hello world", false, AccessLogEntry{}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			e, ok := ParseAccessLog(data.line)
+			if ok != data.ok {
+				t.Fatalf("ok: expected=%t got=%t", data.ok, ok)
+			}
+			if !e.Time.Equal(data.expected.Time) {
+				t.Errorf("time: expected=%v got=%v", data.expected.Time, e.Time)
+			}
+			e.Time, data.expected.Time = time.Time{}, time.Time{}
+			if e != data.expected {
+				t.Errorf("expected=%+v got=%+v", data.expected, e)
+			}
+		})
+	}
+}
+
+func TestPutAccessLog(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	input := `10.0.0.1 - - [31/May/2024:22:14:15 +0000] "GET / HTTP/1.1" 200 5` + "\nnot an access log\n"
+	if err := cw.PutAccessLog(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	msgs := messages(s)
+	expected := []string{
+		`{"remote_addr":"10.0.0.1","method":"GET","path":"/","protocol":"HTTP/1.1","status":200,"bytes":5}`,
+		"not an access log",
+	}
+	if strings.Join(msgs, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected=%q got=%q", expected, msgs)
+	}
+	if got := aws.ToInt64(s[0].Timestamp); got != time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC).UnixMilli() {
+		t.Errorf("timestamp: got %v", time.UnixMilli(got))
+	}
+	if got := aws.ToInt64(s[1].Timestamp); got != (time.Time{}).UnixMilli() {
+		t.Errorf("fallback timestamp: got %v", time.UnixMilli(got))
+	}
+}
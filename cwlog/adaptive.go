@@ -0,0 +1,78 @@
+package cwlog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// Adaptive batching bounds. See Options.AdaptiveBatching.
+const (
+	// adaptiveMaxScale caps how much flush parameters grow under throttling.
+	adaptiveMaxScale = 16
+
+	// adaptiveHealthySends is how many consecutive successful background
+	// sends shrink the parameters back one step.
+	adaptiveHealthySends = 3
+)
+
+// throttlingCodes are AWS error codes reporting exceeded request quotas.
+var throttlingCodes = map[string]bool{
+	"ThrottlingException":      true,
+	"Throttling":               true,
+	"TooManyRequestsException": true,
+}
+
+// isThrottling reports whether err, possibly joined, holds a throttling error.
+func isThrottling(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && throttlingCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	return HTTPStatus(err) == 429
+}
+
+// adaptive holds the adaptive batching state, guarded by batcher.mu.
+type adaptive struct {
+	scale        int
+	healthy      int
+	baseEvents   int
+	baseBytes    int
+	baseInterval time.Duration
+}
+
+// adapt grows the flush parameters when the last background send was
+// throttled, and shrinks them back after consecutive healthy sends.
+// It reports whether the flush interval changed. The caller must hold mu.
+func (b *batcher) adapt(err error) bool {
+	a := &b.adaptive
+	previous := a.scale
+	switch {
+	case isThrottling(err):
+		a.scale = min(a.scale*2, adaptiveMaxScale)
+		a.healthy = 0
+		b.log.stats.throttledBatches.Add(1)
+	case err == nil:
+		a.healthy++
+		if a.healthy < adaptiveHealthySends {
+			return false
+		}
+		a.healthy = 0
+		a.scale = max(a.scale/2, 1)
+	}
+	if a.scale == previous {
+		return false
+	}
+	b.flushEvents = min(a.baseEvents*a.scale, MaxBatchEvents)
+	b.flushBytes = min(a.baseBytes*a.scale, MaxBatchBytes)
+	b.log.debug("adaptive batching", "group", b.log.options.LogGroup,
+		"scale", a.scale, "flush_events", b.flushEvents,
+		"flush_bytes", b.flushBytes, "flush_interval", b.flushInterval())
+	return a.baseInterval > 0
+}
+
+// flushInterval returns the current flush interval. The caller must hold mu.
+func (b *batcher) flushInterval() time.Duration {
+	return b.adaptive.baseInterval * time.Duration(b.adaptive.scale)
+}
@@ -0,0 +1,101 @@
+package cwlog
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// throttlingClient fails PutLogEvents with ThrottlingException while
+// throttle is set.
+type throttlingClient struct {
+	*cloudWatchLogMock
+	throttle atomic.Bool
+	sent     atomic.Int32
+}
+
+func (c *throttlingClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	defer c.sent.Add(1)
+	if c.throttle.Load() {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	return c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+}
+
+// waitFor polls cond until true or timeout.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAdaptiveBatching(t *testing.T) {
+	client := &throttlingClient{cloudWatchLogMock: newCloudWatchLogMock()}
+	cw, err := New(Options{
+		Client:           client,
+		LogGroup:         "/cloudwatchlogs/group",
+		LogStream:        "/cloudwatchlogs/stream",
+		Async:            true,
+		FlushInterval:    time.Hour, // only sends triggered by priority events
+		FlushEvents:      10,
+		AdaptiveBatching: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+
+	priority := func() {
+		t.Helper()
+		before := client.sent.Load()
+		if err := cw.PutLogEventsPriority([]types.InputLogEvent{newEvent("x", time.Now().UnixMilli())}); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, "send", func() bool { return client.sent.Load() > before })
+	}
+
+	client.throttle.Store(true)
+	priority()
+	waitFor(t, "first throttle", func() bool { return cw.Stats().ThrottledBatches == 1 })
+	priority()
+	waitFor(t, "second throttle", func() bool { return cw.Stats().ThrottledBatches == 2 })
+
+	stats := cw.Stats()
+	if stats.FlushEvents != 40 || stats.FlushInterval != 4*time.Hour {
+		t.Errorf("grown: unexpected flush events=%d interval=%v",
+			stats.FlushEvents, stats.FlushInterval)
+	}
+
+	client.throttle.Store(false)
+	for range adaptiveHealthySends {
+		priority()
+	}
+	waitFor(t, "shrink", func() bool { return cw.Stats().FlushEvents == 20 })
+	if interval := cw.Stats().FlushInterval; interval != 2*time.Hour {
+		t.Errorf("shrunk: unexpected flush interval=%v", interval)
+	}
+}
+
+func TestIsThrottling(t *testing.T) {
+	if !isThrottling(newResponseError("req", 429)) {
+		t.Errorf("HTTP 429 must be throttling")
+	}
+	if isThrottling(newResponseError("req", 500)) {
+		t.Errorf("HTTP 500 must not be throttling")
+	}
+	if !isThrottling(&smithy.GenericAPIError{Code: "ThrottlingException"}) {
+		t.Errorf("ThrottlingException must be throttling")
+	}
+}
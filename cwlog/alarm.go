@@ -0,0 +1,189 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// MetricFilterPutter is implemented by clients supporting PutMetricFilter,
+// like the AWS SDK client. It is optional for Options.Client, required
+// only by ErrorAlarm.
+type MetricFilterPutter interface {
+	PutMetricFilter(ctx context.Context,
+		params *cloudwatchlogs.PutMetricFilterInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutMetricFilterOutput, error)
+}
+
+// AlarmPutter is implemented by CloudWatch clients supporting
+// PutMetricAlarm, like the cloudwatch.Client from the AWS SDK.
+type AlarmPutter interface {
+	PutMetricAlarm(ctx context.Context,
+		params *cloudwatch.PutMetricAlarmInput,
+		optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error)
+}
+
+// ErrorAlarmOptions define the metric filter and alarm created by
+// EnsureErrorAlarm.
+type ErrorAlarmOptions struct {
+	// LogGroup is the monitored group. Required by EnsureErrorAlarm,
+	// defaults to the Log group for ErrorAlarm.
+	LogGroup string
+
+	// FilterName defaults to LogGroup with the "-errors" suffix.
+	FilterName string
+
+	// FilterPattern selects the counted events.
+	// If undefined, defaults to "ERROR", matching events with that term.
+	FilterPattern string
+
+	// MetricNamespace defaults to "LogMetrics".
+	MetricNamespace string
+
+	// MetricName defaults to "ErrorCount".
+	MetricName string
+
+	// AlarmName defaults to FilterName.
+	AlarmName string
+
+	// Threshold is the error count that triggers the alarm within a period.
+	// If undefined, defaults to 1.
+	Threshold float64
+
+	// Period defaults to 5 minutes. It must be a multiple of 60s, as
+	// required by CloudWatch for log metrics.
+	Period time.Duration
+
+	// EvaluationPeriods defaults to 1.
+	EvaluationPeriods int32
+
+	// TopicARN optionally defines the SNS topic notified on alarm.
+	TopicARN string
+}
+
+// ErrorAlarm provisions the metric filter and alarm for the Log group.
+// The client must implement MetricFilterPutter. Groups of class
+// INFREQUENT_ACCESS, either Options.LogGroupClass or the class described
+// when the client implements GroupDescriber, are reported with
+// ErrIncompatibleClass.
+func (l *Log) ErrorAlarm(ctx context.Context, alarms AlarmPutter, options ErrorAlarmOptions) error {
+	putter, ok := l.options.Client.(MetricFilterPutter)
+	if !ok {
+		return fmt.Errorf("error alarm error: client does not support PutMetricFilter")
+	}
+	if options.LogGroup == "" {
+		options.LogGroup = l.options.LogGroup
+	}
+	options, errDefaults := errorAlarmDefaults(options)
+	if errDefaults != nil {
+		return errDefaults
+	}
+	var class types.LogGroupClass
+	if options.LogGroup == l.options.LogGroup {
+		class = l.options.LogGroupClass
+	}
+	if err := checkMetricFilters(ctx, putter, options.LogGroup, class); err != nil {
+		return err
+	}
+	return ensureErrorAlarm(ctx, putter, alarms, options)
+}
+
+// EnsureErrorAlarm creates or updates a metric filter counting error
+// events in a log group, and a CloudWatch alarm on that metric.
+// Both calls are upserts, hence it is safe to call on every startup.
+// When logs implements GroupDescriber, INFREQUENT_ACCESS groups, which do
+// not support metric filters, are reported with ErrIncompatibleClass.
+func EnsureErrorAlarm(ctx context.Context, logs MetricFilterPutter, alarms AlarmPutter, options ErrorAlarmOptions) error {
+	if options.LogGroup == "" {
+		return fmt.Errorf("error alarm error: missing log group")
+	}
+	options, errDefaults := errorAlarmDefaults(options)
+	if errDefaults != nil {
+		return errDefaults
+	}
+	if err := checkMetricFilters(ctx, logs, options.LogGroup, ""); err != nil {
+		return err
+	}
+	return ensureErrorAlarm(ctx, logs, alarms, options)
+}
+
+// ensureErrorAlarm implements EnsureErrorAlarm, after the defaults and
+// the class check.
+func ensureErrorAlarm(ctx context.Context, logs MetricFilterPutter, alarms AlarmPutter, options ErrorAlarmOptions) error {
+	_, errFilter := logs.PutMetricFilter(ctx, &cloudwatchlogs.PutMetricFilterInput{
+		LogGroupName:  aws.String(options.LogGroup),
+		FilterName:    aws.String(options.FilterName),
+		FilterPattern: aws.String(options.FilterPattern),
+		MetricTransformations: []types.MetricTransformation{{
+			MetricNamespace: aws.String(options.MetricNamespace),
+			MetricName:      aws.String(options.MetricName),
+			MetricValue:     aws.String("1"),
+			DefaultValue:    aws.Float64(0),
+		}},
+	})
+	if errFilter != nil {
+		return fmt.Errorf("put metric filter error: group=%s filter=%s: %w",
+			options.LogGroup, options.FilterName, errFilter)
+	}
+
+	input := &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(options.AlarmName),
+		AlarmDescription:   aws.String("Errors logged to " + options.LogGroup),
+		Namespace:          aws.String(options.MetricNamespace),
+		MetricName:         aws.String(options.MetricName),
+		Statistic:          cwtypes.StatisticSum,
+		Period:             aws.Int32(int32(options.Period / time.Second)),
+		EvaluationPeriods:  aws.Int32(options.EvaluationPeriods),
+		Threshold:          aws.Float64(options.Threshold),
+		ComparisonOperator: cwtypes.ComparisonOperatorGreaterThanOrEqualToThreshold,
+		TreatMissingData:   aws.String("notBreaching"),
+	}
+	if options.TopicARN != "" {
+		input.AlarmActions = []string{options.TopicARN}
+	}
+
+	if _, errAlarm := alarms.PutMetricAlarm(ctx, input); errAlarm != nil {
+		return fmt.Errorf("put metric alarm error: alarm=%s: %w",
+			options.AlarmName, errAlarm)
+	}
+
+	return nil
+}
+
+func errorAlarmDefaults(options ErrorAlarmOptions) (ErrorAlarmOptions, error) {
+	if options.FilterName == "" {
+		options.FilterName = options.LogGroup + "-errors"
+	}
+	if options.FilterPattern == "" {
+		options.FilterPattern = "ERROR"
+	}
+	if options.MetricNamespace == "" {
+		options.MetricNamespace = "LogMetrics"
+	}
+	if options.MetricName == "" {
+		options.MetricName = "ErrorCount"
+	}
+	if options.AlarmName == "" {
+		options.AlarmName = options.FilterName
+	}
+	if options.Threshold == 0 {
+		options.Threshold = 1
+	}
+	if options.Period == 0 {
+		options.Period = 5 * time.Minute
+	}
+	if options.Period < 0 || options.Period%time.Minute != 0 {
+		return options, fmt.Errorf("error alarm error: period must be a positive multiple of 60s: %v",
+			options.Period)
+	}
+	if options.EvaluationPeriods == 0 {
+		options.EvaluationPeriods = 1
+	}
+	return options, nil
+}
@@ -0,0 +1,141 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+type metricFilterMock struct {
+	*cloudWatchLogMock
+	filter *cloudwatchlogs.PutMetricFilterInput
+}
+
+func (m *metricFilterMock) PutMetricFilter(_ context.Context,
+	params *cloudwatchlogs.PutMetricFilterInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutMetricFilterOutput, error) {
+	m.filter = params
+	return &cloudwatchlogs.PutMetricFilterOutput{}, nil
+}
+
+type alarmMock struct {
+	alarm *cloudwatch.PutMetricAlarmInput
+	err   error
+}
+
+func (m *alarmMock) PutMetricAlarm(_ context.Context,
+	params *cloudwatch.PutMetricAlarmInput,
+	_ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	m.alarm = params
+	return &cloudwatch.PutMetricAlarmOutput{}, m.err
+}
+
+func TestErrorAlarm(t *testing.T) {
+	client := &metricFilterMock{cloudWatchLogMock: newCloudWatchLogMock()}
+	cw, err := New(Options{
+		Client:    client,
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alarms := &alarmMock{}
+	errAlarm := cw.ErrorAlarm(context.TODO(), alarms, ErrorAlarmOptions{
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:alerts",
+	})
+	if errAlarm != nil {
+		t.Fatal(errAlarm)
+	}
+
+	if client.filter == nil {
+		t.Fatal("metric filter not created")
+	}
+	if g := aws.ToString(client.filter.LogGroupName); g != "/cloudwatchlogs/group" {
+		t.Errorf("unexpected filter group: %s", g)
+	}
+	if p := aws.ToString(client.filter.FilterPattern); p != "ERROR" {
+		t.Errorf("unexpected filter pattern: %s", p)
+	}
+	tr := client.filter.MetricTransformations[0]
+
+	if alarms.alarm == nil {
+		t.Fatal("alarm not created")
+	}
+	a := alarms.alarm
+	if aws.ToString(a.MetricName) != aws.ToString(tr.MetricName) ||
+		aws.ToString(a.Namespace) != aws.ToString(tr.MetricNamespace) {
+		t.Errorf("alarm metric %s/%s differs from filter metric %s/%s",
+			aws.ToString(a.Namespace), aws.ToString(a.MetricName),
+			aws.ToString(tr.MetricNamespace), aws.ToString(tr.MetricName))
+	}
+	if aws.ToInt32(a.Period) != 300 || aws.ToFloat64(a.Threshold) != 1 {
+		t.Errorf("unexpected alarm period=%d threshold=%v",
+			aws.ToInt32(a.Period), aws.ToFloat64(a.Threshold))
+	}
+	if len(a.AlarmActions) != 1 || a.AlarmActions[0] != "arn:aws:sns:us-east-1:123456789012:alerts" {
+		t.Errorf("unexpected alarm actions: %v", a.AlarmActions)
+	}
+}
+
+func TestErrorAlarmErrors(t *testing.T) {
+	cw := newTestLog(t, newCloudWatchLogMock())
+	if err := cw.ErrorAlarm(context.TODO(), &alarmMock{}, ErrorAlarmOptions{}); err == nil {
+		t.Errorf("expected error for client without PutMetricFilter")
+	}
+
+	logs := &metricFilterMock{cloudWatchLogMock: newCloudWatchLogMock()}
+	if err := EnsureErrorAlarm(context.TODO(), logs, &alarmMock{}, ErrorAlarmOptions{}); err == nil {
+		t.Errorf("expected error for missing log group")
+	}
+
+	alarms := &alarmMock{err: errors.New("denied")}
+	if err := EnsureErrorAlarm(context.TODO(), logs, alarms,
+		ErrorAlarmOptions{LogGroup: "group"}); err == nil {
+		t.Errorf("expected alarm error")
+	}
+}
+
+func TestErrorAlarmPeriod(t *testing.T) {
+	table := []struct {
+		name    string
+		period  time.Duration
+		wantErr bool
+	}{
+		{"default", 0, false},
+		{"minute", time.Minute, false},
+		{"hour", time.Hour, false},
+		{"negative", -time.Minute, true},
+		{"sub-second", 500 * time.Millisecond, true},
+		{"not a multiple", 90 * time.Second, true},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			logs := &metricFilterMock{cloudWatchLogMock: newCloudWatchLogMock()}
+			alarms := &alarmMock{}
+			err := EnsureErrorAlarm(context.TODO(), logs, alarms,
+				ErrorAlarmOptions{LogGroup: "group", Period: data.period})
+			if data.wantErr {
+				if err == nil {
+					t.Errorf("expected period error")
+				}
+				if logs.filter != nil || alarms.alarm != nil {
+					t.Errorf("unexpected put with invalid period")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package cwlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ErrBufferFull reports events dropped because the buffered mode queue is full.
+var ErrBufferFull = errors.New("buffer full")
+
+// ErrClosed reports use of a Log after Close.
+var ErrClosed = errors.New("log closed")
+
+// batchPool recycles event slices between flushes, keeping GC pressure flat
+// under sustained load.
+var batchPool = sync.Pool{
+	New: func() any {
+		s := make([]types.InputLogEvent, 0, 1024)
+		return &s
+	},
+}
+
+// bufferPool recycles byte buffers used to render messages and names.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64*1024 {
+		return // let oversized buffers go
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+func getBatch() *[]types.InputLogEvent {
+	return batchPool.Get().(*[]types.InputLogEvent)
+}
+
+func recycleBatch(batch *[]types.InputLogEvent) {
+	clear(*batch) // drop references to messages
+	*batch = (*batch)[:0]
+	batchPool.Put(batch)
+}
+
+// batcher queues events per destination and sends them from a background
+// goroutine. It is shared by a Log, its LevelRouting destinations and its
+// clones, so that multi-stream setups are not serialized behind a single
+// sender: each flush cycle sends destinations in parallel, up to
+// FlushWorkers at a time, each destination handled by one worker in order.
+//
+// Events are queued in two lanes. The high priority lane is sent ahead of
+// the low priority one, and when the buffer is full, high priority events
+// evict queued low priority events instead of being dropped.
+type batcher struct {
+	log         *Log // owner, for options and diagnostics
+	workers     int
+	flushEvents int // per lane threshold triggering a send
+	flushBytes  int
+
+	mu           sync.Mutex
+	pending      map[*core]*destBatch
+	count        int // total queued events
+	countLow     int // queued low priority events
+	bytes        int // total EventSize of queued events
+	peakCount    int // high watermark of count
+	peakBytes    int // high watermark of bytes
+	closed       bool
+	sendErr      error // last background send error, reported by flush
+	adaptive     adaptive
+	retuneTicker bool // flush interval changed by adapt
+
+	ticker   Ticker          // nil when FlushInterval is disabled
+	ctx      context.Context // for background sends, canceled by stop
+	cancel   context.CancelFunc
+	stopReq  chan struct{} // closed on context cancellation
+	stopOnce sync.Once
+	wake     chan struct{}
+	flushReq chan chan error
+	quit     chan struct{}
+	done     chan struct{}
+	closeErr error
+}
+
+// destBatch holds events queued for one destination.
+type destBatch struct {
+	dest *Log
+	high laneBatch
+	low  laneBatch
+}
+
+// laneBatch holds events queued in one priority lane.
+type laneBatch struct {
+	events  *[]types.InputLogEvent
+	bytes   int
+	streams []streamRun    // consecutive events by stream, covering events
+	waiters []chan<- error // notified with the send result, see enqueueWait
+}
+
+// streamRun counts consecutive queued events bound to one stream, the
+// current stream when they were queued, so a rotation while they wait
+// does not move them to the new stream.
+type streamRun struct {
+	stream string // empty for the stream current at send time
+	count  int
+}
+
+// add queues events for stream, reporting whether the lane reached the
+// flush thresholds maxEvents or maxBytes.
+func (lb *laneBatch) add(stream string, events []types.InputLogEvent, maxEvents, maxBytes int) bool {
+	if lb.events == nil {
+		lb.events = getBatch()
+	}
+	*lb.events = append(*lb.events, events...)
+	if last := len(lb.streams) - 1; last >= 0 && lb.streams[last].stream == stream {
+		lb.streams[last].count += len(events)
+	} else {
+		lb.streams = append(lb.streams, streamRun{stream: stream, count: len(events)})
+	}
+	for _, e := range events {
+		lb.bytes += EventSize(e)
+	}
+	return len(*lb.events) >= maxEvents || lb.bytes >= maxBytes
+}
+
+// evict drops up to n of the oldest events, returning how many were dropped.
+func (lb *laneBatch) evict(n int) int {
+	if lb.events == nil {
+		return 0
+	}
+	events := *lb.events
+	n = min(n, len(events))
+	for _, e := range events[:n] {
+		lb.bytes -= EventSize(e)
+	}
+	kept := copy(events, events[n:])
+	clear(events[kept:])
+	*lb.events = events[:kept]
+	for left := n; left > 0; {
+		if run := &lb.streams[0]; run.count > left {
+			run.count -= left
+			break
+		}
+		left -= lb.streams[0].count
+		lb.streams = lb.streams[1:]
+	}
+	return n
+}
+
+func newBatcher(l *Log) *batcher {
+	b := &batcher{
+		log:         l,
+		workers:     max(l.options.FlushWorkers, 1),
+		flushEvents: flushThreshold(l.options.FlushEvents, MaxBatchEvents),
+		flushBytes:  flushThreshold(l.options.FlushBytes, MaxBatchBytes),
+		pending:     map[*core]*destBatch{},
+		wake:        make(chan struct{}, 1),
+		flushReq:    make(chan chan error),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+		stopReq:     make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(l.options.Context)
+	b.adaptive = adaptive{
+		scale:        1,
+		baseEvents:   b.flushEvents,
+		baseBytes:    b.flushBytes,
+		baseInterval: max(l.options.FlushInterval, 0),
+	}
+	if l.options.FlushInterval > 0 {
+		b.ticker = l.options.Clock.NewTicker(l.options.FlushInterval)
+	}
+	go b.run()
+	b.watch(l.options.Context)
+	return b
+}
+
+// watch stops the batcher when ctx is canceled.
+func (b *batcher) watch(ctx context.Context) {
+	if ctx.Done() == nil {
+		return // never canceled
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.stop()
+		case <-b.done:
+		}
+	}()
+}
+
+// stop aborts in-flight sends and makes the background goroutine exit
+// after a final flush bounded by FinalFlushTimeout.
+func (b *batcher) stop() {
+	b.stopOnce.Do(func() {
+		b.cancel()
+		close(b.stopReq)
+	})
+}
+
+// finalFlush sends pending events with a fresh deadline, since the
+// background context was canceled.
+func (b *batcher) finalFlush() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(b.ctx),
+		b.log.options.FinalFlushTimeout)
+	defer cancel()
+	err := b.sendPending(ctx)
+	if err != nil {
+		b.log.warn("final flush failed", "group", b.log.options.LogGroup,
+			"error", err)
+	}
+	return err
+}
+
+// flushThreshold returns v, defaulting to and capped at limit.
+func flushThreshold(v, limit int) int {
+	if v < 1 || v > limit {
+		return limit
+	}
+	return v
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+	defer b.cancel()
+	var tick <-chan time.Time
+	if b.ticker != nil {
+		defer func() { b.ticker.Stop() }() // replaced by retune
+		tick = b.ticker.C()
+	}
+	for {
+		select {
+		case <-b.wake:
+			b.sendBackground()
+			tick = b.retune(tick)
+		case <-tick:
+			b.mu.Lock()
+			empty := b.count == 0
+			b.mu.Unlock()
+			if !empty {
+				b.sendBackground()
+			}
+			tick = b.retune(tick)
+		case reply := <-b.flushReq:
+			err := b.sendPending(b.ctx)
+			b.mu.Lock()
+			if err == nil {
+				err = b.sendErr
+			}
+			b.sendErr = nil
+			b.mu.Unlock()
+			reply <- err
+		case <-b.quit:
+			b.closeErr = b.sendPending(b.ctx)
+			return
+		case <-b.stopReq:
+			b.closeErr = b.finalFlush()
+			return
+		}
+	}
+}
+
+// sendBackground sends pending events, retaining any error for flush.
+func (b *batcher) sendBackground() {
+	err := b.sendPending(b.ctx)
+	if err != nil {
+		b.log.warn("background send failed", "group", b.log.options.LogGroup,
+			"error", err)
+	}
+	b.mu.Lock()
+	if err != nil {
+		b.sendErr = err
+	}
+	if b.log.options.AdaptiveBatching && b.adapt(err) {
+		b.retuneTicker = true
+	}
+	b.mu.Unlock()
+}
+
+// retune replaces the ticker after adaptive batching changed the flush
+// interval, returning the channel to select on.
+func (b *batcher) retune(tick <-chan time.Time) <-chan time.Time {
+	b.mu.Lock()
+	retune := b.retuneTicker
+	b.retuneTicker = false
+	interval := b.flushInterval()
+	b.mu.Unlock()
+	if !retune || b.ticker == nil {
+		return tick
+	}
+	b.ticker.Stop()
+	b.ticker = b.log.options.Clock.NewTicker(interval)
+	return b.ticker.C()
+}
+
+// enqueue queues events for the destination dest.
+// High priority events trigger an immediate send.
+func (b *batcher) enqueue(dest *Log, events []types.InputLogEvent, priority bool) error {
+	return b.enqueueLane(dest, events, priority, nil)
+}
+
+// enqueueWait queues events in the high priority lane for dest,
+// returning a channel receiving the result of sending them.
+func (b *batcher) enqueueWait(dest *Log, events []types.InputLogEvent) (<-chan error, error) {
+	done := make(chan error, 1)
+	if err := b.enqueueLane(dest, events, true, done); err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// enqueueLane implements enqueue, registering done, when not nil, to
+// receive the send result if all events were accepted.
+func (b *batcher) enqueueLane(dest *Log, events []types.InputLogEvent, priority bool, done chan<- error) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+
+	room := b.log.options.BufferEvents - b.count
+	if priority && room < len(events) {
+		room += b.evictLow(len(events) - max(room, 0))
+	}
+	accepted := max(min(room, len(events)), 0)
+
+	// a template error is reported when sending to the current stream
+	stream, _ := dest.renderStream(&dest.queueStream)
+
+	batch, found := b.pending[dest.core]
+	if !found && accepted > 0 {
+		batch = &destBatch{dest: dest}
+		b.pending[dest.core] = batch
+	}
+
+	var wake bool
+	if accepted > 0 {
+		b.count += accepted
+		for _, e := range events[:accepted] {
+			b.bytes += EventSize(e)
+		}
+		b.peakCount = max(b.peakCount, b.count)
+		b.peakBytes = max(b.peakBytes, b.bytes)
+		if priority {
+			batch.high.add(stream, events[:accepted], b.flushEvents, b.flushBytes)
+			if done != nil && accepted == len(events) {
+				batch.high.waiters = append(batch.high.waiters, done)
+			}
+			wake = true
+		} else {
+			b.countLow += accepted
+			wake = batch.low.add(stream, events[:accepted], b.flushEvents, b.flushBytes)
+		}
+	}
+	b.mu.Unlock()
+
+	if wake {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	if dropped := len(events) - accepted; dropped > 0 {
+		b.log.stats.droppedEvents.Add(int64(dropped))
+		b.log.warn("buffer full, events dropped", "group", dest.options.LogGroup,
+			"dropped", dropped, "priority", priority)
+		return fmt.Errorf("%w: dropped %d events", ErrBufferFull, dropped)
+	}
+	return nil
+}
+
+// evictLow drops up to n queued low priority events, oldest first,
+// returning how many were dropped. The caller must hold mu.
+func (b *batcher) evictLow(n int) int {
+	var evicted int
+	for _, batch := range b.pending {
+		if evicted == n || b.countLow == 0 {
+			break
+		}
+		before := batch.low.bytes
+		evicted += batch.low.evict(n - evicted)
+		b.bytes -= before - batch.low.bytes
+	}
+	if evicted > 0 {
+		b.count -= evicted
+		b.countLow -= evicted
+		b.log.stats.droppedEvents.Add(int64(evicted))
+		b.log.warn("buffer full, low priority events evicted",
+			"group", b.log.options.LogGroup, "evicted", evicted)
+	}
+	return evicted
+}
+
+// take removes all pending batches.
+func (b *batcher) take() []*destBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	batches := make([]*destBatch, 0, len(b.pending))
+	for _, batch := range b.pending {
+		batches = append(batches, batch)
+	}
+	clear(b.pending)
+	b.count = 0
+	b.countLow = 0
+	b.bytes = 0
+	return batches
+}
+
+// sendPending sends the high priority lane of every destination, then
+// the low priority lane. It is only called from the background goroutine.
+func (b *batcher) sendPending(ctx context.Context) error {
+	batches := b.take()
+	errHigh := b.sendLane(ctx, batches, func(d *destBatch) *laneBatch { return &d.high })
+	errLow := b.sendLane(ctx, batches, func(d *destBatch) *laneBatch { return &d.low })
+	return errors.Join(errHigh, errLow)
+}
+
+// sendLane sends one lane of each destination, in parallel up to workers.
+func (b *batcher) sendLane(ctx context.Context, batches []*destBatch, lane func(*destBatch) *laneBatch) error {
+	if b.workers == 1 || len(batches) < 2 {
+		var errs []error
+		for _, batch := range batches {
+			errs = append(errs, sendBatch(ctx, batch.dest, lane(batch)))
+		}
+		return errors.Join(errs...)
+	}
+
+	queue := make(chan *destBatch, len(batches))
+	for _, batch := range batches {
+		queue <- batch
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for range min(b.workers, len(batches)) {
+		wg.Go(func() {
+			for batch := range queue {
+				if err := sendBatch(ctx, batch.dest, lane(batch)); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// sendBatch sends the events queued in one lane for dest, in order,
+// split within the AWS batch limits. Events queued before a stream
+// rotation are sent to the old stream before switching to the new one.
+// MonotonicTimestamps is enforced here, per stream, across flushes.
+func sendBatch(ctx context.Context, dest *Log, lb *laneBatch) error {
+	if lb.events == nil {
+		return nil
+	}
+	defer recycleBatch(lb.events)
+
+	var errs []error
+	events := *lb.events
+	for _, run := range lb.streams {
+		runEvents := events[:run.count]
+		events = events[run.count:]
+		if dest.options.MonotonicTimestamps {
+			runEvents = dest.monotonic(run.stream, runEvents)
+		}
+		for len(runEvents) > 0 {
+			n := batchLen(runEvents)
+			if _, err := dest.sendLockedTo(ctx, run.stream, runEvents[:n]); err != nil {
+				errs = append(errs, err)
+			}
+			runEvents = runEvents[n:]
+		}
+	}
+	err := errors.Join(errs...)
+	for _, w := range lb.waiters {
+		w <- err // buffered
+	}
+	return err
+}
+
+// monotonic raises the timestamps of events sent to stream to at least
+// those of events sent to it before, so that flushed batches stay
+// non-decreasing whatever the order concurrent callers and the priority
+// lanes queued them in. It is only called from the batcher goroutine.
+func (l *Log) monotonic(stream string, events []types.InputLogEvent) []types.InputLogEvent {
+	if stream != l.monotonicStream {
+		l.monotonicStream = stream
+		l.monotonicFloor = 0
+	}
+	events, l.monotonicFloor = raiseTimestamps(events, l.monotonicFloor)
+	return events
+}
+
+// batchLen finds how many leading events fit in one PutLogEvents call.
+func batchLen(events []types.InputLogEvent) int {
+	var size int
+	var first, last int64
+	for i, e := range events {
+		size += EventSize(e)
+		ts := aws.ToInt64(e.Timestamp)
+		if i == 0 {
+			first, last = ts, ts
+		}
+		first, last = min(first, ts), max(last, ts)
+		if i == MaxBatchEvents || (i > 0 && size > MaxBatchBytes) ||
+			last-first > MaxBatchSpan.Milliseconds() {
+			return i
+		}
+	}
+	return len(events)
+}
+
+func (b *batcher) flush() error {
+	reply := make(chan error, 1)
+	select {
+	case b.flushReq <- reply:
+		return <-reply
+	case <-b.done:
+		return ErrClosed
+	}
+}
+
+func (b *batcher) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		<-b.done // stopping after context cancellation
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.quit)
+	<-b.done
+	return b.closeErr
+}
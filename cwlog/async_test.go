@@ -0,0 +1,307 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func newAsyncLog(t *testing.T, client *cloudWatchLogMock, bufferEvents int) *Log {
+	t.Helper()
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		Async:         true,
+		BufferEvents:  bufferEvents,
+		FlushInterval: -1, // only explicit flushes
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cw
+}
+
+func TestAsyncFlush(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+
+	if err := cw.PutSimple("test 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("test 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 2 {
+		t.Fatalf("log lines: expected=2 found=%d", len(s))
+	}
+
+	if err := cw.PutSimple("test 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s = client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 3 {
+		t.Fatalf("log lines: expected=3 found=%d", len(s))
+	}
+
+	if err := cw.PutSimple("test 4"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+}
+
+func TestAsyncBufferFull(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 2)
+	defer cw.Close()
+
+	for i := range 2 {
+		if err := cw.PutSimple(fmt.Sprintf("test %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.PutSimple("overflow"); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got: %v", err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 2 {
+		t.Fatalf("log lines: expected=2 found=%d", len(s))
+	}
+}
+
+func TestAsyncBufferStats(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+	defer cw.Close()
+
+	for _, msg := range []string{"test 1", "test 2"} {
+		if err := cw.PutSimple(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := 2 * EventSize(newEvent("test 1", 0))
+	s := cw.Stats()
+	if s.BufferedEvents != 2 || s.BufferedBytes != size {
+		t.Fatalf("buffered: expected=2/%d got=%d/%d", size,
+			s.BufferedEvents, s.BufferedBytes)
+	}
+
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s = cw.Stats()
+	if s.BufferedEvents != 0 || s.BufferedBytes != 0 {
+		t.Fatalf("buffered after flush: expected=0/0 got=%d/%d",
+			s.BufferedEvents, s.BufferedBytes)
+	}
+	if s.PeakBufferedEvents != 2 || s.PeakBufferedBytes != size {
+		t.Fatalf("peak: expected=2/%d got=%d/%d", size,
+			s.PeakBufferedEvents, s.PeakBufferedBytes)
+	}
+}
+
+func TestAsyncSplitBatches(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+	defer cw.Close()
+
+	events := make([]types.InputLogEvent, 2*MaxBatchEvents+1)
+	for i := range events {
+		events[i] = types.InputLogEvent{
+			Message:   aws.String("x"),
+			Timestamp: aws.Int64(0),
+		}
+	}
+	if err := cw.PutLogEvents(events); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != len(events) {
+		t.Fatalf("log lines: expected=%d found=%d", len(events), len(s))
+	}
+	if client.puts < 3 {
+		t.Fatalf("put calls: expected>=3 found=%d", client.puts)
+	}
+}
+
+func TestBatchLen(t *testing.T) {
+	big := string(make([]byte, MaxBatchBytes/2))
+	events := []types.InputLogEvent{
+		{Message: aws.String(big)},
+		{Message: aws.String(big)},
+		{Message: aws.String("small")},
+	}
+	if n := batchLen(events); n != 1 {
+		t.Fatalf("batch len: expected=1 got=%d", n)
+	}
+
+	day := MaxBatchSpan.Milliseconds()
+	spanning := []types.InputLogEvent{newEvent("a", day), newEvent("b", 0), newEvent("c", day+1)}
+	if n := batchLen(spanning); n != 2 {
+		t.Fatalf("batch len over 24h: expected=2 got=%d", n)
+	}
+}
+
+func TestAsyncPriorityEviction(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:       client,
+		Now:          func() time.Time { return time.Time{} },
+		LogGroup:     "/cloudwatchlogs/group",
+		LogStream:    "/cloudwatchlogs/stream",
+		Async:        true,
+		BufferEvents: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hold the sender so the buffer fills up
+	cw.sendMu.Lock()
+
+	for i := range 3 {
+		if err := cw.Info("bulk", "i", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Info("overflow"); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got: %v", err)
+	}
+	if err := cw.Error("important"); err != nil {
+		t.Fatalf("priority event dropped: %v", err)
+	}
+
+	cw.sendMu.Unlock()
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 3 {
+		t.Fatalf("log lines: expected=3 found=%d: %v", len(msgs), msgs)
+	}
+	if msgs[0] != `{"level":"ERROR","msg":"important"}` {
+		t.Errorf("priority event not sent first: %v", msgs)
+	}
+	if dropped := cw.Stats().DroppedEvents; dropped != 2 {
+		t.Errorf("dropped: expected=2 got=%d", dropped)
+	}
+}
+
+func TestAsyncStreamRotation(t *testing.T) {
+	client := newCloudWatchLogMock()
+	var hour atomic.Int64
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{}.Add(time.Duration(hour.Load()) * time.Hour) },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		Async:         true,
+		FlushInterval: -1, // only explicit flushes
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 3 {
+		if i == 2 {
+			hour.Store(1) // rotate while events are buffered
+		}
+		if err := cw.PutSimple(fmt.Sprintf("test %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	group := client.groups["/cloudwatchlogs/group"]
+	if msgs := messages(group["/cloudwatchlogs/stream-0001-01-01-00"]); len(msgs) != 2 {
+		t.Errorf("old stream: %v", msgs)
+	}
+	if msgs := messages(group["/cloudwatchlogs/stream-0001-01-01-01"]); len(msgs) != 1 || msgs[0] != "test 2" {
+		t.Errorf("new stream: %v", msgs)
+	}
+}
+
+func TestLaneBatchEvictStreams(t *testing.T) {
+	var lb laneBatch
+	lb.add("a", []types.InputLogEvent{newEvent("1", 0), newEvent("2", 0)}, 100, MaxBatchBytes)
+	lb.add("b", []types.InputLogEvent{newEvent("3", 0), newEvent("4", 0)}, 100, MaxBatchBytes)
+	if n := lb.evict(3); n != 3 {
+		t.Fatalf("evicted: expected=3 got=%d", n)
+	}
+	if len(lb.streams) != 1 || lb.streams[0] != (streamRun{stream: "b", count: 1}) {
+		t.Errorf("streams: %+v", lb.streams)
+	}
+	recycleBatch(lb.events)
+}
+
+func TestAsyncFlushThresholds(t *testing.T) {
+	table := []struct {
+		name        string
+		flushEvents int
+		flushBytes  int
+	}{
+		{"events", 3, 0},
+		{"bytes", 0, 3 * (EventOverhead + len("test 0"))},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			client := newCloudWatchLogMock()
+			cw, err := New(Options{
+				Client:        client,
+				Now:           func() time.Time { return time.Time{} },
+				LogGroup:      "/cloudwatchlogs/group",
+				LogStream:     "/cloudwatchlogs/stream",
+				Async:         true,
+				FlushInterval: -1,
+				FlushEvents:   data.flushEvents,
+				FlushBytes:    data.flushBytes,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cw.Close()
+
+			for i := range 3 {
+				if err := cw.PutSimple(fmt.Sprintf("test %d", i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				client.mu.Lock()
+				puts := client.puts
+				client.mu.Unlock()
+				if puts == 1 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("threshold did not trigger a send")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
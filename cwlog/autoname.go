@@ -0,0 +1,40 @@
+package cwlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// replaced in tests
+var (
+	executable = os.Executable
+	hostname   = os.Hostname
+)
+
+// autoName fills in LogGroup from the executable name, as "/app/<name>",
+// and LogStream from the host name, when they are undefined.
+func autoName(options *Options) error {
+	if options.LogGroup == "" {
+		exe, err := executable()
+		if err != nil {
+			return fmt.Errorf("auto name: executable: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(exe), ".exe")
+		options.LogGroup = "/app/" + strings.Map(func(r rune) rune {
+			if validGroupRune(r) {
+				return r
+			}
+			return '_'
+		}, name)
+	}
+	if options.LogStream == "" {
+		host, err := hostname()
+		if err != nil {
+			return fmt.Errorf("auto name: hostname: %w", err)
+		}
+		options.LogStream = strings.NewReplacer(":", "_", "*", "_").Replace(host)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package cwlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoName(t *testing.T) {
+	savedExecutable, savedHostname := executable, hostname
+	t.Cleanup(func() { executable, hostname = savedExecutable, savedHostname })
+	executable = func() (string, error) { return "/usr/local/bin/my app.exe", nil }
+	hostname = func() (string, error) { return "ip-10-0-0-1:8080", nil }
+
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		AutoName: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if s := client.groups["/app/my_app"]["ip-10-0-0-1_8080-0001-01-01-00"]; len(s) != 1 {
+		t.Errorf("unexpected destinations: %v", client.groups)
+	}
+
+	cw, err = New(Options{Client: client, LogGroup: "/explicit", AutoName: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cw.options.LogGroup != "/explicit" || cw.options.LogStream != "ip-10-0-0-1_8080" {
+		t.Errorf("unexpected names: group=%s stream=%s", cw.options.LogGroup, cw.options.LogStream)
+	}
+}
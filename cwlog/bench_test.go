@@ -0,0 +1,116 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// nullClient accepts every call without doing anything,
+// isolating the cost of the put path itself.
+type nullClient struct{}
+
+var (
+	nullCreateGroupOutput  = &cloudwatchlogs.CreateLogGroupOutput{}
+	nullRetentionOutput    = &cloudwatchlogs.PutRetentionPolicyOutput{}
+	nullCreateStreamOutput = &cloudwatchlogs.CreateLogStreamOutput{}
+	nullPutOutput          = &cloudwatchlogs.PutLogEventsOutput{}
+)
+
+func (nullClient) CreateLogGroup(_ context.Context,
+	_ *cloudwatchlogs.CreateLogGroupInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nullCreateGroupOutput, nil
+}
+
+func (nullClient) PutRetentionPolicy(_ context.Context,
+	_ *cloudwatchlogs.PutRetentionPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return nullRetentionOutput, nil
+}
+
+func (nullClient) CreateLogStream(_ context.Context,
+	_ *cloudwatchlogs.CreateLogStreamInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	return nullCreateStreamOutput, nil
+}
+
+func (nullClient) PutLogEvents(_ context.Context,
+	_ *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return nullPutOutput, nil
+}
+
+func newBenchLog(b *testing.B) *Log {
+	b.Helper()
+	cw, err := New(Options{
+		Client:   nullClient{},
+		LogGroup: "/cloudwatchlogs/bench",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return cw
+}
+
+func BenchmarkPutSimple(b *testing.B) {
+	cw := newBenchLog(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := cw.PutSimple("benchmark line"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPutLogEvents(b *testing.B) {
+	for _, size := range []int{1, 100, 1000} {
+		b.Run(fmt.Sprintf("events=%d", size), func(b *testing.B) {
+			cw := newBenchLog(b)
+			now := time.Now().UnixMilli()
+			events := make([]types.InputLogEvent, size)
+			for i := range events {
+				events[i] = types.InputLogEvent{
+					Message:   aws.String(fmt.Sprintf("benchmark line %d", i)),
+					Timestamp: aws.Int64(now),
+				}
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := cw.PutLogEvents(events); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPutSimpleAsync(b *testing.B) {
+	cw, err := New(Options{
+		Client:   nullClient{},
+		LogGroup: "/cloudwatchlogs/bench",
+		Async:    true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cw.Close()
+	b.ReportAllocs()
+	var i int
+	for b.Loop() {
+		if err := cw.PutSimple("benchmark line"); err != nil {
+			b.Fatal(err)
+		}
+		i++
+		if i%MaxBatchEvents == 0 {
+			if err := cw.Flush(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
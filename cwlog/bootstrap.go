@@ -0,0 +1,85 @@
+package cwlog
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// bootstrapped records groups already created with the retention set, so
+// that many Log instances targeting the same group, like per-module
+// loggers, bootstrap it at most once per process.
+var bootstrapped sync.Map // bootstrapKey -> struct{}
+
+// bootstrapKey identifies a group bootstrap. The account is not known
+// without an extra STS call, hence it is approximated by the client, or
+// the credentials source for clients created from AwsConfig, plus the
+// region and endpoint.
+type bootstrapKey struct {
+	source    any
+	region    string
+	endpoint  string
+	group     string
+	class     types.LogGroupClass
+	retention int32
+}
+
+// newBootstrapKey returns the cache key for options, and false when the
+// client or credentials source cannot be used as a key.
+func newBootstrapKey(options Options, clientFromConfig bool) (bootstrapKey, bool) {
+	source := any(options.Client)
+	if clientFromConfig {
+		source = options.AwsConfig.Credentials
+	}
+	if source == nil || !reflect.TypeOf(source).Comparable() {
+		return bootstrapKey{}, false
+	}
+	return bootstrapKey{
+		source:    source,
+		region:    options.AwsConfig.Region,
+		endpoint:  aws.ToString(options.AwsConfig.BaseEndpoint),
+		group:     options.LogGroup,
+		class:     options.LogGroupClass,
+		retention: options.RetentionInDays,
+	}, true
+}
+
+// forgetGroup drops cached bootstraps of the group, for example after
+// finding it was deleted.
+func forgetGroup(group string) {
+	bootstrapped.Range(func(k, _ any) bool {
+		if k.(bootstrapKey).group == group {
+			bootstrapped.Delete(k)
+		}
+		return true
+	})
+}
+
+// bootstrapGroup creates the group, if missing, and sets its retention.
+// clientFromConfig reports options.Client was created from AwsConfig.
+func bootstrapGroup(options Options, clientFromConfig bool) error {
+	key, cacheable := newBootstrapKey(options, clientFromConfig)
+	if cacheable {
+		if _, found := bootstrapped.Load(key); found {
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(options.Context, options.BootstrapTimeout)
+	defer cancel()
+	if err := EnsureGroup(ctx, options.Client, GroupSpec{
+		Name:            options.LogGroup,
+		Class:           options.LogGroupClass,
+		RetentionInDays: options.RetentionInDays,
+	}); err != nil {
+		return err
+	}
+
+	if cacheable {
+		bootstrapped.Store(key, struct{}{})
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// countingClient counts group bootstrap calls.
+type countingClient struct {
+	*cloudWatchLogMock
+	creates int
+}
+
+func (c *countingClient) CreateLogGroup(ctx context.Context,
+	params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	c.creates++
+	return c.cloudWatchLogMock.CreateLogGroup(ctx, params, optFns...)
+}
+
+func TestBootstrapCache(t *testing.T) {
+	client := &countingClient{cloudWatchLogMock: newCloudWatchLogMock()}
+	for _, stream := range []string{"module-a", "module-b", "module-c"} {
+		if _, err := New(Options{Client: client, LogGroup: "/bootstrap/cache", LogStream: stream}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.creates != 1 {
+		t.Errorf("group bootstraps: expected=1 got=%d", client.creates)
+	}
+
+	if _, err := New(Options{Client: client, LogGroup: "/bootstrap/cache", RetentionInDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if client.creates != 2 {
+		t.Errorf("different retention must bootstrap again: creates=%d", client.creates)
+	}
+
+	other := &countingClient{cloudWatchLogMock: newCloudWatchLogMock()}
+	if _, err := New(Options{Client: other, LogGroup: "/bootstrap/cache"}); err != nil {
+		t.Fatal(err)
+	}
+	if other.creates != 1 {
+		t.Errorf("other client must bootstrap: creates=%d", other.creates)
+	}
+
+	forgetGroup("/bootstrap/cache")
+	if _, err := New(Options{Client: client, LogGroup: "/bootstrap/cache"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.creates != 3 {
+		t.Errorf("forgotten group must bootstrap again: creates=%d", client.creates)
+	}
+}
+
+// hangingClient blocks group creation until the context is done.
+type hangingClient struct {
+	*cloudWatchLogMock
+}
+
+func (c *hangingClient) CreateLogGroup(ctx context.Context,
+	_ *cloudwatchlogs.CreateLogGroupInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBootstrapTimeout(t *testing.T) {
+	client := &hangingClient{cloudWatchLogMock: newCloudWatchLogMock()}
+	_, err := New(Options{Client: client, LogGroup: "/bootstrap/timeout", BootstrapTimeout: 10 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New(Options{Client: client, LogGroup: "/bootstrap/timeout", Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got: %v", err)
+	}
+}
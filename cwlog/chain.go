@@ -0,0 +1,288 @@
+package cwlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ChainSeparator separates the message from its hash in AuditChain mode.
+// The rest of the message is the hex encoded chain hash.
+const ChainSeparator = " cwlog-chain:"
+
+// ErrChainBroken reports a stream failing audit chain verification.
+var ErrChainBroken = errors.New("audit chain broken")
+
+// auditChain is the hash chain state of one stream.
+type auditChain struct {
+	stream        string
+	prev          [sha256.Size]byte
+	lastTimestamp int64
+}
+
+// chainGenesis binds a chain to its stream, so events copied into
+// another stream fail verification.
+func chainGenesis(stream string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(stream))
+}
+
+// chainHash returns SHA-256(prev + message).
+func chainHash(prev [sha256.Size]byte, message string) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(prev[:])
+	h.Write([]byte(message))
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func newAuditChain(stream string) *auditChain {
+	return &auditChain{stream: stream, prev: chainGenesis(stream)}
+}
+
+// link returns a copy of events with the chain hash appended to every
+// message, and the chain state after each of them, leaving c unchanged
+// so a failed put can be retried from the same state. Timestamps lower
+// than the previous event are raised to it, because streams are read
+// back in timestamp order.
+func (c auditChain) link(events []types.InputLogEvent) ([]types.InputLogEvent, []auditChain) {
+	result := make([]types.InputLogEvent, len(events))
+	states := make([]auditChain, len(events))
+	for i, e := range events {
+		msg := aws.ToString(e.Message)
+		c.prev = chainHash(c.prev, msg)
+		ts := max(aws.ToInt64(e.Timestamp), c.lastTimestamp)
+		c.lastTimestamp = ts
+		result[i] = types.InputLogEvent{
+			Message:   aws.String(msg + ChainSeparator + hex.EncodeToString(c.prev[:])),
+			Timestamp: aws.Int64(ts),
+		}
+		states[i] = c
+	}
+	return result, states
+}
+
+// partialPutError reports a batch failing after its leading events were
+// delivered, so they are not counted as failed nor written to the
+// FallbackWriter.
+type partialPutError struct {
+	sent int
+	err  error
+}
+
+func (e *partialPutError) Error() string { return e.err.Error() }
+
+func (e *partialPutError) Unwrap() error { return e.err }
+
+// putChained links events into the chain of stream and sends them, in
+// parts when the chain hashes push them over the PutLogEvents limits.
+// Each delivered part advances the chain, so a failed part is retried
+// from the state after the previous one. The caller must hold sendMu.
+func (l *Log) putChained(ctx context.Context, stream string, events []types.InputLogEvent) (PutResult, error) {
+	if l.chain == nil || l.chain.stream != stream {
+		l.chain = newAuditChain(stream)
+	}
+	events = l.dropExpired(events)
+	result := PutResult{Stream: stream}
+	for sent := 0; sent < len(events); {
+		linked, states := l.chain.link(events[sent:])
+		n := batchLen(linked)
+		part, err := l.putBatch(ctx, stream, linked[:n])
+		if err != nil {
+			if sent > 0 {
+				err = &partialPutError{sent: sent, err: err}
+			}
+			return result, err
+		}
+		l.commitChain(stream, states[:n], part.Rejected)
+		result = mergePutResults(result, part, sent)
+		sent += n
+	}
+	return result, nil
+}
+
+// dropExpired removes events CloudWatch Logs would reject as older than
+// expiredCutoff, even once raised to the last chain timestamp, since the
+// hashes following them would not verify. The caller slice is copied
+// only when some event is dropped. The caller must hold sendMu.
+func (l *Log) dropExpired(events []types.InputLogEvent) []types.InputLogEvent {
+	cutoff := l.expiredCutoff(l.options.Now())
+	if l.chain.lastTimestamp >= cutoff {
+		return events // all raised within the window
+	}
+	var kept []types.InputLogEvent
+	for i, e := range events {
+		if aws.ToInt64(e.Timestamp) >= cutoff {
+			if kept != nil {
+				kept = append(kept, e)
+			}
+			continue
+		}
+		if kept == nil {
+			kept = make([]types.InputLogEvent, i, len(events))
+			copy(kept, events[:i])
+		}
+	}
+	if kept == nil {
+		return events
+	}
+	dropped := len(events) - len(kept)
+	l.stats.expiredEvents.Add(int64(dropped))
+	l.warn("audit chain dropped expired events", "group", l.options.LogGroup,
+		"stream", l.chain.stream, "events", dropped)
+	return kept
+}
+
+// commitChain advances the chain over a delivered part, given the chain
+// state after each of its events. A too new tail rejected by CloudWatch
+// Logs is left out. A rejected head cannot be, since the hashes after it
+// cover it, and is reported as a chain break.
+func (l *Log) commitChain(stream string, states []auditChain, rejected *RejectedInfo) {
+	accepted := len(states)
+	if rejected != nil {
+		if rejected.TooNewStartIndex >= 0 {
+			accepted = min(accepted, rejected.TooNewStartIndex)
+		}
+		if rejected.TooOldEndIndex > 0 || rejected.ExpiredEndIndex >= 0 {
+			l.warn("audit chain broken by rejected events", "group", l.options.LogGroup,
+				"stream", stream)
+		}
+	}
+	if accepted > 0 {
+		*l.chain = states[accepted-1]
+	}
+}
+
+// mergePutResults combines the results of consecutive parts of a batch,
+// where the second part started at index offset.
+func mergePutResults(first, second PutResult, offset int) PutResult {
+	result := PutResult{
+		Stream: second.Stream,
+		Events: first.Events + second.Events,
+		Bytes:  first.Bytes + second.Bytes,
+	}
+	if first.Rejected == nil && second.Rejected == nil {
+		return result
+	}
+	merged := RejectedInfo{TooOldEndIndex: -1, TooNewStartIndex: -1, ExpiredEndIndex: -1}
+	if r := first.Rejected; r != nil {
+		merged = *r
+	}
+	if r := second.Rejected; r != nil {
+		if r.TooOldEndIndex > 0 {
+			merged.TooOldEndIndex = r.TooOldEndIndex + offset
+		}
+		if r.ExpiredEndIndex >= 0 {
+			merged.ExpiredEndIndex = r.ExpiredEndIndex + offset
+		}
+		if merged.TooNewStartIndex < 0 && r.TooNewStartIndex >= 0 {
+			merged.TooNewStartIndex = r.TooNewStartIndex + offset
+		}
+	}
+	result.Rejected = &merged
+	return result
+}
+
+// ChainReport summarizes an audit chain verification.
+type ChainReport struct {
+	// Events counts verified events.
+	Events int
+
+	// Segments counts chain starts. Each writer restart on the same
+	// stream begins a new segment, so more segments than restarts
+	// means events were removed right before a segment start.
+	Segments int
+}
+
+// ChainVerifier checks the AuditChain hashes of one stream, fed with
+// the messages in stream order. It is useful for streams exported out
+// of CloudWatch Logs; see VerifyChain for reading a stream directly.
+type ChainVerifier struct {
+	genesis [sha256.Size]byte
+	prev    [sha256.Size]byte
+	report  ChainReport
+}
+
+// NewChainVerifier creates a verifier for stream.
+func NewChainVerifier(stream string) *ChainVerifier {
+	genesis := chainGenesis(stream)
+	return &ChainVerifier{genesis: genesis, prev: genesis}
+}
+
+// Verify checks the next message, returning it without the chain hash.
+// A message following the chain or starting a new segment is accepted.
+func (v *ChainVerifier) Verify(message string) (string, error) {
+	n := v.report.Events + 1
+	i := strings.LastIndex(message, ChainSeparator)
+	if i < 0 {
+		return "", fmt.Errorf("%w: event %d: missing chain hash", ErrChainBroken, n)
+	}
+	original := message[:i]
+	got, err := hex.DecodeString(message[i+len(ChainSeparator):])
+	if err != nil || len(got) != sha256.Size {
+		return "", fmt.Errorf("%w: event %d: malformed chain hash", ErrChainBroken, n)
+	}
+
+	next := chainHash(v.prev, original)
+	if v.report.Events == 0 || [sha256.Size]byte(got) != next {
+		restart := chainHash(v.genesis, original)
+		if [sha256.Size]byte(got) != restart {
+			return "", fmt.Errorf("%w: event %d: hash mismatch: %q", ErrChainBroken, n, original)
+		}
+		next = restart
+		v.report.Segments++
+	}
+	v.prev = next
+	v.report.Events++
+	return original, nil
+}
+
+// Report returns the verification summary so far.
+func (v *ChainVerifier) Report() ChainReport {
+	return v.report
+}
+
+// EventGetter is implemented by clients supporting GetLogEvents, like
+// the AWS SDK client. It is required only by VerifyChain.
+type EventGetter interface {
+	GetLogEvents(ctx context.Context,
+		params *cloudwatchlogs.GetLogEventsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error)
+}
+
+// VerifyChain reads stream from the start and checks the hash chain
+// written by Options.AuditChain, failing with ErrChainBroken on the
+// first modified, inserted or removed event.
+func VerifyChain(ctx context.Context, client EventGetter, group, stream string) (ChainReport, error) {
+	v := NewChainVerifier(stream)
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(group),
+		LogStreamName: aws.String(stream),
+		StartFromHead: aws.Bool(true),
+	}
+	for {
+		out, err := client.GetLogEvents(ctx, input)
+		if err != nil {
+			return v.Report(), fmt.Errorf("GetLogEvents error: group=%s stream=%s%s: %w",
+				group, stream, callDetails(err), err)
+		}
+		for _, e := range out.Events {
+			if _, err := v.Verify(aws.ToString(e.Message)); err != nil {
+				return v.Report(), fmt.Errorf("verify chain: group=%s stream=%s: %w",
+					group, stream, err)
+			}
+		}
+		// the forward token repeats at the end of the stream
+		if len(out.Events) == 0 || aws.ToString(out.NextForwardToken) == aws.ToString(input.NextToken) {
+			return v.Report(), nil
+		}
+		input.NextToken = out.NextForwardToken
+	}
+}
@@ -0,0 +1,302 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// chainReaderMock serves GetLogEvents from a cloudWatchLogMock,
+// two events per page.
+type chainReaderMock struct {
+	*cloudWatchLogMock
+}
+
+func (m chainReaderMock) GetLogEvents(_ context.Context,
+	params *cloudwatchlogs.GetLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := m.groups[aws.ToString(params.LogGroupName)][aws.ToString(params.LogStreamName)]
+	from, _ := strconv.Atoi(aws.ToString(params.NextToken))
+	to := min(from+2, len(events))
+	out := &cloudwatchlogs.GetLogEventsOutput{NextForwardToken: aws.String(strconv.Itoa(to))}
+	for _, e := range events[from:to] {
+		out.Events = append(out.Events, types.OutputLogEvent{
+			Message:   e.Message,
+			Timestamp: e.Timestamp,
+		})
+	}
+	return out, nil
+}
+
+func newChainLog(t *testing.T, client *cloudWatchLogMock) *Log {
+	t.Helper()
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		AuditChain: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cw
+}
+
+func TestAuditChain(t *testing.T) {
+	const stream = "/cloudwatchlogs/stream-0001-01-01-00"
+	client := newCloudWatchLogMock()
+	cw := newChainLog(t, client)
+	for i := range 5 {
+		if err := cw.PutSimple(fmt.Sprintf("audit %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reader := chainReaderMock{client}
+	report, err := VerifyChain(context.Background(), reader, "/cloudwatchlogs/group", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events != 5 || report.Segments != 1 {
+		t.Errorf("report: %+v", report)
+	}
+
+	// a writer restart on the same stream begins a new segment
+	if err := newChainLog(t, client).PutSimple("after restart"); err != nil {
+		t.Fatal(err)
+	}
+	report, err = VerifyChain(context.Background(), reader, "/cloudwatchlogs/group", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events != 6 || report.Segments != 2 {
+		t.Errorf("report after restart: %+v", report)
+	}
+
+	table := []struct {
+		name   string
+		tamper func(events []types.InputLogEvent) []types.InputLogEvent
+	}{
+		{"modified message", func(events []types.InputLogEvent) []types.InputLogEvent {
+			events[2].Message = aws.String(strings.Replace(*events[2].Message, "audit 2", "audit X", 1))
+			return events
+		}},
+		{"removed event", func(events []types.InputLogEvent) []types.InputLogEvent {
+			return append(events[:1], events[2:]...)
+		}},
+		{"swapped events", func(events []types.InputLogEvent) []types.InputLogEvent {
+			events[1], events[2] = events[2], events[1]
+			return events
+		}},
+		{"missing hash", func(events []types.InputLogEvent) []types.InputLogEvent {
+			events[3].Message = aws.String("audit 3")
+			return events
+		}},
+	}
+
+	original := client.groups["/cloudwatchlogs/group"][stream]
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			events := make([]types.InputLogEvent, len(original))
+			copy(events, original)
+			client.groups["/cloudwatchlogs/group"][stream] = data.tamper(events)
+			_, err := VerifyChain(context.Background(), reader, "/cloudwatchlogs/group", stream)
+			if !errors.Is(err, ErrChainBroken) {
+				t.Errorf("expected ErrChainBroken, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuditChainOtherStream(t *testing.T) {
+	client := newCloudWatchLogMock()
+	if err := newChainLog(t, client).PutSimple("audit"); err != nil {
+		t.Fatal(err)
+	}
+	msg := *client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"][0].Message
+
+	if _, err := NewChainVerifier("other").Verify(msg); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("copied event: expected ErrChainBroken, got: %v", err)
+	}
+	original, err := NewChainVerifier("/cloudwatchlogs/stream-0001-01-01-00").Verify(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original != "audit" {
+		t.Errorf("original: %q", original)
+	}
+}
+
+func TestAuditChainTimestamps(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newChainLog(t, client)
+	if err := cw.PutLogEvents([]types.InputLogEvent{newEvent("late", 2000)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutLogEvents([]types.InputLogEvent{newEvent("early", 1000)}); err != nil {
+		t.Fatal(err)
+	}
+	events := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if ts := aws.ToInt64(events[1].Timestamp); ts != 2000 {
+		t.Errorf("raised timestamp: expected=2000 got=%d", ts)
+	}
+}
+
+func TestAuditChainSplitsBatch(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newChainLog(t, client)
+
+	// fits the limits before, but not after, the chain hashes
+	msg := strings.Repeat("x", MaxBatchBytes/MaxBatchEvents-EventOverhead)
+	events := make([]types.InputLogEvent, MaxBatchEvents)
+	for i := range events {
+		events[i] = newEvent(msg, 0)
+	}
+	if n := batchLen(events); n != len(events) {
+		t.Fatalf("test batch should fit: %d", n)
+	}
+	if err := cw.PutLogEvents(events); err != nil {
+		t.Fatal(err)
+	}
+	if client.puts != 2 {
+		t.Errorf("puts: expected=2 got=%d", client.puts)
+	}
+
+	report, err := VerifyChain(context.Background(), chainReaderMock{client},
+		"/cloudwatchlogs/group", "/cloudwatchlogs/stream-0001-01-01-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events != len(events) || report.Segments != 1 {
+		t.Errorf("report: %+v", report)
+	}
+}
+
+// rejectingChainClient stores only the accepted events of each put,
+// rejecting as too new the events from tooNewStart on, and failing the
+// put numbered failPut.
+type rejectingChainClient struct {
+	*cloudWatchLogMock
+	tooNewStart int
+	failPut     int
+	calls       int
+}
+
+func (c *rejectingChainClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.calls++
+	if c.calls == c.failPut {
+		return nil, errors.New("put log denied")
+	}
+	if c.tooNewStart < 0 || c.tooNewStart >= len(params.LogEvents) {
+		return c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+	}
+	accepted := *params
+	accepted.LogEvents = params.LogEvents[:c.tooNewStart]
+	if _, err := c.cloudWatchLogMock.PutLogEvents(ctx, &accepted, optFns...); err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.PutLogEventsOutput{RejectedLogEventsInfo: &types.RejectedLogEventsInfo{
+		TooNewLogEventStartIndex: aws.Int32(int32(c.tooNewStart)),
+	}}, nil
+}
+
+func TestAuditChainRejected(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ts := now.UnixMilli()
+	client := &rejectingChainClient{cloudWatchLogMock: newCloudWatchLogMock(), tooNewStart: 2}
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return now },
+		LogGroup:   "group",
+		LogStream:  "stream",
+		AuditChain: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := now.Add(-MaxEventAge - time.Hour).UnixMilli()
+	err = cw.PutLogEvents([]types.InputLogEvent{
+		newEvent("expired", expired),
+		newEvent("a", ts),
+		newEvent("b", ts),
+		newEvent("too new", ts),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.tooNewStart = -1
+	if err := cw.PutLogEvents([]types.InputLogEvent{newEvent("c", ts)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cw.Stats().ExpiredEvents; got != 1 {
+		t.Errorf("expired events: expected=1 got=%d", got)
+	}
+	report, err := VerifyChain(context.Background(), chainReaderMock{client.cloudWatchLogMock},
+		"group", "stream-2024-06-01-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events != 3 || report.Segments != 1 {
+		t.Errorf("report: %+v", report)
+	}
+}
+
+func TestAuditChainPartFails(t *testing.T) {
+	client := &rejectingChainClient{cloudWatchLogMock: newCloudWatchLogMock(), tooNewStart: -1, failPut: 2}
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		AuditChain: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fits the limits before, but not after, the chain hashes
+	msg := strings.Repeat("x", MaxBatchBytes/MaxBatchEvents-EventOverhead)
+	events := make([]types.InputLogEvent, MaxBatchEvents)
+	for i := range events {
+		events[i] = newEvent(msg, 0)
+	}
+	if err := cw.PutLogEvents(events); err == nil {
+		t.Fatal("expected second part to fail")
+	}
+	sent := len(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if sent == 0 || sent == len(events) {
+		t.Fatalf("first part: sent=%d", sent)
+	}
+	if got := cw.Stats().FailedEvents; got != int64(len(events)-sent) {
+		t.Errorf("failed events: expected=%d got=%d", len(events)-sent, got)
+	}
+
+	// the retry is chained after the delivered first part
+	if err := cw.PutLogEvents(events[sent:]); err != nil {
+		t.Fatal(err)
+	}
+	report, err := VerifyChain(context.Background(), chainReaderMock{client.cloudWatchLogMock},
+		"/cloudwatchlogs/group", "/cloudwatchlogs/stream-0001-01-01-00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events != len(events) || report.Segments != 1 {
+		t.Errorf("report: %+v", report)
+	}
+}
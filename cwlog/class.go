@@ -0,0 +1,76 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ErrIncompatibleClass is reported when options require features not
+// supported by Options.LogGroupClass.
+var ErrIncompatibleClass = errors.New("incompatible log group class")
+
+// validateClass checks options against the log group class, so that
+// features unsupported by INFREQUENT_ACCESS groups fail in New rather
+// than being silently ignored by AWS later.
+//
+// INFREQUENT_ACCESS groups lack embedded metric format extraction,
+// metric filters, subscription filters and Live Tail. Of the options,
+// only SelfMetricsNamespace depends on them: Heartbeat is accepted,
+// since absence alarms can use the IncomingLogEvents metric of the
+// group, and LevelRouting and Multi destinations share the class, hence
+// are validated by their own New. Metric filters are checked by
+// ErrorAlarm and EnsureErrorAlarm, see checkMetricFilters.
+//
+// Left to AWS: the class of a group that already exists, since it is not
+// described by New, subscription filters and Live Tail sessions, which
+// this package never creates, and account policies from PutAccountPolicy,
+// applied by AWS only to the groups supporting them.
+func validateClass(options Options) error {
+	class := options.LogGroupClass
+	if class == "" {
+		return nil
+	}
+	if !slices.Contains(class.Values(), class) {
+		return fmt.Errorf("%w: unknown class %q, expecting one of %v",
+			ErrIncompatibleClass, class, class.Values())
+	}
+	if class != types.LogGroupClassInfrequentAccess {
+		return nil
+	}
+	if options.SelfMetricsNamespace != "" {
+		return fmt.Errorf("%w: %s groups do not extract embedded metric format metrics: clear SelfMetricsNamespace or use class %s",
+			ErrIncompatibleClass, class, types.LogGroupClassStandard)
+	}
+	return nil
+}
+
+// supportsMetricFilters reports whether the log group class allows
+// metric filters, which INFREQUENT_ACCESS groups do not.
+func supportsMetricFilters(class types.LogGroupClass) bool {
+	return class != types.LogGroupClassInfrequentAccess
+}
+
+// checkMetricFilters reports ErrIncompatibleClass when group does not
+// support metric filters. The class is described when client implements
+// GroupDescriber, otherwise class, if defined, is assumed. Groups not
+// found are left to AWS.
+func checkMetricFilters(ctx context.Context, client any, group string, class types.LogGroupClass) error {
+	if describer, ok := client.(GroupDescriber); ok {
+		g, err := describeGroup(ctx, describer, group)
+		if err != nil {
+			return fmt.Errorf("metric filter class error: %w", err)
+		}
+		if g != nil {
+			class = g.LogGroupClass
+		}
+	}
+	if !supportsMetricFilters(class) {
+		return fmt.Errorf("%w: %s groups do not support metric filters: group=%s",
+			ErrIncompatibleClass, class, group)
+	}
+	return nil
+}
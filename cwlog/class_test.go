@@ -0,0 +1,99 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type classTestCase struct {
+	name    string
+	options Options
+	wantErr bool
+}
+
+var classTestTable = []classTestCase{
+	{"default class", Options{}, false},
+	{"standard with self metrics", Options{LogGroupClass: types.LogGroupClassStandard, SelfMetricsNamespace: "App"}, false},
+	{"infrequent access", Options{LogGroupClass: types.LogGroupClassInfrequentAccess}, false},
+	{"infrequent access with self metrics", Options{LogGroupClass: types.LogGroupClassInfrequentAccess, SelfMetricsNamespace: "App"}, true},
+	{"unknown class", Options{LogGroupClass: "COLD"}, true},
+}
+
+func TestValidateClass(t *testing.T) {
+	for i, data := range classTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(classTestTable), data.name)
+		t.Run(name, func(t *testing.T) {
+			options := data.options
+			options.Client = newCloudWatchLogMock()
+			options.LogGroup = "/cloudwatchlogs/group"
+			options.LogStream = "/cloudwatchlogs/stream"
+			cw, err := New(options)
+			if data.wantErr {
+				if !errors.Is(err, ErrIncompatibleClass) {
+					t.Errorf("expected ErrIncompatibleClass, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			cw.Close()
+		})
+	}
+}
+
+func TestErrorAlarmInfrequentAccess(t *testing.T) {
+	client := &metricFilterMock{cloudWatchLogMock: newCloudWatchLogMock()}
+	cw, err := New(Options{
+		Client:        client,
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		LogGroupClass: types.LogGroupClassInfrequentAccess,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errAlarm := cw.ErrorAlarm(context.TODO(), &alarmMock{}, ErrorAlarmOptions{})
+	if !errors.Is(errAlarm, ErrIncompatibleClass) {
+		t.Errorf("expected ErrIncompatibleClass, got: %v", errAlarm)
+	}
+	if client.filter != nil {
+		t.Errorf("unexpected metric filter")
+	}
+}
+
+func TestErrorAlarmOtherGroupInfrequentAccess(t *testing.T) {
+	client := &metricFilterMock{cloudWatchLogMock: newCloudWatchLogMock()}
+	cw, err := New(Options{
+		Client:    client,
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := GroupSpec{Name: "/cloudwatchlogs/archive", Class: types.LogGroupClassInfrequentAccess}
+	if err := EnsureGroup(context.TODO(), client, spec); err != nil {
+		t.Fatal(err)
+	}
+
+	errAlarm := cw.ErrorAlarm(context.TODO(), &alarmMock{}, ErrorAlarmOptions{LogGroup: spec.Name})
+	if !errors.Is(errAlarm, ErrIncompatibleClass) {
+		t.Errorf("ErrorAlarm: expected ErrIncompatibleClass, got: %v", errAlarm)
+	}
+	errEnsure := EnsureErrorAlarm(context.TODO(), client, &alarmMock{}, ErrorAlarmOptions{LogGroup: spec.Name})
+	if !errors.Is(errEnsure, ErrIncompatibleClass) {
+		t.Errorf("EnsureErrorAlarm: expected ErrIncompatibleClass, got: %v", errEnsure)
+	}
+	if client.filter != nil {
+		t.Errorf("unexpected metric filter")
+	}
+
+	if err := cw.ErrorAlarm(context.TODO(), &alarmMock{}, ErrorAlarmOptions{}); err != nil {
+		t.Errorf("standard group: %v", err)
+	}
+}
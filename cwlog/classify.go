@@ -0,0 +1,162 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrorClass is a coarse category of errors, for callers writing their
+// own retry or alerting logic. See Classify.
+type ErrorClass int
+
+// Error classes.
+const (
+	// ClassUnknown is an error not recognized by Classify.
+	ClassUnknown ErrorClass = iota
+
+	// ClassRetryable is a transient failure, like throttling, service
+	// unavailability, timeouts or network errors. Retry with backoff.
+	ClassRetryable
+
+	// ClassAuth is a permanent credential or permission failure.
+	ClassAuth
+
+	// ClassNotFound reports a missing log group or stream.
+	ClassNotFound
+
+	// ClassValidation is a permanent request failure, like oversized
+	// events, invalid parameters or invalid timestamps.
+	ClassValidation
+)
+
+var errorClassNames = []string{"unknown", "retryable", "auth", "not-found", "validation"}
+
+func (c ErrorClass) String() string {
+	if c < 0 || int(c) >= len(errorClassNames) {
+		return errorClassNames[ClassUnknown]
+	}
+	return errorClassNames[c]
+}
+
+// errorCodeClasses maps AWS error codes to classes.
+var errorCodeClasses = map[string]ErrorClass{
+	"ThrottlingException":                    ClassRetryable,
+	"Throttling":                             ClassRetryable,
+	"RequestLimitExceeded":                   ClassRetryable,
+	"TooManyRequestsException":               ClassRetryable,
+	"ProvisionedThroughputExceededException": ClassRetryable,
+	"ServiceUnavailableException":            ClassRetryable,
+	"ServiceUnavailable":                     ClassRetryable,
+	"InternalFailure":                        ClassRetryable,
+	"InternalServerError":                    ClassRetryable,
+	"OperationAbortedException":              ClassRetryable,
+	"InvalidSequenceTokenException":          ClassRetryable,
+
+	"AccessDeniedException":       ClassAuth,
+	"AccessDenied":                ClassAuth,
+	"UnrecognizedClientException": ClassAuth,
+	"InvalidClientTokenId":        ClassAuth,
+	"ExpiredToken":                ClassAuth,
+	"ExpiredTokenException":       ClassAuth,
+	"InvalidSignatureException":   ClassAuth,
+	"SignatureDoesNotMatch":       ClassAuth,
+	"IncompleteSignature":         ClassAuth,
+	"MissingAuthenticationToken":  ClassAuth,
+	"NotAuthorized":               ClassAuth,
+
+	"ResourceNotFoundException": ClassNotFound,
+
+	"InvalidParameterException":      ClassValidation,
+	"InvalidParameterValue":          ClassValidation,
+	"InvalidParameterCombination":    ClassValidation,
+	"MissingParameter":               ClassValidation,
+	"ValidationException":            ClassValidation,
+	"SerializationException":         ClassValidation,
+	"MalformedQueryException":        ClassValidation,
+	"InvalidOperationException":      ClassValidation,
+	"UnrecognizedParameterException": ClassValidation,
+	"UnsupportedOperationException":  ClassValidation,
+	"RequestEntityTooLargeException": ClassValidation,
+	"LimitExceededException":         ClassValidation,
+	"ResourceAlreadyExistsException": ClassValidation,
+	"DataAlreadyAcceptedException":   ClassValidation,
+}
+
+// Classify categorizes err, as returned by this package or by a
+// CloudWatchLogClient, into an ErrorClass.
+// AWS errors are recognized by error code, or else by HTTP status.
+// Errors whose chain was flattened into text are recognized by the
+// "Code:" prefix AWS errors render.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassUnknown
+	}
+
+	if c, found := classifySentinel(err); found {
+		return c
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if c, found := errorCodeClasses[apiErr.ErrorCode()]; found {
+			return c
+		}
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return classifyStatus(statusErr.HTTPStatusCode())
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ClassRetryable
+	}
+
+	return classifyText(err.Error())
+}
+
+func classifySentinel(err error) (ErrorClass, bool) {
+	switch {
+	case errors.Is(err, ErrBufferFull),
+		errors.Is(err, context.DeadlineExceeded):
+		return ClassRetryable, true
+	case errors.Is(err, ErrEmptyMessage),
+		errors.Is(err, ErrEventTooLarge),
+		errors.Is(err, ErrInvalidUTF8),
+		errors.Is(err, ErrMissingTimestamp),
+		errors.Is(err, ErrTooOld),
+		errors.Is(err, ErrTooNew):
+		return ClassValidation, true
+	}
+	return ClassUnknown, false
+}
+
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == 429 || status >= 500:
+		return ClassRetryable
+	case status == 401 || status == 403:
+		return ClassAuth
+	case status == 404:
+		return ClassNotFound
+	case status >= 400:
+		return ClassValidation
+	}
+	return ClassUnknown
+}
+
+// classifyText finds AWS error codes in flattened error messages,
+// like "PutLogEvents error: ...: ThrottlingException: Rate exceeded".
+func classifyText(msg string) ErrorClass {
+	for code, c := range errorCodeClasses {
+		if strings.Contains(msg, code+":") {
+			return c
+		}
+	}
+	return ClassUnknown
+}
@@ -0,0 +1,52 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestClassify(t *testing.T) {
+	httpErr := func(status int) error {
+		return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("boom"),
+		}}
+	}
+
+	table := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"nil", nil, ClassUnknown},
+		{"throttle", &smithy.GenericAPIError{Code: "ThrottlingException"}, ClassRetryable},
+		{"auth", &smithy.GenericAPIError{Code: "AccessDeniedException"}, ClassAuth},
+		{"not found", fmt.Errorf("put: %w", &types.ResourceNotFoundException{}), ClassNotFound},
+		{"invalid parameter", &types.InvalidParameterException{}, ClassValidation},
+		{"flattened", fmt.Errorf("PutLogEvents error: %v", &types.ServiceUnavailableException{}), ClassRetryable},
+		{"status 503", httpErr(503), ClassRetryable},
+		{"status 403", httpErr(403), ClassAuth},
+		{"status 413", httpErr(413), ClassValidation},
+		{"buffer full", fmt.Errorf("%w: dropped 1 events", ErrBufferFull), ClassRetryable},
+		{"too large", ErrEventTooLarge, ClassValidation},
+		{"deadline", context.DeadlineExceeded, ClassRetryable},
+		{"other", errors.New("other"), ClassUnknown},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			if got := Classify(data.err); got != data.expected {
+				t.Errorf("expected=%v got=%v", data.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package cwlog
+
+import "time"
+
+// Clock abstracts time, so that rotation and background flushing can be
+// fully controlled in tests. See cwlogtest.FakeClock.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker abstracts time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the real clock, optionally with a custom Now function.
+type systemClock struct {
+	now func() time.Time
+}
+
+func (c systemClock) Now() time.Time {
+	return c.now()
+}
+
+func (c systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+
+func (t systemTicker) Stop() { t.t.Stop() }
@@ -0,0 +1,36 @@
+package cwlog
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	clone, err := cw.Clone(Options{
+		LogStream:         "job-42",
+		LogStreamTemplate: "{{.LogStream}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clone.PutSimple("from clone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("from parent"); err != nil {
+		t.Fatal(err)
+	}
+
+	g := client.groups["/cloudwatchlogs/group"]
+	if len(g["job-42"]) != 1 {
+		t.Fatalf("clone stream: expected=1 found=%d", len(g["job-42"]))
+	}
+	if len(g["/cloudwatchlogs/stream-0001-01-01-00"]) != 1 {
+		t.Fatalf("parent stream: expected=1 found=%d", len(g["/cloudwatchlogs/stream-0001-01-01-00"]))
+	}
+
+	if _, err := cw.Clone(Options{LogStreamTemplate: "{{"}); err == nil {
+		t.Fatal("expected template error")
+	}
+}
@@ -0,0 +1,119 @@
+package cwlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// CompressedPrefix marks messages compressed by Options.CompressLarge.
+// The rest of the message is the base64 encoded gzip of the original.
+const CompressedPrefix = "cwlog-gzip:"
+
+// compressMessage returns the gzip+base64 form of s, with CompressedPrefix.
+func compressMessage(s string) string {
+	var buf bytes.Buffer
+	gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	gz.Write([]byte(s)) // writes to bytes.Buffer do not fail
+	gz.Close()
+	return CompressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// compressLarge returns events with messages larger than the maximum
+// event size compressed. Messages still too large once compressed are
+// split into several events instead. The caller slice is copied only
+// when needed.
+func (l *Log) compressLarge(events []types.InputLogEvent) []types.InputLogEvent {
+	var result []types.InputLogEvent
+	for i, e := range events {
+		if e.Message == nil || len(*e.Message) <= maxMessageBytes {
+			if result != nil {
+				result = append(result, e)
+			}
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, i, len(events)+1)
+			copy(result, events[:i])
+		}
+		if compressed := compressMessage(*e.Message); len(compressed) <= maxMessageBytes {
+			result = append(result, types.InputLogEvent{Message: aws.String(compressed), Timestamp: e.Timestamp})
+			l.stats.compressedEvents.Add(1)
+			continue
+		}
+		for msg := *e.Message; msg != ""; {
+			cut := RuneCut(msg, maxMessageBytes)
+			result = append(result, types.InputLogEvent{Message: aws.String(msg[:cut]), Timestamp: e.Timestamp})
+			msg = msg[cut:]
+		}
+		l.stats.splitEvents.Add(1)
+	}
+	if result == nil {
+		return events
+	}
+	return result
+}
+
+// DecodeMessage reverses Options.CompressLarge, returning the original
+// message. Messages without CompressedPrefix are returned unchanged.
+func DecodeMessage(s string) (string, error) {
+	encoded, found := strings.CutPrefix(s, CompressedPrefix)
+	if !found {
+		return s, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode message: base64: %w", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode message: gzip: %w", err)
+	}
+	var out strings.Builder
+	if _, err := io.Copy(&out, gz); err != nil {
+		return "", fmt.Errorf("decode message: gzip: %w", err)
+	}
+	return out.String(), nil
+}
+
+// DecodeLines copies r to w line by line, decoding compressed messages
+// with DecodeMessage. A compressed message may be preceded by other
+// text, like the timestamp and stream printed by "cloudwatchlog tail".
+func DecodeLines(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, errRead := br.ReadString('\n')
+		if line != "" {
+			text, newline := strings.CutSuffix(line, "\n")
+			var decoded string
+			if i := strings.Index(text, CompressedPrefix); i >= 0 {
+				msg, err := DecodeMessage(text[i:])
+				if err != nil {
+					return err
+				}
+				decoded = text[:i] + msg
+			} else {
+				decoded = text
+			}
+			if newline {
+				decoded += "\n"
+			}
+			if _, err := io.WriteString(w, decoded); err != nil {
+				return err
+			}
+		}
+		if errRead == io.EOF {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+}
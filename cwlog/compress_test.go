@@ -0,0 +1,104 @@
+package cwlog
+
+import (
+	"encoding/base64"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressLarge(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		CompressLarge: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := strings.Repeat("repetitive payload ", 2*maxMessageBytes/19)
+	if err := cw.PutSimple(large); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("small"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if !strings.HasPrefix(msgs[0], CompressedPrefix) || len(msgs[0]) > maxMessageBytes {
+		t.Fatalf("large message not compressed: len=%d", len(msgs[0]))
+	}
+	if msgs[1] != "small" {
+		t.Errorf("small message changed: %q", msgs[1])
+	}
+	if got := cw.Stats().CompressedEvents; got != 1 {
+		t.Errorf("compressed events: expected=1 got=%d", got)
+	}
+
+	decoded, errDecode := DecodeMessage(msgs[0])
+	if errDecode != nil {
+		t.Fatal(errDecode)
+	}
+	if decoded != large {
+		t.Errorf("decoded message differs: len=%d", len(decoded))
+	}
+
+	var out strings.Builder
+	input := "2024-05-31T22:14:15Z stream " + msgs[0] + "\nplain line"
+	if err := DecodeLines(&out, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "2024-05-31T22:14:15Z stream " + large + "\nplain line"; out.String() != expected {
+		t.Errorf("DecodeLines: unexpected output: len=%d", out.Len())
+	}
+
+	if _, err := DecodeMessage(CompressedPrefix + "!!!"); err == nil {
+		t.Errorf("expected error for corrupt message")
+	}
+}
+
+func TestCompressLargeIncompressible(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		CompressLarge: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	random := make([]byte, 300000)
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for i := range random {
+		random[i] = byte(rnd.Uint32())
+	}
+	large := base64.StdEncoding.EncodeToString(random)
+	if err := cw.PutSimple(large); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	for _, m := range msgs {
+		if len(m) > maxMessageBytes {
+			t.Errorf("message too large: len=%d", len(m))
+		}
+	}
+	if strings.Join(msgs, "") != large {
+		t.Errorf("split message differs")
+	}
+	if stats := cw.Stats(); stats.SplitEvents != 1 || stats.CompressedEvents != 0 {
+		t.Errorf("unexpected stats: split=%d compressed=%d", stats.SplitEvents, stats.CompressedEvents)
+	}
+}
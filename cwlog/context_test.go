@@ -0,0 +1,115 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func TestContextCancelFinalFlush(t *testing.T) {
+	client := newCloudWatchLogMock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		Async:         true,
+		FlushInterval: -1,
+		Context:       ctx,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.PutSimple("queued"); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	select {
+	case <-cw.batcher.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flusher did not stop on cancellation")
+	}
+
+	client.mu.Lock()
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	client.mu.Unlock()
+	if len(msgs) != 1 || msgs[0] != "queued" {
+		t.Errorf("final flush: unexpected messages: %v", msgs)
+	}
+
+	if err := cw.PutSimple("late"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after cancellation, got: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Errorf("close after cancellation: %v", err)
+	}
+}
+
+// blockingClient blocks PutLogEvents until the call context is done.
+type blockingClient struct {
+	*cloudWatchLogMock
+	started chan struct{}
+}
+
+func (c *blockingClient) PutLogEvents(ctx context.Context,
+	_ *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	select {
+	case c.started <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStartCancelAbortsInflight(t *testing.T) {
+	client := &blockingClient{
+		cloudWatchLogMock: newCloudWatchLogMock(),
+		started:           make(chan struct{}, 1),
+	}
+	cw, err := New(Options{
+		Client:            client,
+		LogGroup:          "/cloudwatchlogs/group",
+		LogStream:         "/cloudwatchlogs/stream",
+		Async:             true,
+		FlushInterval:     -1,
+		FlushEvents:       1, // send every event in background
+		FinalFlushTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cw.Start(ctx)
+
+	if err := cw.PutSimple("in flight"); err != nil {
+		t.Fatal(err)
+	}
+	<-client.started
+	if err := cw.PutSimple("pending"); err != nil {
+		t.Fatal(err)
+	}
+
+	begin := time.Now()
+	cancel()
+
+	select {
+	case <-cw.batcher.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flusher did not stop on cancellation")
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("stop took too long: %v", elapsed)
+	}
+	if cw.LastError() == nil {
+		t.Errorf("expected aborted send to be reported")
+	}
+}
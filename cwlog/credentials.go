@@ -0,0 +1,35 @@
+package cwlog
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+// credentialErrorCodes are AWS error codes fixed by fresh credentials.
+var credentialErrorCodes = map[string]bool{
+	"ExpiredTokenException":       true,
+	"ExpiredToken":                true,
+	"UnrecognizedClientException": true,
+	"InvalidClientTokenId":        true,
+}
+
+func isCredentialError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && credentialErrorCodes[apiErr.ErrorCode()]
+}
+
+// refreshCredentials invalidates cached AwsConfig credentials, making the
+// client retrieve them again from the provider on the next call, and calls
+// the OnCredentialError hook.
+func (l *Log) refreshCredentials(err error) {
+	l.warn("credential error, refreshing credentials", "group", l.options.LogGroup,
+		"error", err)
+	if cache, isCache := l.options.AwsConfig.Credentials.(*aws.CredentialsCache); isCache {
+		cache.Invalidate()
+	}
+	if l.options.OnCredentialError != nil {
+		l.options.OnCredentialError(err)
+	}
+}
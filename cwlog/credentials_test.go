@@ -0,0 +1,71 @@
+package cwlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+)
+
+// expiringClient fails the first put with expired credentials.
+type expiringClient struct {
+	*cloudWatchLogMock
+	failures int
+}
+
+func (c *expiringClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if c.failures > 0 {
+		c.failures--
+		return nil, &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "expired"}
+	}
+	return c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+}
+
+func TestCredentialRefresh(t *testing.T) {
+	var retrieved int
+	cache := aws.NewCredentialsCache(aws.CredentialsProviderFunc(
+		func(context.Context) (aws.Credentials, error) {
+			retrieved++
+			return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+		}))
+	if _, err := cache.Retrieve(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &expiringClient{cloudWatchLogMock: newCloudWatchLogMock(), failures: 1}
+	var hookCalls int
+	cw, err := New(Options{
+		AwsConfig:         aws.Config{Credentials: cache},
+		Client:            client,
+		LogGroup:          "/cloudwatchlogs/group",
+		OnCredentialError: func(error) { hookCalls++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.PutSimple("a"); err != nil {
+		t.Fatalf("expected recovery, got: %v", err)
+	}
+	if hookCalls != 1 {
+		t.Errorf("hook calls: expected=1 got=%d", hookCalls)
+	}
+	if _, err := cache.Retrieve(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if retrieved != 2 {
+		t.Errorf("credentials not invalidated: retrieved=%d", retrieved)
+	}
+
+	client.failures = 2 // still failing after refresh
+	if err := cw.PutSimple("b"); err == nil {
+		t.Errorf("expected error")
+	}
+	if hookCalls != 2 {
+		t.Errorf("hook calls: expected=2 got=%d", hookCalls)
+	}
+}
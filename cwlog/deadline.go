@@ -0,0 +1,56 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// PutLogEventsWithDeadline is like PutLogEventsContext, bounding the wait
+// to d, for critical events like audit records.
+func (l *Log) PutLogEventsWithDeadline(events []types.InputLogEvent, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(l.options.Context, d)
+	defer cancel()
+	return l.PutLogEventsContext(ctx, events)
+}
+
+// PutLogEventsContext sends events and waits until they are delivered,
+// reporting failure when ctx is done first. In buffered mode events go to
+// the high priority lane, and the call waits for the send of their batch;
+// on timeout the events may still be delivered later.
+// For children created by With, messages are prefixed with the bound fields.
+func (l *Log) PutLogEventsContext(ctx context.Context, events []types.InputLogEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if l.prefix != "" {
+		events = prefixEvents(l.prefix, events)
+	}
+	events = l.prepare(events)
+	if len(events) == 0 {
+		return nil // all dropped by Pipeline
+	}
+
+	if l.batcher == nil {
+		if _, err := l.sendLocked(ctx, events); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return fmt.Errorf("put deadline: %w: %w", ctxErr, err)
+			}
+			return err
+		}
+		return nil
+	}
+
+	done, err := l.batcher.enqueueWait(l, events)
+	if err != nil {
+		return err
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("put deadline: %w", ctx.Err())
+	}
+}
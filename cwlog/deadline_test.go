@@ -0,0 +1,66 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestPutLogEventsWithDeadlineAsync(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+	defer cw.Close()
+
+	if err := cw.PutSimple("low"); err != nil {
+		t.Fatal(err)
+	}
+	audit := []types.InputLogEvent{newEvent("audit", time.Time{}.UnixMilli())}
+	if err := cw.PutLogEventsWithDeadline(audit, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// the audit event was delivered before the call returned
+	client.mu.Lock()
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	client.mu.Unlock()
+	if len(msgs) < 1 || msgs[0] != "audit" {
+		t.Errorf("unexpected messages: %v", msgs)
+	}
+}
+
+func TestPutLogEventsWithDeadlineExceeded(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		client := &blockingClient{
+			cloudWatchLogMock: newCloudWatchLogMock(),
+			started:           make(chan struct{}, 1),
+		}
+		cw, err := New(Options{
+			Client:        client,
+			LogGroup:      "/cloudwatchlogs/group",
+			LogStream:     "/cloudwatchlogs/stream",
+			Async:         async,
+			FlushInterval: -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		begin := time.Now()
+		errPut := cw.PutLogEventsWithDeadline([]types.InputLogEvent{newEvent("audit", 0)},
+			50*time.Millisecond)
+		if !errors.Is(errPut, context.DeadlineExceeded) {
+			t.Errorf("async=%t: expected deadline exceeded, got: %v", async, errPut)
+		}
+		if elapsed := time.Since(begin); elapsed > 2*time.Second {
+			t.Errorf("async=%t: deadline not honored: %v", async, elapsed)
+		}
+
+		if async {
+			cw.batcher.stop() // abort the blocked background send
+		}
+		cw.Close()
+	}
+}
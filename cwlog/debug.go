@@ -0,0 +1,15 @@
+package cwlog
+
+// debug reports routine internal state changes to Options.DebugLogger.
+func (l *Log) debug(msg string, args ...any) {
+	if l.options.DebugLogger != nil {
+		l.options.DebugLogger.Debug(msg, args...)
+	}
+}
+
+// warn reports internal problems to Options.DebugLogger.
+func (l *Log) warn(msg string, args ...any) {
+	if l.options.DebugLogger != nil {
+		l.options.DebugLogger.Warn(msg, args...)
+	}
+}
@@ -0,0 +1,44 @@
+package cwlog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var now time.Time
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:      client,
+		Now:         func() time.Time { return now },
+		LogGroup:    "group",
+		LogStream:   "stream",
+		DebugLogger: logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := cw.PutSimple("line"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(time.Hour)
+	if err := cw.PutSimple("line"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, "stream rotated"); n != 2 {
+		t.Fatalf("stream rotated: expected=2 got=%d: %s", n, out)
+	}
+	if !strings.Contains(out, "to=stream-0001-01-01-01") {
+		t.Fatalf("missing rotation target: %s", out)
+	}
+}
@@ -0,0 +1,73 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// GroupDescriber is implemented by clients supporting DescribeLogGroups,
+// like the AWS SDK client. It is optional for Options.Client, required
+// only by GroupARN.
+type GroupDescriber interface {
+	DescribeLogGroups(ctx context.Context,
+		params *cloudwatchlogs.DescribeLogGroupsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+}
+
+// CurrentStream returns the name of the stream the next event is sent to,
+// according to the stream template and the current time.
+func (l *Log) CurrentStream() string {
+	l.sendMu.Lock()
+	defer l.sendMu.Unlock()
+	stream, _ := l.generateStreamName() // template validated in New
+	return stream
+}
+
+// GroupARN looks up the log group ARN, without the trailing ":*",
+// for example to build links to the CloudWatch console.
+// The client must implement GroupDescriber.
+func (l *Log) GroupARN(ctx context.Context) (string, error) {
+	describer, ok := l.options.Client.(GroupDescriber)
+	if !ok {
+		return "", fmt.Errorf("group arn error: client does not support DescribeLogGroups")
+	}
+	return LookupGroupARN(ctx, describer, l.options.LogGroup)
+}
+
+// LookupGroupARN looks up the ARN of group, without the trailing ":*".
+func LookupGroupARN(ctx context.Context, describer GroupDescriber, group string) (string, error) {
+	g, err := describeGroup(ctx, describer, group)
+	if err != nil {
+		return "", err
+	}
+	if g == nil {
+		return "", fmt.Errorf("group arn error: group not found: %s", group)
+	}
+	if arn := aws.ToString(g.LogGroupArn); arn != "" {
+		return arn, nil
+	}
+	return strings.TrimSuffix(aws.ToString(g.Arn), ":*"), nil
+}
+
+// describeGroup looks up group, returning nil when not found.
+func describeGroup(ctx context.Context, describer GroupDescriber, group string) (*types.LogGroup, error) {
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(describer,
+		&cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String(group)})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describe group error: %s: %w", group, err)
+		}
+		for _, g := range out.LogGroups {
+			if aws.ToString(g.LogGroupName) == group {
+				return &g, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,47 @@
+package cwlog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCurrentStreamAndGroupARN(t *testing.T) {
+	now := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return now },
+		LogGroup:  "/app/main",
+		LogStream: "web",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := cw.CurrentStream(); s != "web-2024-03-02-10" {
+		t.Errorf("current stream: %s", s)
+	}
+	now = now.Add(time.Hour)
+	if s := cw.CurrentStream(); s != "web-2024-03-02-11" {
+		t.Errorf("current stream after rotation: %s", s)
+	}
+
+	arn, errArn := cw.GroupARN(context.Background())
+	if errArn != nil {
+		t.Fatal(errArn)
+	}
+	if expected := "arn:aws:logs:us-east-1:123456789012:log-group:/app/main"; arn != expected {
+		t.Errorf("arn: expected=%s got=%s", expected, arn)
+	}
+}
+
+func TestGroupARNUnsupported(t *testing.T) {
+	cw, err := New(Options{Client: nullClient{}, LogGroup: "/app/main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cw.GroupARN(context.Background()); err == nil {
+		t.Fatal("expected error for client without DescribeLogGroups")
+	}
+}
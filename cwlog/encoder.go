@@ -0,0 +1,215 @@
+package cwlog
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Field is one key/value pair of a structured event.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Entry is a structured event to be encoded.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field // in order, global fields first
+}
+
+// Encoder renders structured events into messages. See Options.Encoder.
+// Encoders must be safe for concurrent use.
+type Encoder interface {
+	Encode(entry Entry) (string, error)
+}
+
+// JSONEncoder renders events as JSON objects like
+// {"level":"INFO","msg":"hello","key":"value"}, preserving field order.
+// The zero value is ready to use. The optional settings help matching
+// the shape expected by existing Insights queries and dashboards.
+type JSONEncoder struct {
+	// TimeKey enables the event time under this key, like "time".
+	TimeKey string
+
+	// TimeLayout formats the event time. Defaults to time.RFC3339Nano.
+	TimeLayout string
+
+	// LevelKey defaults to "level".
+	LevelKey string
+
+	// MessageKey defaults to "msg".
+	MessageKey string
+
+	// FieldNames optionally renames fields, for example
+	// {"request_id": "requestId"}. Flattened keys are renamed as well.
+	FieldNames map[string]string
+
+	// Flatten expands nested maps with string keys into dotted keys,
+	// {"http":{"status":200}} becoming {"http.status":200}.
+	Flatten bool
+}
+
+// Encode implements Encoder.
+func (e JSONEncoder) Encode(entry Entry) (string, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	buf.WriteByte('{')
+	if e.TimeKey != "" {
+		layout := e.TimeLayout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		writeJSONString(buf, e.TimeKey)
+		buf.WriteByte(':')
+		writeJSONString(buf, entry.Time.Format(layout))
+		buf.WriteByte(',')
+	}
+	writeJSONString(buf, cmp.Or(e.LevelKey, "level"))
+	buf.WriteByte(':')
+	writeJSONString(buf, entry.Level.String())
+	buf.WriteByte(',')
+	writeJSONString(buf, cmp.Or(e.MessageKey, "msg"))
+	buf.WriteByte(':')
+	writeJSONString(buf, entry.Message)
+	for _, f := range entry.Fields {
+		if err := e.writeField(buf, f.Key, f.Value); err != nil {
+			return "", err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+func (e JSONEncoder) writeField(buf *bytes.Buffer, key string, value any) error {
+	if e.Flatten {
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+			keys := v.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+			for _, k := range keys {
+				if err := e.writeField(buf, key+"."+k.String(), v.MapIndex(k).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if name, found := e.FieldNames[key]; found {
+		key = name
+	}
+	buf.WriteByte(',')
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+	if err := writeJSONValue(buf, value); err != nil {
+		return fmt.Errorf("json encode error: field=%s: %w", key, err)
+	}
+	return nil
+}
+
+// LogfmtEncoder renders events as logfmt lines like
+// level=INFO msg=hello key=value. Values are formatted with fmt.Sprint
+// and quoted when empty or holding spaces, quotes or equal signs.
+type LogfmtEncoder struct{}
+
+// Encode implements Encoder.
+func (LogfmtEncoder) Encode(entry Entry) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("level=")
+	sb.WriteString(entry.Level.String())
+	sb.WriteString(" msg=")
+	sb.WriteString(logfmtValue(entry.Message))
+	for _, f := range entry.Fields {
+		sb.WriteByte(' ')
+		sb.WriteString(logfmtKey(f.Key))
+		sb.WriteByte('=')
+		v := f.Value
+		if err, isErr := v.(error); isErr && err != nil {
+			v = err.Error()
+		}
+		sb.WriteString(logfmtValue(fmt.Sprint(v)))
+	}
+	return sb.String(), nil
+}
+
+// TemplateEncoder renders events with a text/template, producing human
+// readable messages from structured calls, like
+// "{{.level}} {{.msg}} tenant={{.fields.tenant}}".
+// The template data is a map with keys "time" (time.Time), "level"
+// (string), "msg" (string) and "fields" (map[string]any).
+type TemplateEncoder struct {
+	tmpl *template.Template
+}
+
+// NewTemplateEncoder parses text into a TemplateEncoder.
+func NewTemplateEncoder(text string) (*TemplateEncoder, error) {
+	tmpl, err := template.New("message").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("message template error: %w", err)
+	}
+	return &TemplateEncoder{tmpl: tmpl}, nil
+}
+
+// Encode implements Encoder.
+func (e *TemplateEncoder) Encode(entry Entry) (string, error) {
+	fields := make(map[string]any, len(entry.Fields))
+	for _, f := range entry.Fields {
+		fields[f.Key] = f.Value
+	}
+	data := map[string]any{
+		"time":   entry.Time,
+		"level":  entry.Level.String(),
+		"msg":    entry.Message,
+		"fields": fields,
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := e.tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("message template error: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func logfmtKey(k string) string {
+	if k == "" {
+		return "!EMPTYKEY"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\r\n\"=\\") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s) // strings always marshal
+	buf.Write(data)
+}
+
+func writeJSONValue(buf *bytes.Buffer, v any) error {
+	if err, isErr := v.(error); isErr && err != nil {
+		v = err.Error() // errors usually marshal as {}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
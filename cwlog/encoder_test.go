@@ -0,0 +1,111 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	entry := Entry{
+		Time:    time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC),
+		Level:   LevelWarn,
+		Message: "disk almost full",
+		Fields: []Field{
+			{Key: "path", Value: "/var/log"},
+			{Key: "used", Value: 0.93},
+			{Key: "err", Value: errors.New(`say "hi"`)},
+			{Key: "bad key", Value: ""},
+		},
+	}
+
+	table := []struct {
+		name     string
+		encoder  Encoder
+		expected string
+	}{
+		{"json", JSONEncoder{},
+			`{"level":"WARN","msg":"disk almost full","path":"/var/log","used":0.93,"err":"say \"hi\"","bad key":""}`},
+		{"json custom", JSONEncoder{
+			TimeKey:    "@t",
+			TimeLayout: time.RFC3339,
+			LevelKey:   "severity",
+			MessageKey: "message",
+			FieldNames: map[string]string{"err": "error", "bad key": "badKey"},
+		}, `{"@t":"2024-05-31T22:14:15Z","severity":"WARN","message":"disk almost full","path":"/var/log","used":0.93,"error":"say \"hi\"","badKey":""}`},
+		{"logfmt", LogfmtEncoder{},
+			`level=WARN msg="disk almost full" path=/var/log used=0.93 err="say \"hi\"" bad_key=""`},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			got, err := data.encoder.Encode(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != data.expected {
+				t.Errorf("expected=%s got=%s", data.expected, got)
+			}
+		})
+	}
+}
+
+func TestOptionsEncoder(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		Encoder:   LogfmtEncoder{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.With(map[string]any{"tenant": "acme"}).Info("hello", "count", 1); err != nil {
+		t.Fatal(err)
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 || msgs[0] != "level=INFO msg=hello tenant=acme count=1" {
+		t.Errorf("unexpected messages: %q", msgs)
+	}
+}
+
+func TestJSONEncoderFlatten(t *testing.T) {
+	encoder := JSONEncoder{Flatten: true, FieldNames: map[string]string{"http.status": "status"}}
+	got, err := encoder.Encode(Entry{Level: LevelInfo, Message: "done", Fields: []Field{
+		{Key: "http", Value: map[string]any{"status": 200, "req": map[string]string{"method": "GET"}}},
+		{Key: "list", Value: []int{1, 2}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"level":"INFO","msg":"done","http.req.method":"GET","status":200,"list":[1,2]}`
+	if got != expected {
+		t.Errorf("expected=%s got=%s", expected, got)
+	}
+}
+
+func TestTemplateEncoder(t *testing.T) {
+	if _, err := NewTemplateEncoder("{{.msg"); err == nil {
+		t.Errorf("expected template parse error")
+	}
+	encoder, err := NewTemplateEncoder("{{.level}} {{.msg}} tenant={{.fields.tenant}} at {{.time.Year}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := encoder.Encode(Entry{
+		Time:    time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC),
+		Level:   LevelError,
+		Message: "quota exceeded",
+		Fields:  []Field{{Key: "tenant", Value: "acme"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ERROR quota exceeded tenant=acme at 2024"; got != expected {
+		t.Errorf("expected=%q got=%q", expected, got)
+	}
+}
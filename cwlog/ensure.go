@@ -0,0 +1,87 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// GroupSpec describes the log group created by EnsureGroup.
+type GroupSpec struct {
+	Name string
+
+	// Class is optional log group class.
+	// If undefined, defaults to types.LogGroupClassStandard ("STANDARD").
+	Class types.LogGroupClass
+
+	// RetentionInDays is optional. If undefined, retention is unchanged.
+	RetentionInDays int32
+
+	// Tags are optionally applied when the group is created.
+	Tags map[string]string
+}
+
+// EnsureGroup creates the log group, if missing, and sets its retention.
+// It is idempotent, for provisioning tools that do not need a Log.
+func EnsureGroup(ctx context.Context, client CloudWatchLogClient, spec GroupSpec) error {
+	if err := ValidateGroupName(spec.Name); err != nil {
+		return err
+	}
+
+	groupInput := &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName:  aws.String(spec.Name),
+		LogGroupClass: spec.Class,
+		Tags:          spec.Tags,
+	}
+
+	if _, errCreateGroup := client.CreateLogGroup(ctx,
+		groupInput); errCreateGroup != nil {
+
+		var errExists *types.ResourceAlreadyExistsException
+		if !errors.As(errCreateGroup, &errExists) {
+			// other error than "already exists" must be reported
+			return fmt.Errorf("create group error: %s%s: %w", spec.Name,
+				callDetails(errCreateGroup), errCreateGroup)
+		}
+
+		// here: already exists error is benign
+	}
+
+	if spec.RetentionInDays == 0 {
+		return nil
+	}
+
+	if _, errRetention := client.PutRetentionPolicy(ctx,
+		&cloudwatchlogs.PutRetentionPolicyInput{LogGroupName: aws.String(spec.Name),
+			RetentionInDays: aws.Int32(spec.RetentionInDays)}); errRetention != nil {
+		return fmt.Errorf("put group retention error: group=%s retention=%d%s: %w",
+			spec.Name, spec.RetentionInDays, callDetails(errRetention), errRetention)
+	}
+
+	return nil
+}
+
+// EnsureStream creates the log stream in group, if missing.
+// The group must exist, see EnsureGroup.
+func EnsureStream(ctx context.Context, client CloudWatchLogClient, group, stream string) error {
+	if err := ValidateStreamName(stream); err != nil {
+		return err
+	}
+
+	if _, errCreateStream := client.CreateLogStream(ctx,
+		&cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String(group),
+			LogStreamName: aws.String(stream)}); errCreateStream != nil {
+
+		var errExists *types.ResourceAlreadyExistsException
+		if !errors.As(errCreateStream, &errExists) {
+			return fmt.Errorf("create log stream error: group=%s stream=%s%s: %w",
+				group, stream, callDetails(errCreateStream), errCreateStream)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEnsureGroupAndStream(t *testing.T) {
+	client := newCloudWatchLogMock()
+	ctx := context.TODO()
+
+	// twice, to verify idempotency
+	for range 2 {
+		if err := EnsureGroup(ctx, client, GroupSpec{Name: "/app/group", RetentionInDays: 7}); err != nil {
+			t.Fatal(err)
+		}
+		if err := EnsureStream(ctx, client, "/app/group", "stream"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, found := client.groups["/app/group"]["stream"]; !found {
+		t.Errorf("stream not created")
+	}
+	if client.retentionInDays != 7 {
+		t.Errorf("unexpected retention: %d", client.retentionInDays)
+	}
+
+	// zero retention keeps the current one
+	if err := EnsureGroup(ctx, client, GroupSpec{Name: "/app/group"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.retentionInDays != 7 {
+		t.Errorf("retention changed: %d", client.retentionInDays)
+	}
+}
+
+func TestEnsureErrors(t *testing.T) {
+	client := newCloudWatchLogMock()
+	ctx := context.TODO()
+
+	if err := EnsureGroup(ctx, client, GroupSpec{Name: "bad:name"}); !errors.Is(err, ErrInvalidGroupName) {
+		t.Errorf("expected ErrInvalidGroupName, got: %v", err)
+	}
+	if err := EnsureStream(ctx, client, "/app/group", "bad*name"); !errors.Is(err, ErrInvalidStreamName) {
+		t.Errorf("expected ErrInvalidStreamName, got: %v", err)
+	}
+	if err := EnsureStream(ctx, client, "/app/missing", "stream"); err == nil {
+		t.Errorf("expected error for missing group")
+	}
+
+	client.denyCreateGroup = true
+	if err := EnsureGroup(ctx, client, GroupSpec{Name: "/app/group"}); err == nil {
+		t.Errorf("expected create group error")
+	}
+}
@@ -0,0 +1,59 @@
+package cwlog
+
+import "sync"
+
+// errorSink records delivery errors. It is shared by a Log, its
+// LevelRouting destinations and its clones.
+type errorSink struct {
+	mu     sync.Mutex
+	last   error
+	ch     chan error // nil unless Options.ErrorsBuffer is defined
+	closed bool
+}
+
+func newErrorSink(buffer int) *errorSink {
+	s := &errorSink{}
+	if buffer > 0 {
+		s.ch = make(chan error, buffer)
+	}
+	return s
+}
+
+func (s *errorSink) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = err
+	if s.ch == nil || s.closed {
+		return
+	}
+	select {
+	case s.ch <- err:
+	default: // nobody listening, drop it
+	}
+}
+
+func (s *errorSink) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch != nil && !s.closed {
+		close(s.ch)
+	}
+	s.closed = true
+}
+
+// LastError returns the most recent PutLogEvents delivery error, including
+// background failures in buffered mode, or nil if none happened yet.
+func (l *Log) LastError() error {
+	l.errs.mu.Lock()
+	defer l.errs.mu.Unlock()
+	return l.errs.last
+}
+
+// Errors returns a channel receiving PutLogEvents delivery errors,
+// including background failures in buffered mode, so supervisory code can
+// observe delivery problems. It requires Options.ErrorsBuffer, otherwise
+// it returns nil. Errors are dropped when the channel is full.
+// The channel is closed by Close.
+func (l *Log) Errors() <-chan error {
+	return l.errs.ch
+}
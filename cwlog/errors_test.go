@@ -0,0 +1,69 @@
+package cwlog
+
+import (
+	"testing"
+)
+
+func TestLastErrorAndErrors(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:        client,
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		Async:         true,
+		FlushInterval: -1,
+		ErrorsBuffer:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.LastError(); err != nil {
+		t.Fatalf("unexpected last error: %v", err)
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = true
+	client.mu.Unlock()
+
+	if err := cw.PutSimple("test 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+
+	last := cw.LastError()
+	if last == nil {
+		t.Fatal("expected last error")
+	}
+
+	select {
+	case err := <-cw.Errors():
+		if err != last {
+			t.Errorf("channel error %v differs from last error %v", err, last)
+		}
+	default:
+		t.Fatal("expected error on channel")
+	}
+
+	// clones share the error sink
+	if clone, errClone := cw.Clone(Options{LogStream: "other"}); errClone != nil {
+		t.Fatal(errClone)
+	} else if clone.LastError() != last {
+		t.Errorf("clone last error mismatch")
+	}
+
+	cw.Close()
+
+	if _, ok := <-cw.Errors(); ok {
+		t.Errorf("expected closed errors channel")
+	}
+}
+
+func TestErrorsDisabled(t *testing.T) {
+	cw := newTestLog(t, newCloudWatchLogMock())
+	if cw.Errors() != nil {
+		t.Errorf("expected nil errors channel without ErrorsBuffer")
+	}
+}
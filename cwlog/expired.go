@@ -0,0 +1,45 @@
+package cwlog
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// expiredCutoff returns the oldest timestamp, in milliseconds, accepted
+// by the group: MaxEventAge, or RetentionInDays if shorter.
+func (l *Log) expiredCutoff(now time.Time) int64 {
+	age := MaxEventAge
+	if retention := time.Duration(l.options.RetentionInDays) * 24 * time.Hour; retention > 0 {
+		age = min(age, retention)
+	}
+	return now.Add(-age).UnixMilli()
+}
+
+// divertExpired returns events without those older than expiredCutoff,
+// which are handed to Options.OnExpired instead.
+// The caller slice is copied only when some event is expired.
+func (l *Log) divertExpired(events []types.InputLogEvent) []types.InputLogEvent {
+	cutoff := l.expiredCutoff(l.options.Now())
+	var kept, expired []types.InputLogEvent
+	for i, e := range events {
+		if aws.ToInt64(e.Timestamp) >= cutoff {
+			if expired != nil {
+				kept = append(kept, e)
+			}
+			continue
+		}
+		if expired == nil {
+			kept = make([]types.InputLogEvent, i, len(events))
+			copy(kept, events[:i])
+		}
+		expired = append(expired, e)
+	}
+	if expired == nil {
+		return events
+	}
+	l.stats.expiredEvents.Add(int64(len(expired)))
+	l.options.OnExpired(l.options.LogGroup, expired)
+	return kept
+}
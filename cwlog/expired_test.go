@@ -0,0 +1,106 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestOnExpired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	table := []struct {
+		name      string
+		retention int32
+		age       time.Duration
+		expired   bool
+	}{
+		{"recent", 0, day, false},
+		{"older than default retention window", 0, 15 * day, true},
+		{"within retention", 3, 2 * day, false},
+		{"older than retention", 3, 4 * day, true},
+		{"retention longer than ingestion window", 365, 20 * day, true},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			client := newCloudWatchLogMock()
+			var diverted []types.InputLogEvent
+			cw, err := New(Options{
+				Client:            client,
+				Now:               func() time.Time { return now },
+				LogGroup:          "/cloudwatchlogs/group",
+				LogStream:         "/cloudwatchlogs/stream",
+				LogStreamTemplate: "{{.LogStream}}",
+				RetentionInDays:   data.retention,
+				OnExpired: func(group string, events []types.InputLogEvent) {
+					if group != "/cloudwatchlogs/group" {
+						t.Errorf("group: %s", group)
+					}
+					diverted = append(diverted, events...)
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := []types.InputLogEvent{
+				newEvent("old", now.Add(-data.age).UnixMilli()),
+				newEvent("now", now.UnixMilli()),
+			}
+			if err := cw.PutLogEvents(events); err != nil {
+				t.Fatal(err)
+			}
+
+			sent := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream"])
+			if data.expired {
+				if len(diverted) != 1 || aws.ToString(diverted[0].Message) != "old" || len(sent) != 1 {
+					t.Errorf("diverted=%v sent=%v", messages(diverted), sent)
+				}
+			} else if len(diverted) != 0 || len(sent) != 2 {
+				t.Errorf("diverted=%v sent=%v", messages(diverted), sent)
+			}
+			if n := cw.Stats().ExpiredEvents; n != int64(len(diverted)) {
+				t.Errorf("expired stat: %d", n)
+			}
+		})
+	}
+}
+
+func TestOnExpiredLeveled(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	client := newCloudWatchLogMock()
+	var diverted []types.InputLogEvent
+	cw, err := New(Options{
+		Client:            client,
+		Now:               func() time.Time { return now },
+		LogGroup:          "/cloudwatchlogs/group",
+		LogStream:         "/cloudwatchlogs/stream",
+		LogStreamTemplate: "{{.LogStream}}",
+		OnExpired: func(_ string, events []types.InputLogEvent) {
+			diverted = append(diverted, events...)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []types.InputLogEvent{
+		newEvent("ERROR old", now.Add(-40*24*time.Hour).UnixMilli()),
+		newEvent("INFO now", now.UnixMilli()),
+	}
+	if err := cw.PutLogEventsSeverity(events, KeywordSeverity()); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream"])
+	if len(diverted) != 1 || len(sent) != 1 {
+		t.Errorf("diverted=%v sent=%v", messages(diverted), sent)
+	}
+	if n := cw.Stats().ExpiredEvents; n != 1 {
+		t.Errorf("expired stat: expected=1 got=%d", n)
+	}
+}
@@ -0,0 +1,110 @@
+package cwlog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampExtractor finds the timestamp of a log line, for PutReaderTimestamps,
+// ImportFile and the cwlogtail tailer. ok is false when line has none.
+// Timestamps from layouts without year, like time.Stamp, have year 0,
+// which those consumers replace with the current year.
+type TimestampExtractor func(line string) (timestamp time.Time, ok bool)
+
+// commonTimeLayouts are tried by RegexTimestamp without layout.
+var commonTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	"02/Jan/2006:15:04:05 -0700",
+	time.Stamp, // syslog, without year
+}
+
+// LayoutTimestamp parses the timestamp at the beginning of each line with
+// a time.Parse layout, like time.RFC3339Nano or "2006-01-02 15:04:05".
+// loc is the time zone of timestamps without one; nil means UTC.
+func LayoutTimestamp(layout string, loc *time.Location) TimestampExtractor {
+	loc = defaultLocation(loc)
+	return func(line string) (time.Time, bool) {
+		return parsePrefix(line, layout, loc)
+	}
+}
+
+// RegexTimestamp finds the timestamp anywhere in a line with re, as its
+// first submatch, or the whole match if it has none, and parses it with
+// layout. An empty layout tries common layouts, like RFC 3339, syslog
+// and access log ones. loc is as for LayoutTimestamp.
+func RegexTimestamp(re *regexp.Regexp, layout string, loc *time.Location) TimestampExtractor {
+	loc = defaultLocation(loc)
+	layouts := commonTimeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	return func(line string) (time.Time, bool) {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			return time.Time{}, false
+		}
+		text := m[0]
+		if len(m) > 1 {
+			text = m[1]
+		}
+		for _, l := range layouts {
+			if t, err := time.ParseInLocation(l, text, loc); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+}
+
+// FirstTimestamp combines extractors for mixed-format sources, returning
+// the timestamp found by the first one that finds it.
+func FirstTimestamp(extractors ...TimestampExtractor) TimestampExtractor {
+	return func(line string) (time.Time, bool) {
+		for _, extract := range extractors {
+			if t, ok := extract(line); ok {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+}
+
+func defaultLocation(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parsePrefix parses a timestamp at the beginning of line, trying both
+// the layout length and its number of space-separated fields, since
+// layouts like time.RFC3339Nano match values of varying length.
+func parsePrefix(line, layout string, loc *time.Location) (time.Time, bool) {
+	if len(line) >= len(layout) {
+		if t, err := time.ParseInLocation(layout, line[:len(layout)], loc); err == nil {
+			return t, true
+		}
+	}
+	n := len(strings.Fields(layout))
+	fields := strings.SplitN(line, " ", n+1)
+	if len(fields) < n {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(layout, strings.Join(fields[:n], " "), loc)
+	return t, err == nil
+}
+
+// completeYear gives timestamps without year the year of now.
+func completeYear(t, now time.Time) time.Time {
+	if t.Year() == 0 {
+		return t.AddDate(now.In(t.Location()).Year(), 0, 0)
+	}
+	return t
+}
@@ -0,0 +1,81 @@
+package cwlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampExtractors(t *testing.T) {
+	syslog := RegexTimestamp(regexp.MustCompile(`^\w{3} [ \d]\d \d\d:\d\d:\d\d`), "", nil)
+	bracketed := RegexTimestamp(regexp.MustCompile(`\[(.+?)\]`), "02/Jan/2006:15:04:05 -0700", nil)
+	mixed := FirstTimestamp(LayoutTimestamp(time.DateTime, nil), syslog)
+
+	table := []struct {
+		name    string
+		extract TimestampExtractor
+		line    string
+		ok      bool
+		want    time.Time
+	}{
+		{"layout", LayoutTimestamp(time.RFC3339Nano, nil), "2024-05-31T22:14:15.5Z msg", true,
+			time.Date(2024, 5, 31, 22, 14, 15, 500000000, time.UTC)},
+		{"layout two fields", LayoutTimestamp(time.DateTime, nil), "2024-05-31 22:14:15 msg", true,
+			time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC)},
+		{"layout location", LayoutTimestamp(time.DateTime, time.FixedZone("X", 3600)), "2024-05-31 22:14:15 msg", true,
+			time.Date(2024, 5, 31, 21, 14, 15, 0, time.UTC)},
+		{"layout no match", LayoutTimestamp(time.RFC3339, nil), "hello world", false, time.Time{}},
+		{"regex common layouts", syslog, "May  1 22:14:15 host app: hi", true,
+			time.Date(0, 5, 1, 22, 14, 15, 0, time.UTC)},
+		{"regex submatch", bracketed, `1.2.3.4 - - [10/Oct/2000:13:55:36 -0700] "GET /"`, true,
+			time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)},
+		{"regex no match", bracketed, "no brackets", false, time.Time{}},
+		{"first of datetime", mixed, "2024-05-31 22:14:15 msg", true,
+			time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC)},
+		{"first of syslog", mixed, "May 31 22:14:15 msg", true,
+			time.Date(0, 5, 31, 22, 14, 15, 0, time.UTC)},
+		{"first of none", mixed, "msg", false, time.Time{}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			got, ok := data.extract(data.line)
+			if ok != data.ok || !got.Equal(data.want) {
+				t.Errorf("expected=%v,%v got=%v,%v", data.want, data.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestCompleteYear(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := completeYear(time.Date(0, 5, 1, 10, 0, 0, 0, time.UTC), now)
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected=%v got=%v", want, got)
+	}
+}
+
+func TestPutReaderTimestamps(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newImportLog(t, client)
+	input := "2024-05-31 22:14:15 first\nno timestamp\n"
+	if err := cw.PutReaderTimestamps(strings.NewReader(input), LayoutTimestamp(time.DateTime, nil)); err != nil {
+		t.Fatal(err)
+	}
+	events := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(events) != 2 {
+		t.Fatalf("events: %v", messages(events))
+	}
+	if ts, want := *events[0].Timestamp, time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC).UnixMilli(); ts != want {
+		t.Errorf("timestamp: expected=%d got=%d", want, ts)
+	}
+	if ts, want := *events[1].Timestamp, (time.Time{}).UnixMilli(); ts != want {
+		t.Errorf("current time: expected=%d got=%d", want, ts)
+	}
+	if msg := *events[0].Message; msg != "2024-05-31 22:14:15 first" {
+		t.Errorf("message changed: %q", msg)
+	}
+}
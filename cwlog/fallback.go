@@ -0,0 +1,65 @@
+package cwlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// FallbackPrefix starts every line written to Options.FallbackWriter,
+// followed by a FallbackEvent JSON object.
+const FallbackPrefix = "cwlog-fallback: "
+
+// FallbackEvent is an event written to Options.FallbackWriter after a
+// failed delivery.
+type FallbackEvent struct {
+	Group     string `json:"group"`
+	Stream    string `json:"stream,omitempty"` // empty when not created yet
+	Timestamp int64  `json:"timestamp"`        // milliseconds since epoch
+	Message   string `json:"message"`
+	Error     string `json:"error"`
+}
+
+// ParseFallback parses a line written to Options.FallbackWriter.
+// ok is false for other lines.
+func ParseFallback(line string) (FallbackEvent, bool) {
+	data, found := strings.CutPrefix(strings.TrimRight(line, "\r\n"), FallbackPrefix)
+	if !found {
+		return FallbackEvent{}, false
+	}
+	var e FallbackEvent
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return FallbackEvent{}, false
+	}
+	return e, true
+}
+
+// fallback writes events of a failed delivery to Options.FallbackWriter.
+// The caller must hold sendMu.
+func (l *Log) fallback(events []types.InputLogEvent, err error) {
+	stream := l.pinnedStream
+	if stream == "" {
+		stream = l.logStreamName
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, e := range events {
+		buf.WriteString(FallbackPrefix)
+		enc.Encode(FallbackEvent{ // strings always encode, adding the newline
+			Group:     l.options.LogGroup,
+			Stream:    stream,
+			Timestamp: aws.ToInt64(e.Timestamp),
+			Message:   aws.ToString(e.Message),
+			Error:     err.Error(),
+		})
+	}
+	l.stats.fallbackEvents.Add(int64(len(events)))
+	l.localMu.Lock()
+	defer l.localMu.Unlock()
+	l.options.FallbackWriter.Write(buf.Bytes()) // best effort
+}
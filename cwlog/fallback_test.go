@@ -0,0 +1,59 @@
+package cwlog
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFallbackWriter(t *testing.T) {
+	var fallback bytes.Buffer
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:         client,
+		Now:            func() time.Time { return time.Time{} },
+		LogGroup:       "/cloudwatchlogs/group",
+		LogStream:      "/cloudwatchlogs/stream",
+		FallbackWriter: &fallback,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("delivered"); err != nil {
+		t.Fatal(err)
+	}
+	if fallback.Len() != 0 {
+		t.Fatalf("unexpected fallback: %q", fallback.String())
+	}
+
+	client.denyPutLog = true
+	if err := cw.PutLines([]string{"line 1", "line <2>\nnext"}); err == nil {
+		t.Fatal("expected delivery error")
+	}
+
+	var events []FallbackEvent
+	scanner := bufio.NewScanner(&fallback)
+	for scanner.Scan() {
+		e, ok := ParseFallback(scanner.Text())
+		if !ok {
+			t.Fatalf("unparsable fallback line: %q", scanner.Text())
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("fallback events: expected=2 got=%d", len(events))
+	}
+	e := events[1]
+	if e.Group != "/cloudwatchlogs/group" || e.Stream != "/cloudwatchlogs/stream-0001-01-01-00" ||
+		e.Message != "line <2>\nnext" || e.Timestamp != (time.Time{}).UnixMilli() || e.Error == "" {
+		t.Errorf("fallback event: %+v", e)
+	}
+	if n := cw.Stats().FallbackEvents; n != 2 {
+		t.Errorf("fallback stat: expected=2 got=%d", n)
+	}
+
+	if _, ok := ParseFallback("other line"); ok {
+		t.Error("parsed unrelated line")
+	}
+}
@@ -0,0 +1,180 @@
+package cwlog
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// GrokMessageKey holds the original line in events converted by Grok,
+// unless a pattern captures a field with the same name.
+const GrokMessageKey = "message"
+
+// grokPatterns are the named patterns usable as %{NAME} in Grok patterns.
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?`,
+	"IP":                `(?:\d{1,3}(?:\.\d{1,3}){3}|[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+)`,
+	"HOSTNAME":          `[0-9A-Za-z][0-9A-Za-z.-]*`,
+	"UUID":              `[0-9A-Fa-f]{8}-(?:[0-9A-Fa-f]{4}-){3}[0-9A-Fa-f]{12}`,
+	"PATH":              `(?:/[^\s/]*)+`,
+	"URIPATHPARAM":      `/[^\s?#]*(?:\?[^\s#]*)?`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|error|err|crit(?:ical)?|fatal|severe|panic)`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+	"HTTPDATE":          `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"SYSLOGTIMESTAMP":   `\w{3} [ \d]\d \d{2}:\d{2}:\d{2}`,
+}
+
+// grokReference matches %{NAME}, %{NAME:field} and %{NAME:field:type}.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::(\w+))?(?::(int|float))?\}`)
+
+// Grok converts plain text messages into JSON objects holding the
+// captures of the first matching pattern, so legacy text logs become
+// queryable by field in Logs Insights. See Options.Grok.
+type Grok struct {
+	patterns []grokPattern
+}
+
+type grokPattern struct {
+	re    *regexp.Regexp
+	types map[string]string // capture name to "int" or "float"
+}
+
+// NewGrok compiles patterns, tried in order. A pattern is a regular
+// expression with named captures, like `(?P<status>\d+)`, and grok-like
+// references: %{NAME} matches a predefined pattern, like IP, INT,
+// NUMBER, WORD, NOTSPACE, DATA, GREEDYDATA, QUOTEDSTRING, LOGLEVEL or
+// TIMESTAMP_ISO8601, %{NAME:field} captures it as field, and
+// %{NAME:field:int} or %{NAME:field:float} also converts it to a number.
+func NewGrok(patterns ...string) (*Grok, error) {
+	g := &Grok{}
+	for _, p := range patterns {
+		compiled, err := compileGrok(p)
+		if err != nil {
+			return nil, err
+		}
+		g.patterns = append(g.patterns, compiled)
+	}
+	return g, nil
+}
+
+func compileGrok(pattern string) (grokPattern, error) {
+	types := map[string]string{}
+	var errRef error
+	expr := grokReference.ReplaceAllStringFunc(pattern, func(ref string) string {
+		m := grokReference.FindStringSubmatch(ref)
+		name, field, typ := m[1], m[2], m[3]
+		def, found := grokPatterns[name]
+		if !found {
+			errRef = fmt.Errorf("grok pattern error: unknown pattern %%{%s}", name)
+			return ref
+		}
+		if field == "" {
+			return "(?:" + def + ")"
+		}
+		if typ != "" {
+			types[field] = typ
+		}
+		return "(?P<" + field + ">" + def + ")"
+	})
+	if errRef != nil {
+		return grokPattern{}, errRef
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return grokPattern{}, fmt.Errorf("grok pattern error: %w", err)
+	}
+	return grokPattern{re: re, types: types}, nil
+}
+
+// Extract returns msg as a JSON object of the captures of the first
+// matching pattern, plus the original line under GrokMessageKey.
+// Captures not matched are omitted, and numbers failing conversion are
+// kept as strings. ok is false when no pattern matches.
+func (g *Grok) Extract(msg string) (string, bool) {
+	for _, p := range g.patterns {
+		m := p.re.FindStringSubmatchIndex(msg)
+		if m == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		hasMessage := false
+		for i, name := range p.re.SubexpNames() {
+			if name == "" || m[2*i] < 0 {
+				continue
+			}
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			hasMessage = hasMessage || name == GrokMessageKey
+			writeJSONString(&buf, name)
+			buf.WriteByte(':')
+			writeGrokValue(&buf, msg[m[2*i]:m[2*i+1]], p.types[name])
+		}
+		if !hasMessage {
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(&buf, GrokMessageKey)
+			buf.WriteByte(':')
+			writeJSONString(&buf, msg)
+		}
+		buf.WriteByte('}')
+		return buf.String(), true
+	}
+	return msg, false
+}
+
+func writeGrokValue(buf *bytes.Buffer, s, typ string) {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			buf.WriteString(strconv.FormatInt(n, 10))
+			return
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+			return
+		}
+	}
+	writeJSONString(buf, s)
+}
+
+// grok returns events with messages converted by Options.Grok.
+// JSON object messages are left unchanged.
+// The caller slice is copied only when some message is converted.
+func (l *Log) grok(events []types.InputLogEvent) []types.InputLogEvent {
+	var result []types.InputLogEvent
+	for i, e := range events {
+		msg := aws.ToString(e.Message)
+		if isJSONObject(msg) {
+			continue
+		}
+		converted, ok := l.options.Grok.Extract(msg)
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, len(events))
+			copy(result, events)
+		}
+		result[i].Message = aws.String(converted)
+	}
+	if result == nil {
+		return events
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGrokExtract(t *testing.T) {
+	g, err := NewGrok(
+		`^%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} %{GREEDYDATA:msg}$`,
+		`^%{IP:client} %{WORD:method} %{URIPATHPARAM:path} %{INT:status:int} %{NUMBER:seconds:float}`,
+		`^user=(?P<user>\w+)(?: id=(?P<id>\d+))?`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name     string
+		line     string
+		ok       bool
+		expected string
+	}{
+		{"first pattern", "2024-05-31T22:14:15Z ERROR disk full", true,
+			`{"time":"2024-05-31T22:14:15Z","level":"ERROR","msg":"disk full","message":"2024-05-31T22:14:15Z ERROR disk full"}`},
+		{"numbers", "10.0.0.1 GET /index.html?a=1 200 0.25", true,
+			`{"client":"10.0.0.1","method":"GET","path":"/index.html?a=1","status":200,"seconds":0.25,"message":"10.0.0.1 GET /index.html?a=1 200 0.25"}`},
+		{"optional capture", "user=alice", true,
+			`{"user":"alice","message":"user=alice"}`},
+		{"escaping", `user=bob id=7 "quoted"`, true,
+			`{"user":"bob","id":"7","message":"user=bob id=7 \"quoted\""}`},
+		{"no match", "hello world", false, "hello world"},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			got, ok := g.Extract(data.line)
+			if ok != data.ok || got != data.expected {
+				t.Errorf("expected=%v,%s got=%v,%s", data.ok, data.expected, ok, got)
+			}
+			if ok && !json.Valid([]byte(got)) {
+				t.Errorf("invalid json: %s", got)
+			}
+		})
+	}
+}
+
+func TestGrokInvalid(t *testing.T) {
+	for _, p := range []string{`%{NOSUCH:x}`, `(?P<x>`} {
+		if _, err := NewGrok(p); err == nil {
+			t.Errorf("expected error for pattern %q", p)
+		}
+	}
+}
+
+func TestGrokOption(t *testing.T) {
+	g, err := NewGrok(`^%{LOGLEVEL:level}: %{GREEDYDATA:msg}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		Grok:      g,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"WARN: slow", `{"level":"INFO"}`, "plain"} {
+		if err := cw.PutSimple(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{
+		`{"level":"WARN","msg":"slow","message":"WARN: slow"}`,
+		`{"level":"INFO"}`,
+		"plain",
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+}
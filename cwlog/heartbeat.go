@@ -0,0 +1,65 @@
+package cwlog
+
+import "sync"
+
+// HeartbeatMessage is the msg field of heartbeat events.
+const HeartbeatMessage = "cwlog heartbeat"
+
+// heartbeat periodically sends a structured event with the Log counters,
+// so that absence-of-logs alarms can tell an idle application from a
+// broken logging pipeline.
+type heartbeat struct {
+	log      *Log
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func startHeartbeat(l *Log) *heartbeat {
+	h := &heartbeat{
+		log:  l,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	ticker := l.options.Clock.NewTicker(l.options.Heartbeat)
+	go h.run(ticker)
+	return h
+}
+
+func (h *heartbeat) run(ticker Ticker) {
+	defer close(h.done)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			h.send()
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// stop waits for the heartbeat goroutine to exit.
+func (h *heartbeat) stop() {
+	h.stopOnce.Do(func() { close(h.quit) })
+	<-h.done
+}
+
+// send puts the heartbeat event at LevelInfo. It bypasses MinLevel,
+// LoadShedding and LevelRouting, since a missing heartbeat must mean a
+// broken pipeline.
+func (h *heartbeat) send() {
+	l := h.log
+	stats := l.Stats()
+	fields := l.structuredFields([]Field{
+		{Key: "dropped_events", Value: stats.DroppedEvents},
+		{Key: "failed_events", Value: stats.FailedEvents},
+		{Key: "failed_batches", Value: stats.FailedBatches},
+		{Key: "fallback_events", Value: stats.FallbackEvents},
+		{Key: "buffered_events", Value: stats.BufferedEvents},
+		{Key: "buffered_bytes", Value: stats.BufferedBytes},
+	})
+	if err := l.putStructured(LevelInfo, l.options.Now(), HeartbeatMessage, fields); err != nil {
+		l.warn("heartbeat failed", "group", l.options.LogGroup, "error", err)
+	}
+}
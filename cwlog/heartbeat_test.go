@@ -0,0 +1,61 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// tickClock is a Clock whose tickers fire only on tick.
+type tickClock struct {
+	c chan time.Time
+}
+
+func (c tickClock) Now() time.Time { return time.Time{} }
+
+func (c tickClock) NewTicker(time.Duration) Ticker { return c }
+
+func (c tickClock) C() <-chan time.Time { return c.c }
+
+func (c tickClock) Stop() {}
+
+func TestHeartbeat(t *testing.T) {
+	clock := tickClock{c: make(chan time.Time)}
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Clock:     clock,
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		MinLevel:  LevelError,
+		Heartbeat: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = true
+	client.mu.Unlock()
+	if err := cw.Error("lost"); err == nil {
+		t.Fatal("expected put error")
+	}
+	client.mu.Lock()
+	client.denyPutLog = false
+	client.mu.Unlock()
+
+	clock.c <- time.Time{}
+	clock.c <- time.Time{} // the first heartbeat is sent once this is received
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	client.mu.Lock()
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	client.mu.Unlock()
+	heartbeat := `{"level":"INFO","msg":"cwlog heartbeat","dropped_events":0,"failed_events":1,"failed_batches":1,"fallback_events":0,"buffered_events":0,"buffered_bytes":0}`
+	expected := []string{heartbeat, heartbeat}
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+}
@@ -0,0 +1,79 @@
+package cwlog
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// clientOptions returns the cloudwatchlogs.NewFromConfig options for the
+// HTTPClient, ProxyURL, CABundle, AppName and AppVersion settings.
+func clientOptions(options Options) ([]func(*cloudwatchlogs.Options), error) {
+	optFns := []func(*cloudwatchlogs.Options){userAgentOption(options)}
+	httpClient, err := httpClientOption(options)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		optFns = append(optFns, httpClient)
+	}
+	return optFns, nil
+}
+
+// httpClientOption returns the client option for the HTTPClient, ProxyURL
+// and CABundle settings, or nil when none is defined.
+func httpClientOption(options Options) (func(*cloudwatchlogs.Options), error) {
+	if options.HTTPClient != nil {
+		if options.ProxyURL != "" || len(options.CABundle) > 0 {
+			return nil, errors.New("HTTPClient excludes ProxyURL and CABundle")
+		}
+		return func(o *cloudwatchlogs.Options) {
+			o.HTTPClient = options.HTTPClient
+		}, nil
+	}
+
+	if options.ProxyURL == "" && len(options.CABundle) == 0 {
+		return nil, nil
+	}
+
+	var proxy *url.URL
+	if options.ProxyURL != "" {
+		u, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("proxy url error: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("proxy url error: missing scheme or host: %q", options.ProxyURL)
+		}
+		proxy = u
+	}
+
+	var roots *x509.CertPool
+	if len(options.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool() // no system pool, like on some platforms
+		}
+		if !pool.AppendCertsFromPEM(options.CABundle) {
+			return nil, errors.New("CA bundle error: no PEM certificate found")
+		}
+		roots = pool
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		if proxy != nil {
+			t.Proxy = http.ProxyURL(proxy)
+		}
+		if roots != nil {
+			t.TLSClientConfig.RootCAs = roots
+		}
+	})
+	return func(o *cloudwatchlogs.Options) {
+		o.HTTPClient = client
+	}, nil
+}
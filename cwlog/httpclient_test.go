@@ -0,0 +1,156 @@
+package cwlog
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// awsTestConfig returns a config with static credentials for endpoint.
+func awsTestConfig(endpoint string) aws.Config {
+	return aws.Config{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		}),
+	}
+}
+
+// fakeLogsHandler accepts every CloudWatch Logs call, recording the
+// request hosts.
+type fakeLogsHandler struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (h *fakeLogsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.hosts = append(h.hosts, r.Host)
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Write([]byte("{}"))
+}
+
+func TestProxyURL(t *testing.T) {
+	handler := &fakeLogsHandler{}
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+
+	cw, err := New(Options{
+		AwsConfig: awsTestConfig("http://logs.example.invalid"),
+		LogGroup:  "/cloudwatchlogs/proxy",
+		ProxyURL:  proxy.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("via proxy"); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.hosts) == 0 {
+		t.Fatal("proxy received no requests")
+	}
+	for _, host := range handler.hosts {
+		if host != "logs.example.invalid" {
+			t.Errorf("proxied host: %s", host)
+		}
+	}
+}
+
+func TestCABundle(t *testing.T) {
+	srv := httptest.NewUnstartedServer(&fakeLogsHandler{})
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake errors
+	srv.StartTLS()
+	defer srv.Close()
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	untrusted := awsTestConfig(srv.URL)
+	untrusted.RetryMaxAttempts = 1
+	_, err := New(Options{
+		AwsConfig:       untrusted,
+		LogGroup:        "/cloudwatchlogs/untrusted",
+		RetentionInDays: 1,
+	})
+	if err == nil {
+		t.Fatal("expected TLS error without CA bundle")
+	}
+
+	cw, err := New(Options{
+		AwsConfig: awsTestConfig(srv.URL),
+		LogGroup:  "/cloudwatchlogs/trusted",
+		CABundle:  bundle,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("trusted"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type countingHTTPClient struct {
+	mu    sync.Mutex
+	calls int
+	next  aws.HTTPClient
+}
+
+func (c *countingHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return c.next.Do(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(&fakeLogsHandler{})
+	defer srv.Close()
+
+	client := &countingHTTPClient{next: srv.Client()}
+	cw, err := New(Options{
+		AwsConfig:  awsTestConfig(srv.URL),
+		LogGroup:   "/cloudwatchlogs/custom-http",
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("custom client"); err != nil {
+		t.Fatal(err)
+	}
+	if client.calls == 0 {
+		t.Error("custom HTTP client not used")
+	}
+
+	_, err = New(Options{
+		AwsConfig:  awsTestConfig(srv.URL),
+		LogGroup:   "/cloudwatchlogs/custom-http",
+		HTTPClient: client,
+		ProxyURL:   "http://proxy.example.invalid:3128",
+	})
+	if err == nil {
+		t.Error("expected error for HTTPClient with ProxyURL")
+	}
+}
+
+func TestClientOptionsInvalid(t *testing.T) {
+	for _, options := range []Options{
+		{ProxyURL: "proxy.example.com:3128"},
+		{ProxyURL: "http://%zz"},
+		{CABundle: []byte("not a certificate")},
+	} {
+		if _, err := httpClientOption(options); err == nil {
+			t.Errorf("expected error for proxy=%q bundle=%q", options.ProxyURL, options.CABundle)
+		}
+	}
+}
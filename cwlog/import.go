@@ -0,0 +1,164 @@
+package cwlog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ImportOptions defines how ImportFile finds the timestamp of each line.
+type ImportOptions struct {
+	// TimeLayout is the time.Parse layout of the timestamp starting each
+	// line, like time.RFC3339. With TimeRegex, it parses the matched text.
+	// See LayoutTimestamp.
+	TimeLayout string
+
+	// TimeRegex optionally finds the timestamp anywhere in a line, as its
+	// first submatch, or the whole match if it has none. Without
+	// TimeLayout, the matched text is tried with common layouts.
+	// See RegexTimestamp.
+	TimeRegex *regexp.Regexp
+
+	// Timestamp optionally replaces TimeLayout and TimeRegex with any
+	// extractor, like FirstTimestamp for files mixing formats.
+	Timestamp TimestampExtractor
+
+	// Location is the time zone of timestamps without one, for TimeLayout
+	// and TimeRegex. Defaults to Options.Location.
+	Location *time.Location
+
+	// Multiline appends lines without timestamp to the event of the
+	// previous line, like stack traces. Otherwise they are sent as
+	// separate events with the timestamp of the previous line.
+	Multiline bool
+}
+
+// ImportFile sends the lines of an existing log file with the timestamps
+// found in them, rather than the current time, for backfilling after an
+// outage. Events are sent in as few PutLogEvents calls as the AWS limits
+// allow, including the 24-hour span of a call. Lines before the first
+// timestamp get its time, or the current time when the file has none.
+// CloudWatch Logs rejects events older than MaxEventAge or the group
+// retention.
+func (l *Log) ImportFile(path string, options ImportOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("import file error: %w", err)
+	}
+	defer f.Close()
+	if err := l.importReader(f, options); err != nil {
+		return fmt.Errorf("import file error: %s: %w", path, err)
+	}
+	return nil
+}
+
+// importReader implements ImportFile.
+func (l *Log) importReader(r io.Reader, options ImportOptions) error {
+	extract, err := options.extractor(l.options.Location)
+	if err != nil {
+		return err
+	}
+
+	s := lineSender{log: l}
+	now := l.options.Now()
+	timestamp := now.UnixMilli()
+	var pending []string // lines of the current multiline event
+	var pendingBytes int
+
+	// events before the first timestamp, held to get its time, so that
+	// the batch stays in chronological order
+	var held []string
+	var heldBytes int
+	known := false
+
+	release := func() error {
+		for _, msg := range held {
+			if err := s.addAt(msg, timestamp); err != nil {
+				return err
+			}
+		}
+		held, heldBytes = nil, 0
+		return nil
+	}
+
+	flushPending := func() error {
+		msg := strings.Join(pending, "\n")
+		pending, pendingBytes = pending[:0], 0
+		if known {
+			return s.addAt(msg, timestamp)
+		}
+		held = append(held, msg)
+		heldBytes += len(msg)
+		if heldBytes > MaxBatchBytes {
+			return release() // no timestamp in sight, send with the current time
+		}
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, errRead := br.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			t, found := extract(line)
+			if found {
+				if len(pending) > 0 {
+					if err := flushPending(); err != nil {
+						return err
+					}
+				}
+				timestamp = completeYear(t, now).UnixMilli()
+				if !known {
+					known = true
+					if err := release(); err != nil {
+						return err
+					}
+				}
+			}
+			pending = append(pending, line)
+			pendingBytes += len(line) + 1
+			// an event larger than the maximum size would be split anyway
+			if !options.Multiline || pendingBytes > maxMessageBytes {
+				if err := flushPending(); err != nil {
+					return err
+				}
+			}
+		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+	if len(pending) > 0 {
+		if err := flushPending(); err != nil {
+			return err
+		}
+	}
+	if err := release(); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// extractor returns the timestamp extractor for options.
+func (options ImportOptions) extractor(loc *time.Location) (TimestampExtractor, error) {
+	if options.Location != nil {
+		loc = options.Location
+	}
+	switch {
+	case options.Timestamp != nil:
+		return options.Timestamp, nil
+	case options.TimeRegex != nil:
+		return RegexTimestamp(options.TimeRegex, options.TimeLayout, loc), nil
+	case options.TimeLayout != "":
+		return LayoutTimestamp(options.TimeLayout, loc), nil
+	}
+	return nil, errors.New("missing TimeLayout, TimeRegex or Timestamp")
+}
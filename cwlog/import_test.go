@@ -0,0 +1,162 @@
+package cwlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestImportFile(t *testing.T) {
+	const content = "2024-03-01T10:00:00Z start\n" +
+		"2024-03-01T10:00:01.500Z panic: boom\n" +
+		"\tgoroutine 1\n" +
+		"\tmain.go:10\n" +
+		"2024-03-02T11:00:00Z next day\n"
+
+	table := []struct {
+		name       string
+		options    ImportOptions
+		messages   []string
+		timestamps []int64
+	}{
+		{
+			"layout multiline",
+			ImportOptions{TimeLayout: time.RFC3339Nano, Multiline: true},
+			[]string{
+				"2024-03-01T10:00:00Z start",
+				"2024-03-01T10:00:01.500Z panic: boom\n\tgoroutine 1\n\tmain.go:10",
+				"2024-03-02T11:00:00Z next day",
+			},
+			[]int64{1709287200000, 1709287201500, 1709377200000},
+		},
+		{
+			"layout single lines",
+			ImportOptions{TimeLayout: time.RFC3339Nano},
+			[]string{
+				"2024-03-01T10:00:00Z start",
+				"2024-03-01T10:00:01.500Z panic: boom",
+				"\tgoroutine 1",
+				"\tmain.go:10",
+				"2024-03-02T11:00:00Z next day",
+			},
+			[]int64{1709287200000, 1709287201500, 1709287201500, 1709287201500, 1709377200000},
+		},
+		{
+			"regex without layout",
+			ImportOptions{TimeRegex: regexp.MustCompile(`^(\S+) `), Multiline: true},
+			[]string{
+				"2024-03-01T10:00:00Z start",
+				"2024-03-01T10:00:01.500Z panic: boom\n\tgoroutine 1\n\tmain.go:10",
+				"2024-03-02T11:00:00Z next day",
+			},
+			[]int64{1709287200000, 1709287201500, 1709377200000},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			client := newCloudWatchLogMock()
+			cw := newImportLog(t, client)
+			if err := cw.ImportFile(path, data.options); err != nil {
+				t.Fatal(err)
+			}
+			events := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+			if got := messages(events); strings.Join(got, "|") != strings.Join(data.messages, "|") {
+				t.Fatalf("messages: expected=%q got=%q", data.messages, got)
+			}
+			for j, e := range events {
+				if ts := aws.ToInt64(e.Timestamp); ts != data.timestamps[j] {
+					t.Errorf("event %d timestamp: expected=%d got=%d", j, data.timestamps[j], ts)
+				}
+			}
+			// the second day exceeds the 24-hour span of a batch
+			if client.puts != 2 {
+				t.Errorf("puts: expected=2 got=%d", client.puts)
+			}
+		})
+	}
+}
+
+func TestImportFileLeadingLines(t *testing.T) {
+	table := []struct {
+		name       string
+		content    string
+		multiline  bool
+		timestamps []int64
+	}{
+		{"single lines", "header\nmore\n2024-03-01T10:00:00Z start\n", false,
+			[]int64{1709287200000, 1709287200000, 1709287200000}},
+		{"multiline", "header\nmore\n2024-03-01T10:00:00Z start\n", true,
+			[]int64{1709287200000, 1709287200000}},
+		{"no timestamps", "header\nmore\n", false,
+			[]int64{(time.Time{}).UnixMilli(), (time.Time{}).UnixMilli()}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			if err := os.WriteFile(path, []byte(data.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			client := newCloudWatchLogMock()
+			cw := newImportLog(t, client)
+			options := ImportOptions{TimeLayout: time.RFC3339, Multiline: data.multiline}
+			if err := cw.ImportFile(path, options); err != nil {
+				t.Fatal(err)
+			}
+			events := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+			if len(events) != len(data.timestamps) {
+				t.Fatalf("events: expected=%d got=%q", len(data.timestamps), messages(events))
+			}
+			for j, e := range events {
+				if ts := aws.ToInt64(e.Timestamp); ts != data.timestamps[j] {
+					t.Errorf("event %d timestamp: expected=%d got=%d", j, data.timestamps[j], ts)
+				}
+			}
+			if client.puts != 1 {
+				t.Errorf("puts: expected=1 got=%d", client.puts)
+			}
+		})
+	}
+}
+
+func TestImportFileErrors(t *testing.T) {
+	cw := newImportLog(t, newCloudWatchLogMock())
+	if err := cw.ImportFile(filepath.Join(t.TempDir(), "missing.log"), ImportOptions{TimeLayout: time.RFC3339}); err == nil {
+		t.Error("expected error for missing file")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("line\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.ImportFile(path, ImportOptions{}); err == nil {
+		t.Error("expected error without TimeLayout, TimeRegex or Timestamp")
+	}
+}
+
+func newImportLog(t *testing.T, client *cloudWatchLogMock) *Log {
+	t.Helper()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cw
+}
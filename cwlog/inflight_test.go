@@ -0,0 +1,83 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// concurrencyClient records the peak number of concurrent PutLogEvents calls.
+type concurrencyClient struct {
+	*cloudWatchLogMock
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func (c *concurrencyClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	n := c.current.Add(1)
+	defer c.current.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+}
+
+func TestMaxInflight(t *testing.T) {
+	table := []struct {
+		name        string
+		maxInflight int
+	}{
+		{"one", 1},
+		{"two", 2},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			client := &concurrencyClient{cloudWatchLogMock: newCloudWatchLogMock()}
+			cw, err := New(Options{
+				Client:       client,
+				Now:          func() time.Time { return time.Time{} },
+				LogGroup:     "/app/all",
+				LogStream:    "stream",
+				Async:        true,
+				FlushWorkers: 4,
+				MaxInflight:  data.maxInflight,
+				LevelRouting: []Route{
+					{MinLevel: LevelInfo, LogGroup: "/app/info"},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stream := range []string{"a", "b"} {
+				clone, errClone := cw.Clone(Options{LogStream: stream})
+				if errClone != nil {
+					t.Fatal(errClone)
+				}
+				if err := clone.PutSimple("from clone"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := cw.Info("hello"); err != nil {
+				t.Fatal(err)
+			}
+			if err := cw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if peak := client.peak.Load(); int(peak) > data.maxInflight {
+				t.Errorf("peak in-flight: max=%d got=%d", data.maxInflight, peak)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Level is the severity of structured events.
+type Level int
+
+// Levels for structured events, from least to most severe.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// String returns the level name, like "INFO".
+func (lv Level) String() string {
+	if lv < LevelDebug || lv > LevelError {
+		return fmt.Sprintf("LEVEL(%d)", int(lv))
+	}
+	return levelNames[lv]
+}
+
+// ParseLevel converts a level name, case insensitive, into Level.
+// "WARNING" is accepted as an alias for "WARN".
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	if name == "WARNING" {
+		return LevelWarn, nil
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LevelDebug, fmt.Errorf("unknown level: %q", s)
+}
+
+// Debug sends a structured event at LevelDebug.
+// keyvals are alternating keys and values added as fields.
+func (l *Log) Debug(msg string, keyvals ...any) error {
+	return l.PutLevel(LevelDebug, msg, keyvals...)
+}
+
+// Info sends a structured event at LevelInfo.
+// keyvals are alternating keys and values added as fields.
+func (l *Log) Info(msg string, keyvals ...any) error {
+	return l.PutLevel(LevelInfo, msg, keyvals...)
+}
+
+// Warn sends a structured event at LevelWarn.
+// keyvals are alternating keys and values added as fields.
+func (l *Log) Warn(msg string, keyvals ...any) error {
+	return l.PutLevel(LevelWarn, msg, keyvals...)
+}
+
+// Error sends a structured event at LevelError.
+// keyvals are alternating keys and values added as fields.
+func (l *Log) Error(msg string, keyvals ...any) error {
+	return l.PutLevel(LevelError, msg, keyvals...)
+}
+
+// PutLevel sends a structured event rendered by Options.Encoder, by default
+// a JSON event like {"level":"INFO","msg":"hello","key":"value"}.
+// Events below Options.MinLevel are silently dropped, as well as DEBUG and
+// INFO events shed by Options.LoadShedding.
+// keyvals are alternating keys and values added as fields.
+func (l *Log) PutLevel(level Level, msg string, keyvals ...any) error {
+	return l.putLevel(level, msg, pairs(keyvals))
+}
+
+// putLevel implements PutLevel for already paired fields.
+func (l *Log) putLevel(level Level, msg string, extra []Field) error {
+	return l.putLevelAt(level, l.options.Now(), msg, extra)
+}
+
+// putLevelAt is like putLevel, with the event time t.
+func (l *Log) putLevelAt(level Level, t time.Time, msg string, extra []Field) error {
+	if level < l.options.MinLevel || l.shed(level) {
+		return nil
+	}
+	fields := l.structuredFields(extra)
+	err := l.putStructured(level, t, msg, fields)
+	if len(l.routes) == 0 {
+		return err
+	}
+	errs := []error{err}
+	for _, r := range l.routes {
+		if level >= r.minLevel {
+			errs = append(errs, r.log.putStructured(level, t, msg, fields))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pairs converts alternating keys and values into fields.
+// A dangling value is reported under key "!BADKEY", like log/slog.
+func pairs(keyvals []any) []Field {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			fields = append(fields, Field{Key: "!BADKEY", Value: keyvals[i]})
+			break
+		}
+		key, isString := keyvals[i].(string)
+		if !isString {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
+	}
+	return fields
+}
+
+func (l *Log) putStructured(level Level, t time.Time, msg string, fields []Field) error {
+	entry := Entry{Time: t, Level: level, Message: msg, Fields: fields}
+	s, err := l.options.Encoder.Encode(entry)
+	if err != nil {
+		return err
+	}
+	return l.putMessageAt(s, t.UnixMilli(), level >= l.options.PriorityLevel)
+}
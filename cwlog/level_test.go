@@ -0,0 +1,57 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLevels(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		MinLevel:  LevelInfo,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Debug("dropped"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Info("hello", "user", "alice", "count", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Error("failed", "err", errors.New("boom"), "dangling"); err != nil {
+		t.Fatal(err)
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	expected := []string{
+		`{"level":"INFO","msg":"hello","user":"alice","count":3}`,
+		`{"level":"ERROR","msg":"failed","err":"boom","!BADKEY":"dangling"}`,
+	}
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%q got=%q", expected, msgs)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, lv := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		got, err := ParseLevel(lv.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != lv {
+			t.Errorf("level: expected=%s got=%s", lv, got)
+		}
+	}
+	if lv, err := ParseLevel("warning"); err != nil || lv != LevelWarn {
+		t.Errorf("warning: expected=WARN got=%s err=%v", lv, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
@@ -0,0 +1,168 @@
+package cwlog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// PutLines sends one event per line, in as few PutLogEvents calls as
+// the AWS batch limits allow. Lines longer than the maximum event size
+// are split into several events. Empty lines are skipped.
+func (l *Log) PutLines(lines []string) error {
+	s := lineSender{log: l}
+	for _, line := range lines {
+		if err := s.add(line); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+// PutReader reads r until EOF, sending one event per line just like PutLines.
+// Memory use is bounded regardless of line length, making it suitable for
+// importing command output or file contents.
+func (l *Log) PutReader(r io.Reader) error {
+	return l.putReader(r, nil)
+}
+
+// PutReaderTimestamps is like PutReader, but timestamps each event with
+// extract, like LayoutTimestamp. Lines without timestamp get the current
+// time. The messages are sent unchanged.
+func (l *Log) PutReaderTimestamps(r io.Reader, extract TimestampExtractor) error {
+	return l.putReader(r, func(line string) (string, time.Time, bool) {
+		t, ok := extract(line)
+		if !ok {
+			return "", time.Time{}, false
+		}
+		return line, completeYear(t, l.options.Now()), true
+	})
+}
+
+// putReader implements PutReader, converting lines with parse when not nil.
+func (l *Log) putReader(r io.Reader, parse lineParser) error {
+	s := lineSender{log: l, parse: parse}
+	br := bufio.NewReader(r)
+	var line []byte
+	for {
+		frag, errRead := br.ReadSlice('\n')
+		line = append(line, frag...)
+
+		for len(line) > maxMessageBytes {
+			cut := RuneCut(line, maxMessageBytes)
+			if err := s.add(string(line[:cut])); err != nil {
+				return err
+			}
+			line = append(line[:0], line[cut:]...)
+		}
+
+		if errors.Is(errRead, bufio.ErrBufferFull) {
+			continue // long line, keep reading it
+		}
+
+		if len(line) > 0 {
+			if err := s.add(string(line)); err != nil {
+				return err
+			}
+			line = line[:0]
+		}
+
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+	return s.flush()
+}
+
+// RuneCut finds a cut position not larger than limit that does not split
+// a UTF-8 sequence, for splitting messages larger than MaxEventSize.
+func RuneCut[T ~string | ~[]byte](b T, limit int) int {
+	if limit >= len(b) {
+		return len(b)
+	}
+	for cut := limit; cut > limit-utf8.UTFMax && cut > 0; cut-- {
+		if utf8.RuneStart(b[cut]) {
+			return cut
+		}
+	}
+	return limit
+}
+
+// lineParser converts a line into a message and its timestamp.
+// ok is false when the line should be sent as is.
+type lineParser func(line string) (message string, timestamp time.Time, ok bool)
+
+// lineSender accumulates lines into batches within the AWS limits.
+type lineSender struct {
+	log    *Log
+	parse  lineParser
+	events []types.InputLogEvent
+	bytes  int
+	first  int64 // lowest timestamp in events
+	last   int64 // highest timestamp in events
+
+	// prepared sends events as they are, without prefix and prepare,
+	// like replayed events already transformed when first sent.
+	prepared bool
+}
+
+func (s *lineSender) add(line string) error {
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	now := s.log.options.Now().UnixMilli()
+	if s.parse != nil && line != "" {
+		if msg, ts, ok := s.parse(line); ok {
+			line, now = msg, ts.UnixMilli()
+		}
+	}
+	return s.addAt(line, now)
+}
+
+// addAt queues message with timestamp, split into several events when
+// larger than the maximum event size.
+func (s *lineSender) addAt(message string, timestamp int64) error {
+	for message != "" {
+		cut := RuneCut(message, maxMessageBytes)
+		e := newEvent(message[:cut], timestamp)
+		message = message[cut:]
+
+		size := EventSize(e)
+		if len(s.events) == MaxBatchEvents || s.bytes+size > MaxBatchBytes ||
+			(len(s.events) > 0 && max(s.last, timestamp)-min(s.first, timestamp) > MaxBatchSpan.Milliseconds()) {
+			if err := s.flush(); err != nil {
+				return err
+			}
+		}
+		if len(s.events) == 0 {
+			s.first, s.last = timestamp, timestamp
+		}
+		s.first, s.last = min(s.first, timestamp), max(s.last, timestamp)
+		s.events = append(s.events, e)
+		s.bytes += size
+	}
+	return nil
+}
+
+func (s *lineSender) flush() error {
+	if len(s.events) == 0 {
+		return nil
+	}
+	var err error
+	if s.prepared {
+		err = s.log.putPrepared(s.events, false)
+	} else {
+		err = s.log.PutLogEvents(s.events)
+	}
+	clear(s.events)
+	s.events = s.events[:0]
+	s.bytes = 0
+	return err
+}
@@ -0,0 +1,72 @@
+package cwlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func newTestLog(t *testing.T, client *cloudWatchLogMock) *Log {
+	t.Helper()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cw
+}
+
+func TestPutLines(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	lines := make([]string, MaxBatchEvents+5)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[3] = ""
+	if err := cw.PutLines(lines); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != len(lines)-1 {
+		t.Fatalf("log lines: expected=%d found=%d", len(lines)-1, len(s))
+	}
+	if client.puts != 2 {
+		t.Fatalf("put calls: expected=2 found=%d", client.puts)
+	}
+}
+
+func TestPutReader(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	long := strings.Repeat("é", maxMessageBytes) // 2 bytes per rune
+	input := "line 1\r\n" + long + "\nline 3"
+
+	if err := cw.PutReader(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	msgs := messages(s)
+	if len(msgs) != 4 {
+		t.Fatalf("log lines: expected=4 found=%d", len(msgs))
+	}
+	if msgs[0] != "line 1" || msgs[3] != "line 3" {
+		t.Fatalf("unexpected messages: %q %q", msgs[0], msgs[3])
+	}
+	if got := msgs[1] + msgs[2]; got != long {
+		t.Fatalf("long line not preserved: len=%d", len(got))
+	}
+	for _, e := range s {
+		if err := validateEvent(e, time.UnixMilli(aws.ToInt64(e.Timestamp))); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+	}
+}
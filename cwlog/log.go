@@ -7,6 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,11 +27,18 @@ type Options struct {
 	// AwsConfig can be created with config.LoadDefaultConfig() from importing "github.com/aws/aws-sdk-go-v2/config".
 	AwsConfig aws.Config
 
-	// LogGroup is required.
+	// LogGroup is required, unless AutoName is enabled.
 	LogGroup string
 
+	// AutoName derives an undefined LogGroup from the executable name,
+	// as "/app/<name>", and an undefined LogStream from the host name,
+	// enabling zero-config usage in simple deployments.
+	AutoName bool
+
 	// LogGroupClass is optional log group class.
 	// If undefined, defaults to types.LogGroupClassStandard ("STANDARD").
+	// New rejects options unsupported by INFREQUENT_ACCESS groups,
+	// like SelfMetricsNamespace.
 	LogGroupClass types.LogGroupClass
 
 	// LogStream defaults to LogGroup.
@@ -40,27 +54,381 @@ type Options struct {
 	// If undefined, it is created automatically from AwsConfig.
 	Client CloudWatchLogClient
 
+	// HTTPClient optionally replaces the HTTP client of the CloudWatch Logs
+	// client created from AwsConfig, for example to customize transport
+	// timeouts. It excludes ProxyURL and CABundle.
+	HTTPClient aws.HTTPClient
+
+	// ProxyURL optionally sends requests through an HTTP proxy, like
+	// "http://proxy.example.com:3128", instead of the proxy taken from
+	// the HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+
+	// CABundle optionally holds PEM certificates trusted in addition to
+	// the system roots, for proxies intercepting TLS with a corporate CA.
+	CABundle []byte
+
+	// AppName and AppVersion optionally identify the application in the
+	// User-Agent of CloudWatch Logs API calls, next to this library, so
+	// CloudTrail and AWS support can attribute the traffic. They apply
+	// to the client created from AwsConfig. AppVersion is ignored without
+	// AppName.
+	AppName    string
+	AppVersion string
+
 	// Now is optional function to get current time, for testing.
-	// If undefined, defaults to time.Time().
+	// If undefined, defaults to Clock.Now when Clock is defined,
+	// or to time.Now() otherwise.
 	Now func() time.Time
+
+	// Clock optionally provides time and tickers, for testing.
+	// If undefined, defaults to the system clock using Now.
+	Clock Clock
+
+	// Location is the time zone used to render stream template timestamps,
+	// making rotation boundaries predictable across hosts.
+	// If undefined, defaults to time.UTC.
+	Location *time.Location
+
+	// Async enables buffered mode: PutLogEvents and PutSimple only queue
+	// events, and a background goroutine sends them in batches.
+	// Queued events are sent when a full batch accumulates, every
+	// FlushInterval, on Flush, or on Close.
+	Async bool
+
+	// BufferEvents limits how many events are queued in buffered mode.
+	// When the buffer is full, new events are dropped with ErrBufferFull.
+	// Defaults to 100000.
+	BufferEvents int
+
+	// FlushInterval bounds how long partially filled batches wait in
+	// buffered mode before being sent. The ticker is created from Clock.
+	// Defaults to 1s. Negative disables it, leaving delivery to full
+	// batches, Flush and Close.
+	FlushInterval time.Duration
+
+	// Context is the optional root context for AWS calls. In buffered mode,
+	// its cancellation stops the background flusher promptly, aborting
+	// in-flight sends, after one final best-effort flush bounded by
+	// FinalFlushTimeout. See also Start. Defaults to context.Background().
+	Context context.Context
+
+	// FinalFlushTimeout bounds the final flush after Context cancellation.
+	// If undefined, defaults to 5 seconds.
+	FinalFlushTimeout time.Duration
+
+	// BootstrapTimeout bounds the group creation and retention setup done
+	// by New, which is also aborted by Context cancellation.
+	// If undefined, defaults to 30 seconds.
+	BootstrapTimeout time.Duration
+
+	// LoadShedding, in buffered mode, samples DEBUG and INFO events from
+	// the leveled APIs, like Info and PutLevel, as the buffer fills up:
+	// half are kept above 50% occupancy, 10% above 75%, and none above 90%.
+	// WARN and ERROR events are always kept, so the process degrades
+	// gracefully instead of dropping arbitrarily when the buffer is full.
+	// Shed events are counted by Stats.ShedEvents.
+	LoadShedding bool
+
+	// AdaptiveBatching, in buffered mode, grows FlushInterval, FlushEvents
+	// and FlushBytes when sends are throttled, up to 16 times, shrinking
+	// them back after consecutive healthy sends. This keeps ingestion within
+	// the PutLogEvents quota without manual tuning. The current values are
+	// reported by Stats.
+	AdaptiveBatching bool
+
+	// FlushEvents and FlushBytes trigger an immediate buffered mode send
+	// when a destination queue reaches that many events or bytes (as
+	// computed by EventSize), keeping batches near the AWS maximums without
+	// exceeding them. They default to, and are capped at, MaxBatchEvents
+	// and MaxBatchBytes.
+	FlushEvents int
+	FlushBytes  int
+
+	// FlushWorkers is the number of destinations sent in parallel by each
+	// buffered mode flush, when LevelRouting destinations or clones share
+	// the buffer. Events for the same stream are always sent in order.
+	// Defaults to 1.
+	FlushWorkers int
+
+	// MaxInflight bounds concurrent PutLogEvents calls across all streams
+	// of a Log, including its LevelRouting destinations and clones, to
+	// protect the connection pool and the account-level CloudWatch TPS quota.
+	// If undefined, concurrency is unbounded.
+	MaxInflight int
+
+	// SequenceTokens enables the legacy sequence token protocol for
+	// endpoints still enforcing it, like some GovCloud, China or older
+	// partitions. The next token is tracked per stream, and calls rejected
+	// with InvalidSequenceTokenException are resent with the expected
+	// token reported by the error.
+	SequenceTokens bool
+
+	// SelfMetricsNamespace, when defined, periodically publishes the Log
+	// DroppedEvents, FailedEvents and FailedBatches counters, covering its
+	// LevelRouting destinations and clones, as CloudWatch custom metrics
+	// in this namespace, using the Embedded Metric Format through the same
+	// stream. This allows alarming on an application silently dropping
+	// logs. The documents skip message
+	// transforms, like Pipeline and SigningKey, and cannot be combined
+	// with AuditChain.
+	SelfMetricsNamespace string
+
+	// SelfMetricsInterval sets the SelfMetricsNamespace publication period.
+	// If undefined, defaults to 1 minute.
+	SelfMetricsInterval time.Duration
+
+	// Heartbeat, when defined, sends a small structured event with
+	// message HeartbeatMessage at this period, embedding the Stats
+	// delivery counters, so that absence-of-logs alarms can tell an idle
+	// application from a broken logging pipeline. Heartbeats ignore
+	// MinLevel and LoadShedding.
+	Heartbeat time.Duration
+
+	// ObserveBatch is optionally called after each successful PutLogEvents
+	// with the exact events sent, allowing tests to assert payloads
+	// against a real client. The events slice must not be retained.
+	ObserveBatch func(group, stream string, events []types.InputLogEvent)
+
+	// OnExpired optionally receives events older than MaxEventAge, or
+	// than RetentionInDays if shorter, instead of sending them to be
+	// rejected by CloudWatch Logs. They are checked before being queued
+	// or sent, after the other message options, and counted in
+	// Stats.ExpiredEvents. The callback may retain the events slice.
+	OnExpired func(group string, events []types.InputLogEvent)
+
+	// TeeWriter optionally mirrors every message locally, one per line,
+	// like os.Stderr or a file, to tell application problems from
+	// delivery problems. Messages are written as sent, after the other
+	// message options, when queued or sent. Write errors are ignored.
+	TeeWriter io.Writer
+
+	// FallbackWriter optionally receives the events of failed deliveries,
+	// after the SDK retries, so logs survive IAM misconfiguration and
+	// network partitions. Each event is written as one line of
+	// FallbackPrefix and a FallbackEvent JSON object. Write errors are
+	// ignored.
+	FallbackWriter io.Writer
+
+	// ErrorsBuffer enables the Errors channel with this capacity.
+	ErrorsBuffer int
+
+	// OnCredentialError is optionally called when PutLogEvents fails with
+	// expired or unrecognized credentials, like ExpiredTokenException, so
+	// the application can refresh its credential source. The call is then
+	// retried once, after invalidating cached AwsConfig credentials.
+	OnCredentialError func(err error)
+
+	// SanitizeUTF8 replaces invalid UTF-8 sequences in messages with
+	// U+FFFD before sending, since CloudWatch rejects or mangles them.
+	SanitizeUTF8 bool
+
+	// StripControl removes control characters other than tab and newline
+	// from messages before sending. It implies SanitizeUTF8.
+	StripControl bool
+
+	// CompressLarge replaces messages larger than the maximum event size
+	// with their gzip+base64 form, prefixed with CompressedPrefix, so very
+	// large records can still be shipped. Use DecodeMessage or DecodeLines
+	// to read them back. Messages still too large once compressed, like
+	// random data, are split into several events. PutLines, PutReader and
+	// Writer still split long lines instead.
+	CompressLarge bool
+
+	// CompressRequests sends PutLogEvents request bodies gzip compressed
+	// (Content-Encoding: gzip), cutting egress bandwidth for high volume
+	// producers, like behind NAT gateways billing data transfer. Small
+	// requests are sent uncompressed.
+	CompressRequests bool
+
+	// SplitLines makes PutSimple send one event per line when the string
+	// contains embedded newlines, all sharing the same timestamp, like the
+	// CloudWatch agent does. Empty lines are skipped.
+	SplitLines bool
+
+	// Multiline optionally joins continuation lines sent by PutSimple and
+	// PutLogEvents, like the lines of Java or Go stack traces, into single
+	// events, see WriterOptions.Multiline. A message matching Multiline
+	// starts a new record, any other message is appended to the pending
+	// record. The record is sent, with the timestamp of its first line,
+	// when the next record starts, after MultilineTimeout, on Flush or on
+	// Close. For children created by With, only the first line of a record
+	// is prefixed. Clones keep their own pending record.
+	// Examples: `^\d{4}-\d{2}-\d{2}` or `^[^\s]`.
+	Multiline *regexp.Regexp
+
+	// MultilineTimeout sends the pending Multiline record once no line
+	// was put for between one and two timeouts, so that the last record of
+	// a quiet source is not held until Close. The ticker is created from
+	// Clock. Defaults to 1s. Negative disables it.
+	MultilineTimeout time.Duration
+
+	// ErrorStack makes PutError include the caller goroutine stack.
+	ErrorStack bool
+
+	// ResubmitRejected resends, in a single follow-up call, events that
+	// CloudWatch rejected as too old, too new or expired while accepting
+	// the rest of the batch. Rejected events still outside the ingestion
+	// window according to the local clock are dropped, unless
+	// ClampTimestamps is enabled.
+	ResubmitRejected bool
+
+	// ClampTimestamps makes ResubmitRejected resend rejected events with
+	// the timestamp set to the current time, instead of dropping them.
+	ClampTimestamps bool
+
+	// MonotonicTimestamps enforces non-decreasing timestamps within each
+	// PutLogEvents call, raising an event stepping backwards to the
+	// timestamp of the previous one, to satisfy ordering expectations of
+	// consumers. Events sharing a millisecond are kept unchanged.
+	// Events with undefined timestamp always get the current time.
+	// In buffered mode, it is enforced again when sending, per stream and
+	// across flushes, since concurrent callers and the priority lanes
+	// reorder queued events.
+	MonotonicTimestamps bool
+
+	// AuditChain makes the stream tamper-evident for audit trails: every
+	// message gets ChainSeparator and the hex SHA-256 of the previous
+	// hash plus the message appended, starting from the SHA-256 of the
+	// stream name. Use VerifyChain to read a stream back and check it.
+	// Timestamps are raised to keep the stream order equal to the chain
+	// order. Events CloudWatch Logs would reject as too old or expired are
+	// dropped before linking, counted in Stats.ExpiredEvents, and events
+	// rejected as too new are left out of the chain, so they can be
+	// resubmitted by ResubmitRejected. Batches pushed over the
+	// PutLogEvents limits by the hashes are sent in parts, each advancing
+	// the chain once delivered; when a later part fails, only its events
+	// count as failed and go to FallbackWriter.
+	AuditChain bool
+
+	// Grok optionally converts plain text messages matching one of its
+	// patterns into JSON objects of the captured fields, before
+	// CompressLarge and SigningKey. See NewGrok.
+	Grok *Grok
+
+	// DropIf optionally drops events before they are queued or sent,
+	// like health checks in access logs, cutting ingestion costs at the
+	// source. It runs after SanitizeUTF8, ahead of Grok and Pipeline,
+	// and drops are counted in Stats.FilteredEvents.
+	DropIf func(Event) bool
+
+	// Pipeline optionally transforms events before they are queued or
+	// sent, after SanitizeUTF8 and Grok, with ordered stages like Enrich,
+	// Redact, Sample or a Grok. Stages may drop events.
+	Pipeline Pipeline
+
+	// SigningKey optionally enables HMAC-SHA256 signing of every message
+	// with a caller-provided key, so integrity can be asserted without
+	// trusting IAM. JSON object messages get the SignatureField field,
+	// other messages get SignatureSeparator and the signature appended.
+	// Messages are signed after CompressLarge and before AuditChain.
+	// Use VerifySignature or VerifyLines to check them.
+	SigningKey []byte
+
+	// DebugLogger optionally receives the package own diagnostics, like
+	// stream rotations, resubmissions, dropped events and background
+	// delivery failures. If undefined, diagnostics are discarded.
+	DebugLogger *slog.Logger
+
+	// PriorityLevel is the minimum level of leveled events sent through the
+	// buffered mode high priority lane, together with PutError events.
+	// See PutLogEventsPriority. If undefined (LevelDebug), defaults to LevelError.
+	PriorityLevel Level
+
+	// MinLevel drops leveled events below this level client-side.
+	// Defaults to LevelDebug, sending everything.
+	MinLevel Level
+
+	// Encoder renders structured events from the leveled APIs (Debug, Info,
+	// Warn, Error, PutLevel) into messages.
+	// If undefined, defaults to JSONEncoder.
+	// See also LogfmtEncoder and NewTemplateEncoder.
+	Encoder Encoder
+
+	// GlobalFields are static fields, like service, version, env or
+	// commit SHA, merged into every structured event: leveled events and
+	// PutError. Fields bound by With and per-call fields take precedence.
+	// Plain messages are not changed.
+	GlobalFields map[string]string
+
+	// LevelRouting optionally copies leveled events to additional
+	// destinations, for example ERROR to "/app/errors" while LogGroup
+	// "/app/all" keeps receiving everything.
+	LevelRouting []Route
 }
 
 var defaultStreamTemplate = "{{.LogStream}}-{{.YYYY}}-{{.MM}}-{{.DD}}-{{.HH}}"
 
 // Log holds cloudwatch client context.
+// Log is safe for concurrent use.
 type Log struct {
+	*core
+
+	fields []Field // bound by With
+	prefix string  // fields rendered for plain messages
+}
+
+// core is the state shared between a Log and its children created by With.
+type core struct {
 	options       Options
 	logStreamName string // last used log stream name
 	templ         *template.Template
+	granularity   granularity
+	streamCache   streamCache
+	queueStream   streamCache // stream of buffered events, guarded by batcher.mu
+	pinnedStream  string      // stream forced by sendLockedTo, guarded by sendMu
+
+	// MonotonicTimestamps state of buffered mode, used by the batcher
+	monotonicStream string
+	monotonicFloor  int64
+
+	// serializes the send path and guards stream state
+	sendMu sync.Mutex
+
+	localMu sync.Mutex // serializes writes to TeeWriter and FallbackWriter
+
+	groups *GroupManager
+	writer *StreamWriter // current stream, replaced on rotation
+	chain  *auditChain   // AuditChain state of the current stream
+
+	// reused across puts to keep allocations off the hot path
+	simpleEvent     [1]types.InputLogEvent
+	simpleMessage   string
+	simpleTimestamp int64
+
+	batcher     *batcher      // non-nil in buffered mode
+	ownsBatcher bool          // false for clones and routes sharing the buffer
+	inflight    chan struct{} // limits concurrent puts, nil if unbounded
+	stats       *logStats     // shared like the batcher
+	routes      []route
+	errs        *errorSink // delivery errors, shared like the batcher
+	ownsErrors  bool       // false for clones and routes sharing the sink
+	selfMetrics *selfMetrics
+	heartbeat   *heartbeat
+	lines       *lineJoiner // non-nil in Multiline mode
+
+	globalFields []Field // from Options.GlobalFields, sorted by key
 }
 
 // New creates cloudwatch client context.
+// The group is created, if missing, and its retention set, at most once per
+// process for the same client (or AwsConfig credentials), region and group.
 func New(options Options) (*Log, error) {
 
+	if options.AutoName {
+		if err := autoName(&options); err != nil {
+			return nil, err
+		}
+	}
+
 	if options.LogGroup == "" {
 		return nil, errors.New("LogGroup is required")
 	}
 
+	if err := ValidateGroupName(options.LogGroup); err != nil {
+		return nil, err
+	}
+
 	if options.LogStream == "" {
 		options.LogStream = options.LogGroup
 	}
@@ -71,51 +439,176 @@ func New(options Options) (*Log, error) {
 
 	tmpl, errTemplate := template.New("logStream").Parse(options.LogStreamTemplate)
 	if errTemplate != nil {
-		return nil, fmt.Errorf("log stream template error: %v", errTemplate)
+		return nil, fmt.Errorf("log stream template error: %w", errTemplate)
 	}
 
 	if options.RetentionInDays == 0 {
 		options.RetentionInDays = 30
 	}
 
-	if options.Client == nil {
-		options.Client = cloudwatchlogs.NewFromConfig(options.AwsConfig)
+	clientFromConfig := options.Client == nil
+	if clientFromConfig {
+		optFns, errHTTP := clientOptions(options)
+		if errHTTP != nil {
+			return nil, errHTTP
+		}
+		options.Client = cloudwatchlogs.NewFromConfig(options.AwsConfig, optFns...)
 	}
 
 	if options.Now == nil {
-		options.Now = time.Now
+		if options.Clock != nil {
+			options.Now = options.Clock.Now
+		} else {
+			options.Now = time.Now
+		}
+	}
+
+	if options.Clock == nil {
+		options.Clock = systemClock{now: options.Now}
+	}
+
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+
+	if options.BootstrapTimeout <= 0 {
+		options.BootstrapTimeout = 30 * time.Second
+	}
+
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+
+	if err := validateStream(tmpl, options); err != nil {
+		return nil, err
+	}
+
+	if err := validateClass(options); err != nil {
+		return nil, err
+	}
+
+	if options.AuditChain && options.SelfMetricsNamespace != "" {
+		return nil, errors.New("AuditChain hashes break self metrics documents: clear SelfMetricsNamespace or AuditChain")
+	}
+
+	if options.Encoder == nil {
+		options.Encoder = JSONEncoder{}
+	}
+
+	if options.PriorityLevel == LevelDebug {
+		options.PriorityLevel = LevelError
 	}
 
-	groupInput := &cloudwatchlogs.CreateLogGroupInput{
-		LogGroupName:  aws.String(options.LogGroup),
-		LogGroupClass: options.LogGroupClass,
+	if err := bootstrapGroup(options, clientFromConfig); err != nil {
+		return nil, err
 	}
 
-	if _, errCreateGroup := options.Client.CreateLogGroup(context.TODO(),
-		groupInput); errCreateGroup != nil {
+	cw := newLog(options, tmpl)
+	cw.errs = newErrorSink(options.ErrorsBuffer)
+	cw.ownsErrors = true
 
-		var errExists *types.ResourceAlreadyExistsException
-		if !errors.As(errCreateGroup, &errExists) {
-			// other error than "already exists" must be reported
-			return nil, fmt.Errorf("create group error: %s: %v", options.LogGroup, errCreateGroup)
+	if options.MaxInflight > 0 {
+		cw.inflight = make(chan struct{}, options.MaxInflight)
+	}
+
+	if options.Async {
+		if cw.options.BufferEvents < 1 {
+			cw.options.BufferEvents = 100000
+		}
+		if cw.options.FlushInterval == 0 {
+			cw.options.FlushInterval = time.Second
 		}
+		if cw.options.FinalFlushTimeout <= 0 {
+			cw.options.FinalFlushTimeout = 5 * time.Second
+		}
+		cw.batcher = newBatcher(cw)
+		cw.ownsBatcher = true
+	}
 
-		// here: already exists error is benign
+	if options.Multiline != nil {
+		cw.lines = newLineJoiner(cw)
 	}
-	if _, errRetention := options.Client.PutRetentionPolicy(context.TODO(),
-		&cloudwatchlogs.PutRetentionPolicyInput{LogGroupName: aws.String(options.LogGroup),
-			RetentionInDays: aws.Int32(options.RetentionInDays)}); errRetention != nil {
-		return nil, fmt.Errorf("put group retention error: group=%s retention=%d: %v",
-			options.LogGroup, options.RetentionInDays, errRetention)
+
+	routes, errRoutes := newRoutes(cw)
+	if errRoutes != nil {
+		cw.Close()
+		return nil, errRoutes
+	}
+	cw.routes = routes
+
+	if options.SelfMetricsNamespace != "" {
+		if cw.options.SelfMetricsInterval <= 0 {
+			cw.options.SelfMetricsInterval = time.Minute
+		}
+		cw.selfMetrics = startSelfMetrics(cw)
 	}
 
-	cw := &Log{
-		options: options,
-		templ:   tmpl,
+	if options.Heartbeat > 0 {
+		cw.heartbeat = startHeartbeat(cw)
 	}
+
 	return cw, nil
 }
 
+// newLog creates the Log state for an already bootstrapped group.
+func newLog(options Options, tmpl *template.Template) *Log {
+	cw := &Log{core: &core{
+		options:     options,
+		templ:       tmpl,
+		granularity: templateGranularity(tmpl.Tree),
+		groups:      newGroupManager(options.Client, options.LogGroup),
+		stats:       &logStats{},
+	}}
+	for _, key := range slices.Sorted(maps.Keys(options.GlobalFields)) {
+		cw.globalFields = append(cw.globalFields,
+			Field{Key: key, Value: options.GlobalFields[key]})
+	}
+	cw.simpleEvent[0] = types.InputLogEvent{
+		Message:   &cw.simpleMessage,
+		Timestamp: &cw.simpleTimestamp,
+	}
+	return cw
+}
+
+// Clone creates a Log for the same group, reusing the client and skipping
+// the group bootstrap, but targeting another stream or stream template.
+// Only overrides.LogStream and overrides.LogStreamTemplate are honored,
+// when defined. Bound fields from With are kept, LevelRouting is not.
+// In buffered mode the clone shares the parent buffer, hence Close on the
+// clone only flushes, while Close on the parent stops both.
+func (l *Log) Clone(overrides Options) (*Log, error) {
+	options := l.options
+	options.LevelRouting = nil
+	if overrides.LogStream != "" {
+		options.LogStream = overrides.LogStream
+	}
+	if overrides.LogStreamTemplate != "" {
+		options.LogStreamTemplate = overrides.LogStreamTemplate
+	}
+
+	tmpl, errTemplate := template.New("logStream").Parse(options.LogStreamTemplate)
+	if errTemplate != nil {
+		return nil, fmt.Errorf("log stream template error: %w", errTemplate)
+	}
+
+	if err := validateStream(tmpl, options); err != nil {
+		return nil, err
+	}
+
+	clone := newLog(options, tmpl)
+	clone.batcher = l.batcher
+	clone.inflight = l.inflight
+	clone.errs = l.errs
+	clone.stats = l.stats
+	clone.groups = l.groups
+	clone.fields = l.fields
+	clone.prefix = l.prefix
+	if options.Multiline != nil {
+		clone.lines = newLineJoiner(clone)
+	}
+	return clone, nil
+}
+
 // LogStreamFields defines fields for log stream name.
 type LogStreamFields struct {
 	LogGroup  string
@@ -135,77 +628,341 @@ func genStream(templ *template.Template, group, stream string, now time.Time) (s
 		DD:        now.Format("02"),
 		HH:        now.Format("15"),
 	}
-	var buf bytes.Buffer
-	err := templ.Execute(&buf, fields)
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	err := templ.Execute(buf, fields)
 	return buf.String(), err
 }
 
+// validateStream renders the stream template for the current time,
+// reporting invalid stream names up front.
+func validateStream(tmpl *template.Template, options Options) error {
+	now := options.Now().In(options.Location)
+	name, err := genStream(tmpl, options.LogGroup, options.LogStream, now)
+	if err != nil {
+		return fmt.Errorf("log stream template error: %w", err)
+	}
+	return ValidateStreamName(name)
+}
+
+// generateStreamName renders the stream template only when the current
+// time leaves the rotation period of the last rendered name.
 func (l *Log) generateStreamName() (string, error) {
-	return genStream(l.templ, l.options.LogGroup, l.options.LogStream, l.options.Now())
+	return l.renderStream(&l.streamCache)
+}
+
+// renderStream renders the stream template for the current time,
+// reusing the name held by cache within its rotation period.
+func (l *Log) renderStream(cache *streamCache) (string, error) {
+	now := l.options.Now().In(l.options.Location)
+	if name, found := cache.get(now); found {
+		return name, nil
+	}
+	name, err := genStream(l.templ, l.options.LogGroup, l.options.LogStream, now)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateStreamName(name); err != nil {
+		return "", err
+	}
+	cache.put(name, l.granularity, now)
+	return name, nil
 }
 
 // PutSimple sends a simple log line.
+// For children created by With, the line is prefixed with the bound fields.
 func (l *Log) PutSimple(s string) error {
+	if l.lines != nil {
+		return l.putLines(s)
+	}
+	if l.options.SplitLines && strings.Contains(s, "\n") {
+		return l.putSplit(s)
+	}
+	if l.prefix != "" {
+		s = l.prefix + s
+	}
+	return l.putMessage(s, false)
+}
+
+// putMessage sends s as a single event, without prefix.
+// priority selects the high priority lane in buffered mode.
+func (l *Log) putMessage(s string, priority bool) error {
+	return l.putMessageAt(s, l.options.Now().UnixMilli(), priority)
+}
+
+// putMessageAt is like putMessage, with the event timestamp now.
+func (l *Log) putMessageAt(s string, now int64, priority bool) error {
+	if l.options.SanitizeUTF8 || l.options.StripControl || l.batcher != nil ||
+		l.options.MonotonicTimestamps || len(l.options.SigningKey) > 0 || l.options.Grok != nil ||
+		len(l.options.Pipeline) > 0 || l.options.DropIf != nil || l.options.TeeWriter != nil ||
+		l.options.OnExpired != nil || (l.options.CompressLarge && len(s) > maxMessageBytes) {
+		return l.putEvents([]types.InputLogEvent{newEvent(s, now)}, priority)
+	}
+	l.sendMu.Lock()
+	defer l.sendMu.Unlock()
+	l.simpleMessage = s
+	l.simpleTimestamp = now
+	_, err := l.send(l.options.Context, l.simpleEvent[:])
+	l.simpleMessage = "" // do not retain caller string
+	return err
+}
+
+// Putf formats according to a format specifier, like fmt.Printf,
+// and sends the result as a simple log line.
+func (l *Log) Putf(format string, args ...any) error {
+	return l.PutSimple(fmt.Sprintf(format, args...))
+}
+
+// putSplit sends one event per line of s.
+func (l *Log) putSplit(s string) error {
 	now := l.options.Now().UnixMilli()
-	return l.PutLogEvents([]types.InputLogEvent{
-		{
-			Message:   aws.String(s),
-			Timestamp: aws.Int64(now),
-		},
-	})
+	var events []types.InputLogEvent
+	for line := range strings.SplitSeq(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		events = append(events, newEvent(l.prefix+line, now))
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return l.putEvents(events, false)
+}
+
+// putLines sends s to the Multiline joiner, one event per line with
+// SplitLines.
+func (l *Log) putLines(s string) error {
+	now := l.options.Now().UnixMilli()
+	if !l.options.SplitLines {
+		return l.lines.put(l.prefix, []types.InputLogEvent{newEvent(s, now)})
+	}
+	var events []types.InputLogEvent
+	for line := range strings.SplitSeq(s, "\n") {
+		events = append(events, newEvent(strings.TrimSuffix(line, "\r"), now))
+	}
+	return l.lines.put(l.prefix, events)
 }
 
 // PutLogEvents sends logs.
+// In buffered mode events are only queued, and they are retained until
+// sent, hence the caller must not modify the values they point to.
+// For children created by With, messages are prefixed with the bound fields.
 func (l *Log) PutLogEvents(events []types.InputLogEvent) error {
+	if l.lines != nil {
+		return l.lines.put(l.prefix, events)
+	}
+	if l.prefix != "" {
+		events = prefixEvents(l.prefix, events)
+	}
+	return l.putEvents(events, false)
+}
 
-	logStream, errStream := l.generateStreamName()
-	if errStream != nil {
-		return errStream
+// PutLogEventsPriority is like PutLogEvents, but in buffered mode events
+// go to the high priority lane, meant for errors and audit records.
+// High priority events are sent ahead of other events, and when the buffer
+// is full they evict low priority events instead of being dropped.
+func (l *Log) PutLogEventsPriority(events []types.InputLogEvent) error {
+	if l.prefix != "" {
+		events = prefixEvents(l.prefix, events)
 	}
+	return l.putEvents(events, true)
+}
 
-	if logStream != l.logStreamName {
-		//
-		// log stream has changed, create it
-		//
-		if _, errCreateStream := l.options.Client.CreateLogStream(context.TODO(),
-			&cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String(l.options.LogGroup),
-				LogStreamName: aws.String(logStream)}); errCreateStream != nil {
+// putEvents sends events without prefix.
+// priority selects the high priority lane in buffered mode.
+func (l *Log) putEvents(events []types.InputLogEvent, priority bool) error {
+	events = l.prepare(events)
+	if len(events) == 0 {
+		return nil // all dropped by Pipeline
+	}
+	return l.putPrepared(events, priority)
+}
 
-			var errExists *types.ResourceAlreadyExistsException
-			if !errors.As(errCreateStream, &errExists) {
-				// other error than "already exists" must be reported
+// putPrepared sends events skipping prepare, for messages already
+// transformed or that must not be transformed.
+func (l *Log) putPrepared(events []types.InputLogEvent, priority bool) error {
+	if l.batcher != nil {
+		return l.batcher.enqueue(l, events, priority)
+	}
+	_, err := l.sendLocked(l.options.Context, events)
+	return err
+}
 
-				l.logStreamName = "" // empty will force new attempt
+// sendLocked sends events synchronously, serialized with other senders.
+func (l *Log) sendLocked(ctx context.Context, events []types.InputLogEvent) (PutResult, error) {
+	l.sendMu.Lock()
+	defer l.sendMu.Unlock()
+	return l.send(ctx, events)
+}
 
-				return fmt.Errorf("create log stream error: group=%s stream=%s: %v",
-					l.options.LogGroup, logStream, errCreateStream)
-			}
+// sendLockedTo is like sendLocked, but sends to stream, a name rendered
+// earlier, like for buffered events queued before a stream rotation.
+// Empty stream selects the current one.
+func (l *Log) sendLockedTo(ctx context.Context, stream string, events []types.InputLogEvent) (PutResult, error) {
+	l.sendMu.Lock()
+	defer l.sendMu.Unlock()
+	l.pinnedStream = stream
+	defer func() { l.pinnedStream = "" }()
+	return l.send(ctx, events)
+}
 
-			// here: already exists error is benign
-		} else {
-			//
-			// created, update log stream
-			//
-			l.logStreamName = logStream
+// send sends events, resubmitting rejected ones if enabled.
+// The caller must hold sendMu.
+func (l *Log) send(ctx context.Context, events []types.InputLogEvent) (PutResult, error) {
+	result, err := l.putLogEvents(ctx, events)
+	failed := events
+	var partial *partialPutError
+	if errors.As(err, &partial) {
+		failed = events[partial.sent:]
+	}
+	if err != nil && l.options.FallbackWriter != nil {
+		l.fallback(failed, err)
+	}
+	if err == nil && result.Rejected != nil && l.options.ResubmitRejected {
+		err = l.resubmit(ctx, events, result.Rejected)
+	}
+	if err != nil {
+		l.stats.failedEvents.Add(int64(len(failed)))
+		l.stats.failedBatches.Add(1)
+		l.errs.record(err)
+	}
+	return result, err
+}
+
+// Flush sends all events queued in buffered mode, including those
+// queued for LevelRouting destinations and clones sharing the buffer.
+// In Multiline mode, the pending record is sent first, otherwise Flush
+// is a no-op when buffered mode is disabled.
+func (l *Log) Flush() error {
+	var errLines error
+	if l.lines != nil {
+		errLines = l.lines.flush()
+	}
+	if l.batcher == nil {
+		return errLines
+	}
+	return errors.Join(errLines, l.batcher.flush())
+}
+
+// Close flushes queued events and stops the buffered mode goroutine,
+// including events of LevelRouting destinations and clones sharing the
+// buffer. For clones, Close only flushes. In Multiline mode, the pending
+// record is sent first, otherwise Close is a no-op when buffered mode is
+// disabled.
+func (l *Log) Close() error {
+	if l.ownsErrors {
+		defer l.errs.close()
+	}
+	if l.heartbeat != nil {
+		l.heartbeat.stop()
+	}
+	if l.selfMetrics != nil {
+		l.selfMetrics.stop()
+	}
+	var errLines error
+	if l.lines != nil {
+		errLines = l.lines.close()
+	}
+	switch {
+	case l.ownsBatcher:
+		return errors.Join(errLines, l.batcher.close())
+	case l.batcher != nil:
+		return errors.Join(errLines, l.batcher.flush())
+	}
+	return errLines
+}
+
+// Start binds ctx to the buffered mode flusher, like Options.Context,
+// for a context not available to New. When ctx is canceled, the flusher
+// stops promptly, after one final best-effort flush bounded by
+// Options.FinalFlushTimeout, and further puts fail with ErrClosed.
+// It does nothing in synchronous mode and for clones.
+func (l *Log) Start(ctx context.Context) {
+	if l.ownsBatcher {
+		l.batcher.watch(ctx)
+	}
+}
+
+// putLogEvents sends events synchronously.
+func (l *Log) putLogEvents(ctx context.Context, events []types.InputLogEvent) (PutResult, error) {
+
+	logStream := l.pinnedStream
+	if logStream == "" {
+		var errStream error
+		logStream, errStream = l.generateStreamName()
+		if errStream != nil {
+			return PutResult{}, errStream
 		}
 	}
 
-	input := &cloudwatchlogs.PutLogEventsInput{
-		LogEvents:     events,
-		LogGroupName:  aws.String(l.options.LogGroup),
-		LogStreamName: aws.String(logStream),
+	if l.writer == nil || logStream != l.writer.Name() {
+		//
+		// log stream has changed, create it
+		//
+		l.debug("stream rotated", "group", l.options.LogGroup,
+			"from", l.logStreamName, "to", logStream)
+
+		l.writer = l.groups.Stream(logStream, StreamOptions{
+			SequenceTokens:   l.options.SequenceTokens,
+			CompressRequests: l.options.CompressRequests,
+			DebugLogger:      l.options.DebugLogger,
+		})
 	}
 
-	_, errPut := l.options.Client.PutLogEvents(context.TODO(), input)
+	if err := l.writer.Create(ctx); err != nil {
+		l.logStreamName = "" // empty will force new attempt
+		l.warn("create stream failed", "group", l.options.LogGroup,
+			"stream", logStream, "error", err)
+		return PutResult{}, err
+	}
+	l.logStreamName = logStream
+
+	if l.options.AuditChain {
+		return l.putChained(ctx, logStream, events)
+	}
+	return l.putBatch(ctx, logStream, events)
+}
+
+// putBatch sends events, fitting one PutLogEvents call, to the current
+// stream writer.
+func (l *Log) putBatch(ctx context.Context, logStream string, events []types.InputLogEvent) (PutResult, error) {
+	out, errPut := l.callPut(ctx, events)
 	if errPut != nil {
-		return fmt.Errorf("PutLogEvents error: group=%s stream=%s: %v",
-			l.options.LogGroup, logStream, errPut)
+		return PutResult{}, fmt.Errorf("PutLogEvents error: group=%s stream=%s%s: %w",
+			l.options.LogGroup, logStream, callDetails(errPut), errPut)
+	}
+
+	if l.options.ObserveBatch != nil {
+		l.options.ObserveBatch(l.options.LogGroup, logStream, events)
 	}
 
-	return nil
+	return newPutResult(logStream, events, out), nil
+}
+
+// callPut calls PutLogEvents on the current stream writer within the
+// in-flight limit. Credential errors are retried once after refreshing
+// credentials.
+func (l *Log) callPut(ctx context.Context, events []types.InputLogEvent) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if l.inflight != nil {
+		select {
+		case l.inflight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-l.inflight }()
+	}
+	out, err := l.writer.PutLogEvents(ctx, events)
+	if err != nil && isCredentialError(err) {
+		l.refreshCredentials(err)
+		out, err = l.writer.PutLogEvents(ctx, events)
+	}
+	return out, err
 }
 
 // CloudWatchLogClient defines testable interface for plugging in CloudWatch Logs client.
+// Like the AWS SDK client, implementations must not retain params after
+// the call returns, since they are reused across calls.
 type CloudWatchLogClient interface {
 	CreateLogGroup(ctx context.Context,
 		params *cloudwatchlogs.CreateLogGroupInput,
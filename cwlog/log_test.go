@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -129,6 +131,48 @@ func TestChangeStream(t *testing.T) {
 	}
 }
 
+func TestPutf(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+	if err := cw.Putf("user=%s attempts=%d", "alice", 3); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 1 {
+		t.Fatalf("log lines: expected=1 found=%d", len(s))
+	}
+	if msg := aws.ToString(s[0].Message); msg != "user=alice attempts=3" {
+		t.Fatalf("message: expected=%q got=%q", "user=alice attempts=3", msg)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		SplitLines: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("line 1\r\nline 2\n\nline 3\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("line 4"); err != nil {
+		t.Fatal(err)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 4 {
+		t.Fatalf("log lines: expected=4 found=%d", len(s))
+	}
+	if msg := aws.ToString(s[1].Message); msg != "line 2" {
+		t.Fatalf("message: expected=%q got=%q", "line 2", msg)
+	}
+}
+
 func TestGroupExists(t *testing.T) {
 	client := newCloudWatchLogMock()
 
@@ -197,21 +241,30 @@ func TestGroupExists(t *testing.T) {
 }
 
 func newCloudWatchLogMock() *cloudWatchLogMock {
-	return &cloudWatchLogMock{groups: map[string]map[string][]types.InputLogEvent{}}
+	return &cloudWatchLogMock{
+		groups:  map[string]map[string][]types.InputLogEvent{},
+		classes: map[string]types.LogGroupClass{},
+	}
 }
 
 type cloudWatchLogMock struct {
+	mu               sync.Mutex
 	denyCreateGroup  bool
 	denyRetention    bool
 	denyCreateStream bool
 	denyPutLog       bool
 	groups           map[string]map[string][]types.InputLogEvent
+	classes          map[string]types.LogGroupClass
 	retentionInDays  int32
+	puts             int
+	rejected         *types.RejectedLogEventsInfo
 }
 
 func (m *cloudWatchLogMock) CreateLogGroup(_ context.Context,
 	params *cloudwatchlogs.CreateLogGroupInput,
 	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.denyCreateGroup {
 		return nil, errors.New("create group denied")
 	}
@@ -224,12 +277,15 @@ func (m *cloudWatchLogMock) CreateLogGroup(_ context.Context,
 	}
 	g := map[string][]types.InputLogEvent{}
 	m.groups[groupName] = g
+	m.classes[groupName] = params.LogGroupClass
 	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
 }
 
 func (m *cloudWatchLogMock) PutRetentionPolicy(_ context.Context,
 	params *cloudwatchlogs.PutRetentionPolicyInput,
 	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.denyRetention {
 		return nil, errors.New("put retention denied")
 	}
@@ -240,6 +296,8 @@ func (m *cloudWatchLogMock) PutRetentionPolicy(_ context.Context,
 func (m *cloudWatchLogMock) CreateLogStream(_ context.Context,
 	params *cloudwatchlogs.CreateLogStreamInput,
 	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.denyCreateStream {
 		return nil, errors.New("create stream denied")
 	}
@@ -263,9 +321,12 @@ func (m *cloudWatchLogMock) CreateLogStream(_ context.Context,
 func (m *cloudWatchLogMock) PutLogEvents(_ context.Context,
 	params *cloudwatchlogs.PutLogEventsInput,
 	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.denyPutLog {
 		return nil, errors.New("put log denied")
 	}
+	m.puts++
 	groupName := aws.ToString(params.LogGroupName)
 	g, foundGroup := m.groups[groupName]
 	if !foundGroup {
@@ -277,7 +338,80 @@ func (m *cloudWatchLogMock) PutLogEvents(_ context.Context,
 		return nil, fmt.Errorf("stream not found: group=%s stream=%s",
 			groupName, streamName)
 	}
-	s = append(s, params.LogEvents...)
+	for _, e := range params.LogEvents {
+		// copy values since params are reused by the caller
+		s = append(s, types.InputLogEvent{
+			Message:   aws.String(aws.ToString(e.Message)),
+			Timestamp: aws.Int64(aws.ToInt64(e.Timestamp)),
+		})
+	}
 	g[streamName] = s
-	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	return &cloudwatchlogs.PutLogEventsOutput{RejectedLogEventsInfo: m.rejected}, nil
+}
+
+func (m *cloudWatchLogMock) DescribeLogGroups(_ context.Context,
+	params *cloudwatchlogs.DescribeLogGroupsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := aws.ToString(params.LogGroupNamePrefix)
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for name := range m.groups {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		arn := "arn:aws:logs:us-east-1:123456789012:log-group:" + name
+		out.LogGroups = append(out.LogGroups, types.LogGroup{
+			LogGroupName:  aws.String(name),
+			Arn:           aws.String(arn + ":*"),
+			LogGroupArn:   aws.String(arn),
+			LogGroupClass: m.classes[name],
+		})
+	}
+	return out, nil
+}
+
+func TestObserveBatch(t *testing.T) {
+	client := newCloudWatchLogMock()
+
+	var observed []string
+	var observedStream string
+
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		ObserveBatch: func(group, stream string, events []types.InputLogEvent) {
+			observedStream = stream
+			for _, e := range events {
+				observed = append(observed, aws.ToString(e.Message))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.PutLines([]string{"test 1", "test 2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if observedStream != "/cloudwatchlogs/stream-0001-01-01-00" {
+		t.Errorf("unexpected observed stream: %s", observedStream)
+	}
+	if len(observed) != 2 || observed[0] != "test 1" || observed[1] != "test 2" {
+		t.Errorf("unexpected observed events: %v", observed)
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = true
+	client.mu.Unlock()
+
+	if err := cw.PutSimple("test 3"); err == nil {
+		t.Fatal("expected put error")
+	}
+	if len(observed) != 2 {
+		t.Errorf("failed put must not be observed: %v", observed)
+	}
 }
@@ -0,0 +1,32 @@
+package cwlog
+
+import "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+// Logger is the core Log API, so applications can inject fakes, like Nop,
+// without depending on a mock CloudWatch Logs client.
+type Logger interface {
+	PutSimple(s string) error
+	PutLogEvents(events []types.InputLogEvent) error
+	Flush() error
+	Close() error
+}
+
+var (
+	_ Logger = (*Log)(nil)
+	_ Logger = Nop{}
+)
+
+// Nop is a Logger discarding everything.
+type Nop struct{}
+
+// PutSimple discards s.
+func (Nop) PutSimple(string) error { return nil }
+
+// PutLogEvents discards events.
+func (Nop) PutLogEvents([]types.InputLogEvent) error { return nil }
+
+// Flush does nothing.
+func (Nop) Flush() error { return nil }
+
+// Close does nothing.
+func (Nop) Close() error { return nil }
@@ -0,0 +1,23 @@
+package cwlog
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestNop(t *testing.T) {
+	var l Logger = Nop{}
+	if err := l.PutSimple("discarded"); err != nil {
+		t.Error(err)
+	}
+	if err := l.PutLogEvents([]types.InputLogEvent{newEvent("discarded", 0)}); err != nil {
+		t.Error(err)
+	}
+	if err := l.Flush(); err != nil {
+		t.Error(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+}
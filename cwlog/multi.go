@@ -0,0 +1,273 @@
+package cwlog
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+// MultiOptions define settings for Multi.
+type MultiOptions struct {
+	// Options are the settings shared by every tenant destination.
+	// In stream mode, Options.LogGroup is the group holding every tenant
+	// stream. LevelRouting is not supported.
+	Options Options
+
+	// GroupTemplate enables group mode, one log group per tenant, like
+	// "/app/tenants/{{.Tenant}}". In group mode, Options.LogGroup is the
+	// neutral group receiving the Heartbeat and SelfMetricsNamespace
+	// events; when undefined, both are disabled, so that no tenant group
+	// receives data about other tenants.
+	GroupTemplate string
+
+	// StreamTemplate names the per-tenant stream in stream mode, used as
+	// the LogStream of Options.LogStreamTemplate. Defaults to "{{.Tenant}}".
+	StreamTemplate string
+
+	// MaxTenants bounds how many tenant destinations are kept initialized,
+	// least recently used evicted first. Evicted destinations are closed,
+	// sending their queued events. Defaults to 1000.
+	MaxTenants int
+}
+
+// MultiFields are the fields available to Multi templates.
+type MultiFields struct {
+	Tenant string
+}
+
+// Multi manages per-tenant destinations, all sharing the client, the
+// buffered mode queue and the in-flight limit. Multi is safe for
+// concurrent use.
+type Multi struct {
+	options    MultiOptions
+	groupTmpl  *template.Template // nil in stream mode
+	streamTmpl *template.Template
+
+	rootMu    sync.Mutex     // serializes the root creation in group mode
+	evictions sync.WaitGroup // evicted destinations being closed
+
+	mu      sync.Mutex
+	root    *Log // owns the shared resources
+	lru     *list.List
+	tenants map[string]*list.Element
+}
+
+// tenantEntry is a tenant destination, initialized once by the first
+// caller while other callers for the same tenant wait on ready.
+type tenantEntry struct {
+	tenant string
+	ready  chan struct{} // closed when log or err is set
+	log    *Log
+	err    error
+}
+
+// NewMulti creates a Multi. In stream mode the group is bootstrapped
+// immediately, in group mode each tenant group is bootstrapped on first
+// use, and the neutral group, if any, immediately.
+func NewMulti(options MultiOptions) (*Multi, error) {
+	if len(options.Options.LevelRouting) > 0 {
+		return nil, errors.New("multi: LevelRouting is not supported")
+	}
+	if options.StreamTemplate == "" {
+		options.StreamTemplate = "{{.Tenant}}"
+	}
+	if options.MaxTenants < 1 {
+		options.MaxTenants = 1000
+	}
+	m := &Multi{
+		options: options,
+		lru:     list.New(),
+		tenants: map[string]*list.Element{},
+	}
+
+	var err error
+	m.streamTmpl, err = template.New("tenantStream").Parse(options.StreamTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("multi: stream template error: %w", err)
+	}
+	if options.GroupTemplate != "" {
+		m.groupTmpl, err = template.New("tenantGroup").Parse(options.GroupTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("multi: group template error: %w", err)
+		}
+		if options.Options.LogGroup == "" {
+			return m, nil // root created with the first tenant
+		}
+	}
+
+	m.root, err = New(options.Options)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// For returns the destination for tenant, initializing it on first use.
+// In group mode, initialization bootstraps the tenant group without
+// blocking callers for other tenants.
+func (m *Multi) For(tenant string) (*Log, error) {
+	m.mu.Lock()
+	if elem, found := m.tenants[tenant]; found {
+		m.lru.MoveToFront(elem)
+		m.mu.Unlock()
+		e := elem.Value.(*tenantEntry)
+		<-e.ready
+		return e.log, e.err
+	}
+	e := &tenantEntry{tenant: tenant, ready: make(chan struct{})}
+	m.tenants[tenant] = m.lru.PushFront(e)
+	var evicted *tenantEntry
+	if m.lru.Len() > m.options.MaxTenants {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		evicted = oldest.Value.(*tenantEntry)
+		delete(m.tenants, evicted.tenant)
+	}
+	m.mu.Unlock()
+
+	if evicted != nil {
+		m.evictions.Add(1)
+		go func() {
+			defer m.evictions.Done()
+			evicted.close()
+		}()
+	}
+
+	e.log, e.err = m.create(tenant)
+	close(e.ready)
+
+	if e.err != nil {
+		// forget the failure, so the next call tries again
+		m.mu.Lock()
+		if elem, found := m.tenants[tenant]; found && elem.Value == e {
+			m.lru.Remove(elem)
+			delete(m.tenants, tenant)
+		}
+		m.mu.Unlock()
+	}
+	return e.log, e.err
+}
+
+// close waits for the tenant initialization, then closes its
+// destination, sending its queued events. Delivery errors are recorded
+// by the shared error sink.
+func (e *tenantEntry) close() {
+	<-e.ready
+	if e.log != nil {
+		e.log.Close()
+	}
+}
+
+// PutJSON sends v as a JSON object to the tenant destination, like For
+// followed by Log.PutJSON, reporting initialization errors as well.
+func (m *Multi) PutJSON(tenant string, v any) error {
+	l, err := m.For(tenant)
+	if err != nil {
+		return err
+	}
+	return l.PutJSON(v)
+}
+
+// create initializes the destination for tenant.
+func (m *Multi) create(tenant string) (*Log, error) {
+	fields := MultiFields{Tenant: tenant}
+	stream, err := render(m.streamTmpl, fields)
+	if err != nil {
+		return nil, fmt.Errorf("multi: stream template error: %w", err)
+	}
+	if m.groupTmpl == nil {
+		return m.root.Clone(Options{LogStream: stream}) // root set by NewMulti
+	}
+
+	group, err := render(m.groupTmpl, fields)
+	if err != nil {
+		return nil, fmt.Errorf("multi: group template error: %w", err)
+	}
+	options := m.options.Options
+	options.LogGroup = group
+	options.LogStream = stream
+	root, err := m.groupRoot(options)
+	if err != nil {
+		return nil, err
+	}
+	options.Async = false             // uses the root buffer
+	options.MaxInflight = 0           // uses the root limit
+	options.SelfMetricsNamespace = "" // reported by the root log
+	options.Heartbeat = 0             // sent by the root log
+	l, err := New(options)
+	if err != nil {
+		return nil, err
+	}
+	l.batcher = root.batcher
+	l.inflight = root.inflight
+	l.errs = root.errs
+	l.stats = root.stats
+	l.ownsErrors = false
+	return l, nil
+}
+
+// groupRoot returns the root log of group mode, creating it from the
+// options of the first tenant when there is no neutral group. Such root
+// only owns the shared resources, it sends no events of its own.
+func (m *Multi) groupRoot(options Options) (*Log, error) {
+	m.rootMu.Lock()
+	defer m.rootMu.Unlock()
+	m.mu.Lock()
+	root := m.root
+	m.mu.Unlock()
+	if root != nil {
+		return root, nil
+	}
+	options.SelfMetricsNamespace = "" // would go to the tenant group
+	options.Heartbeat = 0             // would go to the tenant group
+	root, err := New(options)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.root = root
+	m.mu.Unlock()
+	return root, nil
+}
+
+func render(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
+// Flush sends all events queued in buffered mode for every tenant.
+func (m *Multi) Flush() error {
+	m.mu.Lock()
+	root := m.root
+	m.mu.Unlock()
+	if root == nil {
+		return nil
+	}
+	return root.Flush()
+}
+
+// Close closes the tenant destinations, then flushes and releases the
+// shared resources. Tenant destinations must not be used afterwards.
+func (m *Multi) Close() error {
+	m.mu.Lock()
+	root := m.root
+	var entries []*tenantEntry
+	for elem := m.lru.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*tenantEntry))
+	}
+	m.lru.Init()
+	clear(m.tenants)
+	m.mu.Unlock()
+	for _, e := range entries {
+		e.close()
+	}
+	m.evictions.Wait()
+	if root == nil {
+		return nil
+	}
+	return root.Close()
+}
@@ -0,0 +1,275 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func TestMultiStreams(t *testing.T) {
+	client := newCloudWatchLogMock()
+	m, err := NewMulti(MultiOptions{
+		Options: Options{
+			Client:       client,
+			Now:          func() time.Time { return time.Time{} },
+			LogGroup:     "/app/tenants",
+			Async:        true,
+			GlobalFields: map[string]string{"service": "api"},
+		},
+		StreamTemplate: "tenant-{{.Tenant}}",
+		MaxTenants:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acme, err := m.For("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.For("acme"); again != acme {
+		t.Errorf("tenant destination not cached")
+	}
+	if err := acme.PutJSON(map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	globex, err := m.For("globex") // evicts acme
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := globex.PutJSON(map[string]int{"n": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.For("acme"); again == acme {
+		t.Errorf("least recently used tenant not evicted")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	group := client.groups["/app/tenants"]
+	if msgs := messages(group["tenant-acme-0001-01-01-00"]); len(msgs) != 1 || msgs[0] != `{"n":1,"service":"api"}` {
+		t.Errorf("acme: %q", msgs)
+	}
+	if msgs := messages(group["tenant-globex-0001-01-01-00"]); len(msgs) != 1 || msgs[0] != `{"n":2,"service":"api"}` {
+		t.Errorf("globex: %q", msgs)
+	}
+}
+
+func TestMultiGroups(t *testing.T) {
+	client := newCloudWatchLogMock()
+	m, err := NewMulti(MultiOptions{
+		Options: Options{
+			Client: client,
+			Now:    func() time.Time { return time.Time{} },
+			Async:  true,
+		},
+		GroupTemplate:  "/app/{{.Tenant}}",
+		StreamTemplate: "main",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"acme", "globex"} {
+		l, err := m.For(tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.PutSimple("hello " + tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.For("bad tenant"); err == nil {
+		t.Errorf("expected invalid group name error")
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"acme", "globex"} {
+		msgs := messages(client.groups["/app/"+tenant]["main-0001-01-01-00"])
+		if len(msgs) != 1 || msgs[0] != "hello "+tenant {
+			t.Errorf("%s: %q", tenant, msgs)
+		}
+	}
+}
+
+// slowGroupMock blocks the creation of group slow until release is closed.
+type slowGroupMock struct {
+	*cloudWatchLogMock
+	slow    string
+	release chan struct{}
+	creates atomic.Int32
+}
+
+func (m *slowGroupMock) CreateLogGroup(ctx context.Context,
+	params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if aws.ToString(params.LogGroupName) == m.slow {
+		m.creates.Add(1)
+		<-m.release
+	}
+	return m.cloudWatchLogMock.CreateLogGroup(ctx, params, optFns...)
+}
+
+func TestMultiConcurrentBootstrap(t *testing.T) {
+	client := &slowGroupMock{
+		cloudWatchLogMock: newCloudWatchLogMock(),
+		slow:              "/app/slow",
+		release:           make(chan struct{}),
+	}
+	m, err := NewMulti(MultiOptions{
+		Options: Options{
+			Client: client,
+			Now:    func() time.Time { return time.Time{} },
+		},
+		GroupTemplate:  "/app/{{.Tenant}}",
+		StreamTemplate: "main",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.PutJSON("acme", map[string]int{"n": 1}); err != nil { // creates the root
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	slow := make([]*Log, 2)
+	for i := range slow {
+		wg.Go(func() {
+			l, err := m.For("slow")
+			if err != nil {
+				t.Error(err)
+			}
+			slow[i] = l
+		})
+	}
+
+	// another tenant is not blocked by the slow bootstrap
+	for client.creates.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := m.PutJSON("globex", map[string]int{"n": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	close(client.release)
+	wg.Wait()
+	if slow[0] == nil || slow[0] != slow[1] {
+		t.Errorf("concurrent callers got different destinations")
+	}
+	if n := client.creates.Load(); n != 1 {
+		t.Errorf("slow group creations: expected=1 got=%d", n)
+	}
+	if msgs := messages(client.groups["/app/globex"]["main-0001-01-01-00"]); len(msgs) != 1 {
+		t.Errorf("globex: %q", msgs)
+	}
+}
+
+func TestMultiGroupsNeutral(t *testing.T) {
+	heartbeatCount := func(msgs []string) int {
+		var n int
+		for _, m := range msgs {
+			if strings.Contains(m, HeartbeatMessage) {
+				n++
+			}
+		}
+		return n
+	}
+
+	table := []struct {
+		name     string
+		logGroup string
+	}{
+		{"neutral group", "/app/multi"},
+		{"no neutral group", ""},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			clock := tickClock{c: make(chan time.Time)}
+			client := newCloudWatchLogMock()
+			m, err := NewMulti(MultiOptions{
+				Options: Options{
+					Client:    client,
+					Clock:     clock,
+					LogGroup:  data.logGroup,
+					LogStream: "main",
+					Heartbeat: time.Minute,
+				},
+				GroupTemplate:  "/app/{{.Tenant}}",
+				StreamTemplate: "main",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := m.PutJSON("acme", map[string]int{"n": 1}); err != nil {
+				t.Fatal(err)
+			}
+			if data.logGroup != "" {
+				clock.c <- time.Time{}
+				clock.c <- time.Time{} // the first heartbeat is sent once this is received
+			}
+			if err := m.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			acme := messages(client.groups["/app/acme"]["main-0001-01-01-00"])
+			if len(acme) != 1 || acme[0] != `{"n":1}` {
+				t.Errorf("tenant group: %q", acme)
+			}
+			if data.logGroup == "" {
+				return
+			}
+			neutral := messages(client.groups[data.logGroup]["main-0001-01-01-00"])
+			if heartbeatCount(neutral) != len(neutral) || len(neutral) < 1 {
+				t.Errorf("neutral group: %q", neutral)
+			}
+		})
+	}
+}
+
+func TestMultiEvictionFlushes(t *testing.T) {
+	client := newCloudWatchLogMock()
+	m, err := NewMulti(MultiOptions{
+		Options: Options{
+			Client:        client,
+			Now:           func() time.Time { return time.Time{} },
+			Async:         true,
+			FlushInterval: time.Hour,
+		},
+		GroupTemplate:  "/app/{{.Tenant}}",
+		StreamTemplate: "main",
+		MaxTenants:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.PutJSON("acme", map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.For("globex"); err != nil { // evicts acme
+		t.Fatal(err)
+	}
+	m.evictions.Wait()
+
+	client.mu.Lock()
+	acme := messages(client.groups["/app/acme"]["main-0001-01-01-00"])
+	client.mu.Unlock()
+	if len(acme) != 1 || acme[0] != `{"n":1}` {
+		t.Errorf("evicted tenant not flushed: %q", acme)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,175 @@
+package cwlog
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// multiline joins continuation lines into records, for
+// WriterOptions.Multiline and Options.Multiline. It is not safe for
+// concurrent use.
+type multiline struct {
+	start      *regexp.Regexp
+	record     []byte // record being accumulated
+	recordTime int64
+	pending    bool
+	idle       bool // no line added since the last expire
+}
+
+// add handles one line fitting in one event, with timestamp ts,
+// appending completed records to events. A line matching start begins
+// a new record, with prefix, any other line is appended to the current
+// record.
+func (m *multiline) add(events []types.InputLogEvent, prefix string, line []byte, ts int64) []types.InputLogEvent {
+	m.idle = false
+
+	if m.pending && !m.start.Match(line) &&
+		len(m.record)+1+len(line) <= maxMessageBytes {
+		// continuation line
+		m.record = append(m.record, '\n')
+		m.record = append(m.record, line...)
+		return events
+	}
+
+	events = m.take(events)
+
+	if len(line) == 0 {
+		return events // empty messages are rejected by AWS
+	}
+
+	m.record = append(m.record[:0], prefix...)
+	m.record = append(m.record, line...)
+	m.recordTime = ts
+	m.pending = true
+
+	return events
+}
+
+// take appends the pending record, if any, to events.
+func (m *multiline) take(events []types.InputLogEvent) []types.InputLogEvent {
+	if !m.pending {
+		return events
+	}
+	m.pending = false
+	record := bytes.TrimRight(m.record, "\n") // blank continuation lines
+	return append(events, newEvent(string(record), m.recordTime))
+}
+
+// expire takes the pending record when no line was added since the
+// previous call, so that the last record of a quiet source is sent.
+func (m *multiline) expire(events []types.InputLogEvent) []types.InputLogEvent {
+	if m.idle {
+		events = m.take(events)
+	}
+	m.idle = true
+	return events
+}
+
+// multilineTimer calls expire every multiline timeout, until stopped.
+type multilineTimer struct {
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startMultilineTimer starts the timer, returning nil when timeout is
+// negative.
+func startMultilineTimer(clock Clock, timeout time.Duration, expire func()) *multilineTimer {
+	if timeout < 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = time.Second
+	}
+	t := &multilineTimer{
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	ticker := clock.NewTicker(timeout)
+	go func() {
+		defer close(t.done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				expire()
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// stop waits for the timer goroutine to exit. It is a no-op for nil.
+func (t *multilineTimer) stop() {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() { close(t.quit) })
+	<-t.done
+}
+
+// lineJoiner implements Options.Multiline for a Log and its children
+// created by With.
+type lineJoiner struct {
+	log   *Log
+	mu    sync.Mutex // serializes puts of completed records
+	lines multiline
+	timer *multilineTimer
+}
+
+func newLineJoiner(l *Log) *lineJoiner {
+	j := &lineJoiner{log: l, lines: multiline{start: l.options.Multiline}}
+	j.timer = startMultilineTimer(l.options.Clock, l.options.MultilineTimeout, j.expire)
+	return j
+}
+
+// expire sends the pending record of a quiet source.
+func (j *lineJoiner) expire() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.send(j.lines.expire(nil)); err != nil {
+		j.log.warn("multiline record send failed", "group", j.log.options.LogGroup,
+			"error", err)
+	}
+}
+
+// put joins the messages of events, prefixing new records with prefix,
+// and sends completed records.
+func (j *lineJoiner) put(prefix string, events []types.InputLogEvent) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var records []types.InputLogEvent
+	for _, e := range events {
+		records = j.lines.add(records, prefix, []byte(aws.ToString(e.Message)),
+			aws.ToInt64(e.Timestamp))
+	}
+	return j.send(records)
+}
+
+// flush sends the pending record.
+func (j *lineJoiner) flush() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.send(j.lines.take(nil))
+}
+
+// close stops the timer and sends the pending record.
+func (j *lineJoiner) close() error {
+	j.timer.stop()
+	return j.flush()
+}
+
+// send puts completed records. The caller must hold mu.
+func (j *lineJoiner) send(records []types.InputLogEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return j.log.putEvents(records, false)
+}
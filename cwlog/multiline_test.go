@@ -0,0 +1,121 @@
+package cwlog
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestMultilineLog(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		Async:      true,
+		SplitLines: true,
+		Multiline:  regexp.MustCompile(`^[^\s]`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.PutSimple("panic: boom\n\ngoroutine 1 [running]:"); err != nil {
+		t.Fatal(err)
+	}
+	events := []types.InputLogEvent{
+		{Message: aws.String("main.main()"), Timestamp: aws.Int64(0)},
+		{Message: aws.String("\t/tmp/main.go:5 +0x25"), Timestamp: aws.Int64(0)},
+	}
+	if err := cw.PutLogEvents(events); err != nil {
+		t.Fatal(err)
+	}
+	child := cw.With(map[string]any{"req": 1})
+	if err := child.PutSimple("after\n continued"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"panic: boom",
+		"goroutine 1 [running]:",
+		"main.main()\n\t/tmp/main.go:5 +0x25",
+		"req=1 after\n continued",
+	}
+	got := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, got)
+	}
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	table := []struct {
+		name   string
+		writer bool
+	}{
+		{"log", false},
+		{"writer", true},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			clock := tickClock{c: make(chan time.Time)}
+			client := newCloudWatchLogMock()
+			multiline := regexp.MustCompile(`^[^\s]`)
+			options := Options{
+				Client:    client,
+				Clock:     clock,
+				LogGroup:  "/cloudwatchlogs/group",
+				LogStream: "/cloudwatchlogs/stream",
+			}
+			if !data.writer {
+				options.Multiline = multiline
+			}
+			cw, err := New(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var w *Writer
+			if data.writer {
+				w = NewWriter(cw, WriterOptions{Multiline: multiline})
+				if _, err := w.Write([]byte("start\n more\n")); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := cw.PutSimple("start"); err != nil {
+				t.Fatal(err)
+			} else if err := cw.PutSimple(" more"); err != nil {
+				t.Fatal(err)
+			}
+
+			// a record pending through a whole tick period is sent at the
+			// second tick, processed once the third one is received
+			clock.c <- time.Time{}
+			clock.c <- time.Time{}
+			clock.c <- time.Time{}
+
+			client.mu.Lock()
+			got := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+			client.mu.Unlock()
+			if len(got) != 1 || got[0] != "start\n more" {
+				t.Errorf("pending record not sent: %q", got)
+			}
+
+			if w != nil {
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := cw.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package cwlog
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Event is a log event going through a Stage.
+type Event struct {
+	Message string
+
+	// Timestamp is zero when undefined, and then set to the current
+	// time when sent.
+	Timestamp time.Time
+}
+
+// Stage is one step of Options.Pipeline, like enrichment, redaction,
+// sampling or extraction. Process may change e, and returns false to
+// drop it. Since the tailer and receivers send through a Log, its
+// pipeline applies to them as well.
+type Stage interface {
+	Process(e *Event) bool
+}
+
+// StageFunc adapts a function to Stage.
+type StageFunc func(e *Event) bool
+
+// Process calls f.
+func (f StageFunc) Process(e *Event) bool {
+	return f(e)
+}
+
+// Pipeline runs stages in order, stopping when one drops the event.
+// It is a Stage itself, so pipelines can be nested.
+type Pipeline []Stage
+
+// Process runs e through every stage.
+func (p Pipeline) Process(e *Event) bool {
+	for _, s := range p {
+		if !s.Process(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply runs events through the pipeline, returning a new slice with the
+// kept events. The caller slice is never modified.
+func (p Pipeline) Apply(events []types.InputLogEvent) []types.InputLogEvent {
+	result := make([]types.InputLogEvent, 0, len(events))
+	for _, e := range events {
+		ev := Event{Message: aws.ToString(e.Message)}
+		if e.Timestamp != nil {
+			ev.Timestamp = time.UnixMilli(*e.Timestamp)
+		}
+		if !p.Process(&ev) {
+			continue
+		}
+		out := types.InputLogEvent{Message: aws.String(ev.Message)}
+		if !ev.Timestamp.IsZero() {
+			out.Timestamp = aws.Int64(ev.Timestamp.UnixMilli())
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+// dropFiltered returns events without those matching Options.DropIf.
+// The caller slice is copied only when some event is dropped.
+func (l *Log) dropFiltered(events []types.InputLogEvent) []types.InputLogEvent {
+	var result []types.InputLogEvent
+	for i, e := range events {
+		ev := Event{Message: aws.ToString(e.Message)}
+		if e.Timestamp != nil {
+			ev.Timestamp = time.UnixMilli(*e.Timestamp)
+		}
+		if !l.options.DropIf(ev) {
+			if result != nil {
+				result = append(result, e)
+			}
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, i, len(events))
+			copy(result, events[:i])
+		}
+		l.stats.filteredEvents.Add(1)
+	}
+	if result == nil {
+		return events
+	}
+	return result
+}
+
+// Process converts the message with Extract, leaving JSON objects and
+// messages not matching any pattern unchanged. Grok is a Stage.
+func (g *Grok) Process(e *Event) bool {
+	if !isJSONObject(e.Message) {
+		e.Message, _ = g.Extract(e.Message)
+	}
+	return true
+}
+
+// Enrich returns a stage adding fields to every event: JSON object
+// messages get them as fields, other messages get them prefixed as
+// key=value pairs, like children created by Log.With.
+func Enrich(fields ...Field) Stage {
+	prefix := renderPrefix(fields)
+	return StageFunc(func(e *Event) bool {
+		if isJSONObject(e.Message) {
+			if data, err := appendFields([]byte(e.Message), fields); err == nil {
+				e.Message = string(data)
+				return true
+			}
+		}
+		e.Message = prefix + e.Message
+		return true
+	})
+}
+
+// Redact returns a stage replacing every match of re in messages with
+// replacement, which may refer to submatches like regexp.ReplaceAllString.
+func Redact(re *regexp.Regexp, replacement string) Stage {
+	return StageFunc(func(e *Event) bool {
+		e.Message = re.ReplaceAllString(e.Message, replacement)
+		return true
+	})
+}
+
+// sampleRand returns a number in [0,1). It is a variable for testing.
+var sampleRand = rand.Float64
+
+// Sample returns a stage keeping about fraction of the events, randomly,
+// like 0.1 for one in ten.
+func Sample(fraction float64) Stage {
+	return StageFunc(func(*Event) bool {
+		return fraction >= 1 || sampleRand() < fraction
+	})
+}
@@ -0,0 +1,151 @@
+package cwlog
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestStages(t *testing.T) {
+	grok, err := NewGrok(`^%{LOGLEVEL:level} %{GREEDYDATA:msg}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrich := Enrich(Field{Key: "env", Value: "prod"})
+	redact := Redact(regexp.MustCompile(`card=\d+`), "card=***")
+
+	table := []struct {
+		name     string
+		stage    Stage
+		message  string
+		keep     bool
+		expected string
+	}{
+		{"enrich json", enrich, `{"a":1}`, true, `{"a":1,"env":"prod"}`},
+		{"enrich empty json", enrich, `{}`, true, `{"env":"prod"}`},
+		{"enrich text", enrich, "hello", true, "env=prod hello"},
+		{"redact", redact, "pay card=1234 ok", true, "pay card=*** ok"},
+		{"grok", grok, "INFO up", true, `{"level":"INFO","msg":"up","message":"INFO up"}`},
+		{"grok json unchanged", grok, `{"level":"INFO"}`, true, `{"level":"INFO"}`},
+		{"sample all", Sample(1), "x", true, "x"},
+		{"sample none", Sample(0), "x", false, "x"},
+		{"nested pipeline", Pipeline{redact, enrich}, "card=1", true, "env=prod card=***"},
+		{"drop stops pipeline", Pipeline{Sample(0), enrich}, "x", false, "x"},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			e := Event{Message: data.message}
+			keep := data.stage.Process(&e)
+			if keep != data.keep || e.Message != data.expected {
+				t.Errorf("expected=%v,%q got=%v,%q", data.keep, data.expected, keep, e.Message)
+			}
+		})
+	}
+}
+
+func TestPipelineApply(t *testing.T) {
+	dropSecret := StageFunc(func(e *Event) bool { return e.Message != "secret" })
+	shift := StageFunc(func(e *Event) bool {
+		if !e.Timestamp.IsZero() {
+			e.Timestamp = e.Timestamp.Add(time.Second)
+		}
+		return true
+	})
+	events := []types.InputLogEvent{
+		newEvent("a", 1000),
+		newEvent("secret", 2000),
+		{Message: aws.String("no timestamp")},
+	}
+	out := Pipeline{dropSecret, shift}.Apply(events)
+	if len(out) != 2 {
+		t.Fatalf("events: %v", messages(out))
+	}
+	if ts := aws.ToInt64(out[0].Timestamp); ts != 2000 {
+		t.Errorf("timestamp: expected=2000 got=%d", ts)
+	}
+	if out[1].Timestamp != nil {
+		t.Errorf("undefined timestamp changed: %d", *out[1].Timestamp)
+	}
+	if *events[1].Message != "secret" || len(events) != 3 {
+		t.Error("caller slice modified")
+	}
+}
+
+func TestPipelineOption(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		Pipeline: Pipeline{
+			StageFunc(func(e *Event) bool { return e.Message != "/healthz" }),
+			Enrich(Field{Key: "app", Value: "api"}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"/healthz", "/orders"} {
+		if err := cw.PutSimple(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Info("done"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"app=api /orders", `{"level":"INFO","msg":"done","app":"api"}`}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+	if client.puts != 2 {
+		t.Errorf("puts: expected=2 got=%d", client.puts)
+	}
+}
+
+func TestDropIf(t *testing.T) {
+	healthCheck := regexp.MustCompile(`GET /healthz`)
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		DropIf:    func(e Event) bool { return healthCheck.MatchString(e.Message) },
+		Pipeline:  Pipeline{StageFunc(func(e *Event) bool { return e.Message != "noise" })},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []types.InputLogEvent{
+		newEvent("GET /healthz 200", 0),
+		newEvent("GET /orders 200", 0),
+		newEvent("GET /healthz 200", 0),
+		newEvent("noise", 0),
+	}
+	if err := cw.PutLogEvents(events); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("GET /healthz 200"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 || msgs[0] != "GET /orders 200" {
+		t.Errorf("messages: %q", msgs)
+	}
+	if n := cw.Stats().FilteredEvents; n != 4 {
+		t.Errorf("filtered: expected=4 got=%d", n)
+	}
+	if client.puts != 1 {
+		t.Errorf("puts: expected=1 got=%d", client.puts)
+	}
+}
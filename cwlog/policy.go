@@ -0,0 +1,141 @@
+package cwlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// AccountPolicyClient is implemented by clients supporting account-level
+// log policies, like the AWS SDK client.
+type AccountPolicyClient interface {
+	PutAccountPolicy(ctx context.Context,
+		params *cloudwatchlogs.PutAccountPolicyInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutAccountPolicyOutput, error)
+	DescribeAccountPolicies(ctx context.Context,
+		params *cloudwatchlogs.DescribeAccountPoliciesInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeAccountPoliciesOutput, error)
+	DeleteAccountPolicy(ctx context.Context,
+		params *cloudwatchlogs.DeleteAccountPolicyInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteAccountPolicyOutput, error)
+}
+
+// AccountPolicy is an account-wide log policy.
+type AccountPolicy struct {
+	Name string
+	Type types.PolicyType
+
+	// Document is the JSON policy document. See DataProtectionPolicyDocument
+	// and SubscriptionPolicyDocument.
+	Document string
+
+	// SelectionCriteria optionally restricts the policy to some log groups,
+	// like `LogGroupName NOT IN ["excluded"]`.
+	SelectionCriteria string
+}
+
+// PutAccountPolicy creates or replaces an account policy,
+// applied to all log groups in the account.
+func PutAccountPolicy(ctx context.Context, client AccountPolicyClient, policy AccountPolicy) error {
+	input := &cloudwatchlogs.PutAccountPolicyInput{
+		PolicyName:     aws.String(policy.Name),
+		PolicyType:     policy.Type,
+		PolicyDocument: aws.String(policy.Document),
+		Scope:          types.ScopeAll,
+	}
+	if policy.SelectionCriteria != "" {
+		input.SelectionCriteria = aws.String(policy.SelectionCriteria)
+	}
+	if _, err := client.PutAccountPolicy(ctx, input); err != nil {
+		return fmt.Errorf("put account policy error: name=%s type=%s: %w",
+			policy.Name, policy.Type, err)
+	}
+	return nil
+}
+
+// ListAccountPolicies returns the account policies of policyType.
+func ListAccountPolicies(ctx context.Context, client AccountPolicyClient, policyType types.PolicyType) ([]types.AccountPolicy, error) {
+	var policies []types.AccountPolicy
+	input := &cloudwatchlogs.DescribeAccountPoliciesInput{PolicyType: policyType}
+	for {
+		out, err := client.DescribeAccountPolicies(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("describe account policies error: type=%s: %w",
+				policyType, err)
+		}
+		policies = append(policies, out.AccountPolicies...)
+		if aws.ToString(out.NextToken) == "" {
+			return policies, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// DeleteAccountPolicy removes an account policy.
+func DeleteAccountPolicy(ctx context.Context, client AccountPolicyClient, name string, policyType types.PolicyType) error {
+	if _, err := client.DeleteAccountPolicy(ctx, &cloudwatchlogs.DeleteAccountPolicyInput{
+		PolicyName: aws.String(name),
+		PolicyType: policyType,
+	}); err != nil {
+		return fmt.Errorf("delete account policy error: name=%s type=%s: %w",
+			name, policyType, err)
+	}
+	return nil
+}
+
+// DataProtectionPolicyDocument builds a data protection policy document
+// that audits and masks the given managed data identifiers, like
+// "EmailAddress" or "AwsSecretKey". Full identifier ARNs are kept as is.
+func DataProtectionPolicyDocument(name string, identifiers ...string) string {
+	arns := make([]string, 0, len(identifiers))
+	for _, id := range identifiers {
+		if !strings.HasPrefix(id, "arn:") {
+			id = "arn:aws:dataprotection::aws:data-identifier/" + id
+		}
+		arns = append(arns, id)
+	}
+	doc := map[string]any{
+		"Name":    name,
+		"Version": "2021-06-01",
+		"Statement": []map[string]any{
+			{
+				"Sid":            "audit",
+				"DataIdentifier": arns,
+				"Operation": map[string]any{
+					"Audit": map[string]any{"FindingsDestination": map[string]any{}},
+				},
+			},
+			{
+				"Sid":            "redact",
+				"DataIdentifier": arns,
+				"Operation": map[string]any{
+					"Deidentify": map[string]any{"MaskConfig": map[string]any{}},
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(doc) // only strings and maps
+	return string(data)
+}
+
+// SubscriptionPolicyDocument builds a subscription filter policy document
+// forwarding events matching filterPattern to destinationARN, like a
+// Kinesis stream or Lambda function. roleARN is required for Kinesis
+// and Firehose destinations.
+func SubscriptionPolicyDocument(destinationARN, roleARN, filterPattern string) string {
+	doc := map[string]string{
+		"DestinationArn": destinationARN,
+		"FilterPattern":  filterPattern,
+		"Distribution":   string(types.DistributionRandom),
+	}
+	if roleARN != "" {
+		doc["RoleArn"] = roleARN
+	}
+	data, _ := json.Marshal(doc) // only strings
+	return string(data)
+}
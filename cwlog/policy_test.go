@@ -0,0 +1,139 @@
+package cwlog
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type accountPolicyMock struct {
+	policies map[string]types.AccountPolicy
+}
+
+func (m *accountPolicyMock) PutAccountPolicy(_ context.Context,
+	params *cloudwatchlogs.PutAccountPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutAccountPolicyOutput, error) {
+	m.policies[aws.ToString(params.PolicyName)] = types.AccountPolicy{
+		PolicyName:        params.PolicyName,
+		PolicyType:        params.PolicyType,
+		PolicyDocument:    params.PolicyDocument,
+		Scope:             params.Scope,
+		SelectionCriteria: params.SelectionCriteria,
+	}
+	return &cloudwatchlogs.PutAccountPolicyOutput{}, nil
+}
+
+// DescribeAccountPolicies returns one policy per page, to exercise paging.
+func (m *accountPolicyMock) DescribeAccountPolicies(_ context.Context,
+	params *cloudwatchlogs.DescribeAccountPoliciesInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeAccountPoliciesOutput, error) {
+	var names []string
+	for name, p := range m.policies {
+		if p.PolicyType == params.PolicyType {
+			names = append(names, name)
+		}
+	}
+	out := &cloudwatchlogs.DescribeAccountPoliciesOutput{}
+	var i int
+	if params.NextToken != nil {
+		i = len(aws.ToString(params.NextToken))
+	}
+	if i < len(names) {
+		out.AccountPolicies = []types.AccountPolicy{m.policies[names[i]]}
+	}
+	if i+1 < len(names) {
+		out.NextToken = aws.String(strings.Repeat("x", i+1))
+	}
+	return out, nil
+}
+
+func (m *accountPolicyMock) DeleteAccountPolicy(_ context.Context,
+	params *cloudwatchlogs.DeleteAccountPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteAccountPolicyOutput, error) {
+	name := aws.ToString(params.PolicyName)
+	if _, found := m.policies[name]; !found {
+		return nil, &types.ResourceNotFoundException{}
+	}
+	delete(m.policies, name)
+	return &cloudwatchlogs.DeleteAccountPolicyOutput{}, nil
+}
+
+func TestAccountPolicies(t *testing.T) {
+	client := &accountPolicyMock{policies: map[string]types.AccountPolicy{}}
+	ctx := context.TODO()
+
+	doc := DataProtectionPolicyDocument("pii", "EmailAddress",
+		"arn:aws:dataprotection::aws:data-identifier/AwsSecretKey")
+	for _, name := range []string{"pii", "pii-2"} {
+		if err := PutAccountPolicy(ctx, client, AccountPolicy{
+			Name:              name,
+			Type:              types.PolicyTypeDataProtectionPolicy,
+			Document:          doc,
+			SelectionCriteria: `LogGroupName NOT IN ["excluded"]`,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policies, err := ListAccountPolicies(ctx, client, types.PolicyTypeDataProtectionPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(policies))
+	}
+	if policies[0].Scope != types.ScopeAll {
+		t.Errorf("unexpected scope: %s", policies[0].Scope)
+	}
+
+	if err := DeleteAccountPolicy(ctx, client, "pii", types.PolicyTypeDataProtectionPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteAccountPolicy(ctx, client, "pii", types.PolicyTypeDataProtectionPolicy); err == nil {
+		t.Errorf("expected error deleting missing policy")
+	}
+}
+
+func TestDataProtectionPolicyDocument(t *testing.T) {
+	doc := DataProtectionPolicyDocument("pii", "EmailAddress",
+		"arn:aws:dataprotection::aws:data-identifier/AwsSecretKey")
+
+	var policy struct {
+		Name      string
+		Statement []struct {
+			DataIdentifier []string
+			Operation      map[string]any
+		}
+	}
+	if err := json.Unmarshal([]byte(doc), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Statement) != 2 {
+		t.Fatalf("expected audit and redact statements: %s", doc)
+	}
+	ids := policy.Statement[1].DataIdentifier
+	if len(ids) != 2 || ids[0] != "arn:aws:dataprotection::aws:data-identifier/EmailAddress" ||
+		ids[1] != "arn:aws:dataprotection::aws:data-identifier/AwsSecretKey" {
+		t.Errorf("unexpected identifiers: %v", ids)
+	}
+	if _, found := policy.Statement[1].Operation["Deidentify"]; !found {
+		t.Errorf("missing deidentify operation: %s", doc)
+	}
+}
+
+func TestSubscriptionPolicyDocument(t *testing.T) {
+	doc := SubscriptionPolicyDocument("arn:aws:kinesis:us-east-1:123456789012:stream/logs",
+		"arn:aws:iam::123456789012:role/cwl", "ERROR")
+	var policy map[string]string
+	if err := json.Unmarshal([]byte(doc), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy["RoleArn"] != "arn:aws:iam::123456789012:role/cwl" || policy["FilterPattern"] != "ERROR" {
+		t.Errorf("unexpected document: %s", doc)
+	}
+}
@@ -0,0 +1,102 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+)
+
+// ErrorEvent is the structured event sent by PutError.
+type ErrorEvent struct {
+	Error string       `json:"error"`
+	Type  string       `json:"type"`
+	Chain []ErrorCause `json:"chain,omitempty"`
+	Stack string       `json:"stack,omitempty"`
+}
+
+// ErrorCause is one error found by unwrapping.
+type ErrorCause struct {
+	Error string `json:"error"`
+	Type  string `json:"type"`
+}
+
+// PutError sends err as a structured JSON event holding the error message,
+// its type, the errors found by unwrapping it (including errors.Join trees)
+// and, if Options.ErrorStack is enabled, the caller goroutine stack.
+// In buffered mode the event goes to the high priority lane.
+// A nil err is ignored.
+func (l *Log) PutError(err error) error {
+	if err == nil {
+		return nil
+	}
+	event := ErrorEvent{
+		Error: err.Error(),
+		Type:  fmt.Sprintf("%T", err),
+		Chain: unwrapChain(err, nil),
+	}
+	if l.options.ErrorStack {
+		event.Stack = stack()
+	}
+	return l.putJSON(event, true)
+}
+
+// unwrapChain walks the error tree depth-first, skipping the root.
+func unwrapChain(err error, chain []ErrorCause) []ErrorCause {
+	var children []error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			children = []error{inner}
+		}
+	case interface{ Unwrap() []error }:
+		children = e.Unwrap()
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		chain = append(chain, ErrorCause{
+			Error: child.Error(),
+			Type:  fmt.Sprintf("%T", child),
+		})
+		chain = unwrapChain(child, chain)
+	}
+	return chain
+}
+
+// stack returns the current goroutine stack.
+func stack() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// PutJSON sends v encoded as JSON, like a struct or map, as one event.
+// Global fields and, for children created by With, the bound fields are
+// appended to JSON objects.
+func (l *Log) PutJSON(v any) error {
+	return l.putJSON(v, false)
+}
+
+// putJSON sends v encoded as JSON.
+// Global fields and, for children created by With, the bound fields are
+// appended to the object.
+// priority selects the high priority lane in buffered mode.
+func (l *Log) putJSON(v any, priority bool) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json encode error: %w", err)
+	}
+	if fields := l.structuredFields(nil); len(fields) > 0 {
+		data, err = appendFields(data, fields)
+		if err != nil {
+			return err
+		}
+	}
+	return l.putMessage(string(data), priority)
+}
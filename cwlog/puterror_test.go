@@ -0,0 +1,63 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestPutError(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		ErrorStack: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inner := &fs.PathError{Op: "open", Path: "/tmp/x", Err: fs.ErrNotExist}
+	wrapped := fmt.Errorf("load config: %w", errors.Join(inner, errors.New("other")))
+
+	if err := cw.PutError(wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutError(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 1 {
+		t.Fatalf("log lines: expected=1 found=%d", len(s))
+	}
+
+	var event ErrorEvent
+	if err := json.Unmarshal([]byte(aws.ToString(s[0].Message)), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Error != wrapped.Error() {
+		t.Errorf("error: expected=%q got=%q", wrapped.Error(), event.Error)
+	}
+	if event.Type != "*fmt.wrapError" {
+		t.Errorf("type: expected=%q got=%q", "*fmt.wrapError", event.Type)
+	}
+	// join, path error, ErrNotExist, other
+	if len(event.Chain) != 4 {
+		t.Fatalf("chain: expected=4 got=%d: %v", len(event.Chain), event.Chain)
+	}
+	if event.Chain[1].Type != "*fs.PathError" {
+		t.Errorf("chain type: expected=%q got=%q", "*fs.PathError", event.Chain[1].Type)
+	}
+	if !strings.Contains(event.Stack, "TestPutError") {
+		t.Errorf("stack missing caller: %s", event.Stack)
+	}
+}
@@ -0,0 +1,155 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// QueryClient is implemented by clients supporting CloudWatch Logs Insights,
+// like the AWS SDK client. It is optional for Options.Client, required
+// only by Query.
+type QueryClient interface {
+	StartQuery(ctx context.Context,
+		params *cloudwatchlogs.StartQueryInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error)
+	GetQueryResults(ctx context.Context,
+		params *cloudwatchlogs.GetQueryResultsInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error)
+	StopQuery(ctx context.Context,
+		params *cloudwatchlogs.StopQueryInput,
+		optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error)
+}
+
+// QueryOptions define an Insights query.
+type QueryOptions struct {
+	// LogGroups is required by RunQuery. Log.Query defaults it to the Log group.
+	LogGroups []string
+
+	// Query is required, for example "fields @timestamp, @message | limit 20".
+	Query string
+
+	// Start and End delimit the queried time range.
+	// End defaults to now, Start to one hour before End.
+	Start time.Time
+	End   time.Time
+
+	// Limit optionally caps the number of rows.
+	Limit int32
+
+	// PollInterval is the delay between result checks. Defaults to 1s.
+	PollInterval time.Duration
+}
+
+// QueryResult holds the rows of a completed query. Fields lists the
+// columns in order of first appearance, hidden fields like @ptr excluded.
+// Missing values are empty.
+type QueryResult struct {
+	Fields []string
+	Rows   [][]string
+}
+
+// Query runs an Insights query against the Log group, waiting for results.
+// The client must implement QueryClient.
+func (l *Log) Query(ctx context.Context, options QueryOptions) (QueryResult, error) {
+	client, ok := l.options.Client.(QueryClient)
+	if !ok {
+		return QueryResult{}, fmt.Errorf("query error: client does not support Insights queries")
+	}
+	if len(options.LogGroups) == 0 {
+		options.LogGroups = []string{l.options.LogGroup}
+	}
+	return RunQuery(ctx, client, options)
+}
+
+// RunQuery starts an Insights query and waits for its results.
+// If ctx is canceled, the query is stopped.
+func RunQuery(ctx context.Context, client QueryClient, options QueryOptions) (QueryResult, error) {
+	if options.End.IsZero() {
+		options.End = time.Now()
+	}
+	if options.Start.IsZero() {
+		options.Start = options.End.Add(-time.Hour)
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+
+	input := &cloudwatchlogs.StartQueryInput{
+		LogGroupNames: options.LogGroups,
+		QueryString:   aws.String(options.Query),
+		StartTime:     aws.Int64(options.Start.Unix()),
+		EndTime:       aws.Int64(options.End.Unix()),
+	}
+	if options.Limit > 0 {
+		input.Limit = aws.Int32(options.Limit)
+	}
+	start, errStart := client.StartQuery(ctx, input)
+	if errStart != nil {
+		return QueryResult{}, fmt.Errorf("start query error: groups=%v: %w",
+			options.LogGroups, errStart)
+	}
+
+	results, err := waitQuery(ctx, client, start.QueryId, options.PollInterval)
+	if err != nil {
+		if ctx.Err() != nil {
+			client.StopQuery(context.WithoutCancel(ctx),
+				&cloudwatchlogs.StopQueryInput{QueryId: start.QueryId})
+		}
+		return QueryResult{}, err
+	}
+	return newQueryResult(results), nil
+}
+
+func waitQuery(ctx context.Context, client QueryClient, queryID *string,
+	interval time.Duration) ([][]types.ResultField, error) {
+	for {
+		out, err := client.GetQueryResults(ctx,
+			&cloudwatchlogs.GetQueryResultsInput{QueryId: queryID})
+		if err != nil {
+			return nil, fmt.Errorf("get query results error: id=%s: %w",
+				aws.ToString(queryID), err)
+		}
+		switch out.Status {
+		case types.QueryStatusComplete:
+			return out.Results, nil
+		case types.QueryStatusScheduled, types.QueryStatusRunning:
+		default:
+			return nil, fmt.Errorf("query error: id=%s status=%s",
+				aws.ToString(queryID), out.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func newQueryResult(results [][]types.ResultField) QueryResult {
+	var r QueryResult
+	column := map[string]int{}
+	for _, row := range results {
+		for _, f := range row {
+			name := aws.ToString(f.Field)
+			if _, found := column[name]; !found && name != "@ptr" {
+				column[name] = len(r.Fields)
+				r.Fields = append(r.Fields, name)
+			}
+		}
+	}
+	for _, row := range results {
+		values := make([]string, len(r.Fields))
+		for _, f := range row {
+			if i, found := column[aws.ToString(f.Field)]; found {
+				values[i] = aws.ToString(f.Value)
+			}
+		}
+		r.Rows = append(r.Rows, values)
+	}
+	return r
+}
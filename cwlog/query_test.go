@@ -0,0 +1,116 @@
+package cwlog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// queryMock completes queries after a number of polls.
+type queryMock struct {
+	*cloudWatchLogMock
+	pollsLeft int
+	status    types.QueryStatus
+	results   [][]types.ResultField
+	started   *cloudwatchlogs.StartQueryInput
+	stopped   bool
+}
+
+func (m *queryMock) StartQuery(_ context.Context,
+	params *cloudwatchlogs.StartQueryInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	m.started = params
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("q1")}, nil
+}
+
+func (m *queryMock) GetQueryResults(_ context.Context,
+	_ *cloudwatchlogs.GetQueryResultsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	if m.pollsLeft > 0 {
+		m.pollsLeft--
+		return &cloudwatchlogs.GetQueryResultsOutput{Status: types.QueryStatusRunning}, nil
+	}
+	return &cloudwatchlogs.GetQueryResultsOutput{Status: m.status, Results: m.results}, nil
+}
+
+func (m *queryMock) StopQuery(_ context.Context,
+	_ *cloudwatchlogs.StopQueryInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	m.stopped = true
+	return &cloudwatchlogs.StopQueryOutput{}, nil
+}
+
+func resultField(name, value string) types.ResultField {
+	return types.ResultField{Field: aws.String(name), Value: aws.String(value)}
+}
+
+func TestQuery(t *testing.T) {
+	client := &queryMock{
+		cloudWatchLogMock: newCloudWatchLogMock(),
+		pollsLeft:         2,
+		status:            types.QueryStatusComplete,
+		results: [][]types.ResultField{
+			{resultField("@timestamp", "t1"), resultField("@message", "m1"), resultField("@ptr", "x")},
+			{resultField("@timestamp", "t2"), resultField("level", "ERROR")},
+		},
+	}
+	cw, err := New(Options{Client: client, LogGroup: "/app/main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := time.Unix(7200, 0)
+	result, errQuery := cw.Query(context.Background(), QueryOptions{
+		Query:        "fields @timestamp, @message",
+		End:          end,
+		PollInterval: time.Millisecond,
+	})
+	if errQuery != nil {
+		t.Fatal(errQuery)
+	}
+
+	if !reflect.DeepEqual(client.started.LogGroupNames, []string{"/app/main"}) {
+		t.Errorf("groups: %v", client.started.LogGroupNames)
+	}
+	if *client.started.StartTime != 3600 || *client.started.EndTime != 7200 {
+		t.Errorf("range: %d-%d", *client.started.StartTime, *client.started.EndTime)
+	}
+	expected := QueryResult{
+		Fields: []string{"@timestamp", "@message", "level"},
+		Rows:   [][]string{{"t1", "m1", ""}, {"t2", "", "ERROR"}},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected=%v got=%v", expected, result)
+	}
+}
+
+func TestQueryFailures(t *testing.T) {
+	client := &queryMock{cloudWatchLogMock: newCloudWatchLogMock(), status: types.QueryStatusFailed}
+	cw, err := New(Options{Client: client, LogGroup: "/app/main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cw.Query(context.Background(), QueryOptions{Query: "x"}); err == nil {
+		t.Error("expected error for failed query")
+	}
+
+	client.pollsLeft = 1000
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cw.Query(ctx, QueryOptions{Query: "x", PollInterval: time.Millisecond}); err == nil {
+		t.Error("expected error for canceled query")
+	}
+	if !client.stopped {
+		t.Error("canceled query not stopped")
+	}
+
+	plain, _ := New(Options{Client: nullClient{}, LogGroup: "/app/main"})
+	if _, err := plain.Query(context.Background(), QueryOptions{Query: "x"}); err == nil {
+		t.Error("expected error for client without Insights support")
+	}
+}
@@ -0,0 +1,69 @@
+package cwlog
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicEvent is the structured event sent by Recover.
+type PanicEvent struct {
+	Panic string `json:"panic"`
+	Type  string `json:"type"`
+	Stack string `json:"stack"`
+}
+
+// Recover, when deferred, recovers a panic and sends the panic value and
+// stack trace as a structured JSON event, then flushes l synchronously.
+// The panic is swallowed. See RecoverRepanic.
+//
+//	defer cwlog.Recover(l)
+func Recover(l *Log) {
+	if v := recover(); v != nil {
+		l.putPanic(v)
+	}
+}
+
+// RecoverRepanic is like Recover, but panics again with the same value
+// after the event is flushed.
+//
+//	defer cwlog.RecoverRepanic(l)
+func RecoverRepanic(l *Log) {
+	if v := recover(); v != nil {
+		l.putPanic(v)
+		panic(v)
+	}
+}
+
+// RecoverHandler wraps next, recovering panics like Recover and answering
+// them with status 500. http.ErrAbortHandler is passed through unlogged.
+func RecoverHandler(l *Log, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			l.putPanic(v)
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *Log) putPanic(v any) {
+	event := PanicEvent{
+		Panic: fmt.Sprint(v),
+		Type:  fmt.Sprintf("%T", v),
+		Stack: string(debug.Stack()),
+	}
+	if err := l.putJSON(event, true); err != nil {
+		l.warn("panic event send failed", "group", l.options.LogGroup, "error", err)
+	}
+	if err := l.Flush(); err != nil {
+		l.warn("panic event flush failed", "group", l.options.LogGroup, "error", err)
+	}
+}
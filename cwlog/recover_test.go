@@ -0,0 +1,77 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodePanic(t *testing.T, msg string) PanicEvent {
+	t.Helper()
+	var event PanicEvent
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		t.Fatalf("bad json: %v: %s", err, msg)
+	}
+	return event
+}
+
+func TestRecover(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+
+	func() {
+		defer Recover(cw)
+		panic("boom")
+	}()
+
+	// flushed synchronously, even in buffered mode
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 {
+		t.Fatalf("log lines: expected=1 found=%d", len(msgs))
+	}
+	event := decodePanic(t, msgs[0])
+	if event.Panic != "boom" || event.Type != "string" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if !strings.Contains(event.Stack, "TestRecover") {
+		t.Errorf("missing stack: %s", event.Stack)
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("expected repanic, got: %v", v)
+		}
+		if s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]; len(s) != 1 {
+			t.Errorf("log lines: expected=1 found=%d", len(s))
+		}
+	}()
+
+	defer RecoverRepanic(cw)
+	panic("boom")
+}
+
+func TestRecoverHandler(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+
+	h := RecoverHandler(cw, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("handler boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status: expected=500 got=%d", w.Code)
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 || decodePanic(t, msgs[0]).Panic != "handler boom" {
+		t.Errorf("unexpected events: %v", msgs)
+	}
+}
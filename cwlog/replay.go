@@ -0,0 +1,81 @@
+package cwlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReplayReport counts the lines handled by Replay.
+type ReplayReport struct {
+	// Replayed counts events sent again.
+	Replayed int
+
+	// Expired counts events skipped for being older than MaxEventAge
+	// or the group retention, which CloudWatch Logs would reject.
+	Expired int
+
+	// OtherGroup counts events of a group other than the Log group.
+	OtherGroup int
+
+	// Skipped counts lines not written by FallbackWriter.
+	Skipped int
+}
+
+// Replay re-ingests events of the Log group written to
+// Options.FallbackWriter after failed deliveries, as read from r,
+// preserving their original timestamps. Events are sent as written,
+// since they were already transformed by Pipeline, SigningKey and the
+// like, to the current Log stream. Events outside the retention window
+// are skipped, and counted in the report, as are events of other groups
+// and lines not written by FallbackWriter.
+func (l *Log) Replay(r io.Reader) (ReplayReport, error) {
+	var report ReplayReport
+	cutoff := l.expiredCutoff(l.options.Now())
+	s := lineSender{log: l, prepared: true}
+	br := bufio.NewReader(r)
+	for {
+		line, errRead := br.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			e, found := ParseFallback(line)
+			switch {
+			case !found:
+				report.Skipped++
+			case e.Group != l.options.LogGroup:
+				report.OtherGroup++
+			case e.Timestamp < cutoff:
+				report.Expired++
+			case e.Message == "":
+				report.Skipped++ // rejected by AWS
+			default:
+				if err := s.addAt(e.Message, e.Timestamp); err != nil {
+					return report, err
+				}
+				report.Replayed++
+			}
+		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return report, errRead
+		}
+	}
+	return report, s.flush()
+}
+
+// ReplayFile is like Replay, reading the file at path.
+func (l *Log) ReplayFile(path string) (ReplayReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ReplayReport{}, fmt.Errorf("replay file error: %w", err)
+	}
+	defer f.Close()
+	report, err := l.Replay(f)
+	if err != nil {
+		return report, fmt.Errorf("replay file error: %s: %w", path, err)
+	}
+	return report, nil
+}
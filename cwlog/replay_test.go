@@ -0,0 +1,63 @@
+package cwlog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestReplay(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var fallback bytes.Buffer
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:            client,
+		Now:               func() time.Time { return now },
+		LogGroup:          "/cloudwatchlogs/group",
+		LogStream:         "/cloudwatchlogs/stream",
+		LogStreamTemplate: "{{.LogStream}}",
+		Pipeline:          Pipeline{Enrich(Field{Key: "app", Value: "api"})},
+		FallbackWriter:    &fallback,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.denyPutLog = true
+	events := []types.InputLogEvent{
+		newEvent("hour ago", now.Add(-time.Hour).UnixMilli()),
+		newEvent("too old", now.Add(-20*24*time.Hour).UnixMilli()),
+		newEvent("minute ago", now.Add(-time.Minute).UnixMilli()),
+	}
+	if err := cw.PutLogEvents(events); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	client.denyPutLog = false
+	fallback.WriteString("unrelated line\n")
+	fallback.WriteString(FallbackPrefix + `{"group":"/other","timestamp":0,"message":"x","error":"e"}` + "\n")
+
+	report, err := cw.Replay(&fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReport := ReplayReport{Replayed: 2, Expired: 1, OtherGroup: 1, Skipped: 1}
+	if report != expectedReport {
+		t.Errorf("report: expected=%+v got=%+v", expectedReport, report)
+	}
+
+	sent := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream"]
+	expected := []string{"app=api hour ago", "app=api minute ago"}
+	if fmt.Sprint(messages(sent)) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%q got=%q", expected, messages(sent))
+	}
+	if ts := aws.ToInt64(sent[0].Timestamp); ts != now.Add(-time.Hour).UnixMilli() {
+		t.Errorf("timestamp not preserved: %d", ts)
+	}
+	if client.puts != 1 {
+		t.Errorf("puts: expected=1 got=%d", client.puts)
+	}
+}
@@ -0,0 +1,112 @@
+package cwlog
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RequestEvent accumulates fields over the course of a request, like
+// timings, user and decisions, and sends them as one wide structured
+// event on completion: the canonical log line pattern. It is safe for
+// concurrent use, and methods on a nil RequestEvent do nothing, so code
+// can use RequestEventFrom without checking for a middleware.
+type RequestEvent struct {
+	log *Log
+	msg string
+
+	mu      sync.Mutex
+	fields  []Field
+	level   Level
+	emitted bool
+}
+
+// NewRequestEvent starts a wide event with message msg, at LevelInfo.
+func (l *Log) NewRequestEvent(msg string) *RequestEvent {
+	return &RequestEvent{log: l, msg: msg, level: LevelInfo}
+}
+
+// Set adds a field, replacing the value of a field with the same key.
+func (e *RequestEvent) Set(key string, value any) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if i := slices.IndexFunc(e.fields, func(f Field) bool { return f.Key == key }); i >= 0 {
+		e.fields[i].Value = value
+		return
+	}
+	e.fields = append(e.fields, Field{Key: key, Value: value})
+}
+
+// Time starts timing a step, returning the function that stops it,
+// setting key+"_ms" to the elapsed milliseconds, like:
+//
+//	defer e.Time("db")()
+func (e *RequestEvent) Time(key string) func() {
+	begin := time.Now()
+	return func() {
+		e.Set(key+"_ms", time.Since(begin).Milliseconds())
+	}
+}
+
+// Escalate raises the event level to level, if higher, for example
+// when the request hits an error worth noticing.
+func (e *RequestEvent) Escalate(level Level) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.level = max(e.level, level)
+}
+
+// Level returns the event level.
+func (e *RequestEvent) Level() Level {
+	if e == nil {
+		return LevelInfo
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.level
+}
+
+// Emit sends the event once, with keyvals first, then the accumulated
+// fields not overridden by keyvals. Later calls, and later changes to
+// the event, are ignored.
+// keyvals are alternating keys and values added as fields.
+func (e *RequestEvent) Emit(keyvals ...any) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	if e.emitted {
+		e.mu.Unlock()
+		return nil
+	}
+	e.emitted = true
+	fields := pairs(keyvals)
+	for _, f := range e.fields {
+		if !hasField(fields, f.Key) {
+			fields = append(fields, f)
+		}
+	}
+	level := e.level
+	e.mu.Unlock()
+	return e.log.putLevel(level, e.msg, fields)
+}
+
+type requestEventKey struct{}
+
+// ContextWithRequestEvent returns a copy of ctx carrying e.
+func ContextWithRequestEvent(ctx context.Context, e *RequestEvent) context.Context {
+	return context.WithValue(ctx, requestEventKey{}, e)
+}
+
+// RequestEventFrom returns the RequestEvent carried by ctx, or nil.
+func RequestEventFrom(ctx context.Context) *RequestEvent {
+	e, _ := ctx.Value(requestEventKey{}).(*RequestEvent)
+	return e
+}
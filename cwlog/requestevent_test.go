@@ -0,0 +1,50 @@
+package cwlog
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRequestEvent(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := cw.NewRequestEvent("checkout")
+	ctx := ContextWithRequestEvent(context.Background(), ev)
+	RequestEventFrom(ctx).Set("user", "alice")
+	RequestEventFrom(ctx).Set("cart", 2)
+	RequestEventFrom(ctx).Set("cart", 3)
+	RequestEventFrom(ctx).Set("route", "ignored")
+	RequestEventFrom(ctx).Escalate(LevelWarn)
+	RequestEventFrom(ctx).Escalate(LevelDebug)
+	if err := ev.Emit("route", "/pay"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ev.Emit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// without an event in the context, calls do nothing
+	none := RequestEventFrom(context.Background())
+	none.Set("x", 1)
+	none.Time("db")()
+	if err := none.Emit(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	expected := []string{`{"level":"WARN","msg":"checkout","route":"/pay","user":"alice","cart":3}`}
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+}
@@ -0,0 +1,69 @@
+package cwlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// requestGzipMinBytes is the smallest request body compressed by
+// CompressRequests, below which gzip overhead outweighs the savings.
+const requestGzipMinBytes = 1024
+
+// withRequestGzip compresses the request body with Content-Encoding gzip.
+// The middleware runs after serialization, so the signature covers the
+// compressed payload.
+func withRequestGzip(o *cloudwatchlogs.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Serialize.Add(requestGzip{}, middleware.After)
+	})
+}
+
+type requestGzip struct{}
+
+func (requestGzip) ID() string {
+	return "cwlogRequestGzip"
+}
+
+func (requestGzip) HandleSerialize(ctx context.Context, in middleware.SerializeInput,
+	next middleware.SerializeHandler) (middleware.SerializeOutput, middleware.Metadata, error) {
+
+	req, ok := in.Request.(*smithyhttp.Request)
+	if !ok {
+		return next.HandleSerialize(ctx, in)
+	}
+	stream := req.GetStream()
+	if stream == nil {
+		return next.HandleSerialize(ctx, in)
+	}
+	size, found, err := req.StreamLength()
+	if err != nil || !found || size < requestGzipMinBytes {
+		return next.HandleSerialize(ctx, in)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := io.Copy(gz, stream); err != nil {
+		return middleware.SerializeOutput{}, middleware.Metadata{},
+			fmt.Errorf("request gzip error: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return middleware.SerializeOutput{}, middleware.Metadata{},
+			fmt.Errorf("request gzip error: %w", err)
+	}
+
+	compressed, err := req.SetStream(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return middleware.SerializeOutput{}, middleware.Metadata{},
+			fmt.Errorf("request gzip error: %w", err)
+	}
+	compressed.Header.Set("Content-Encoding", "gzip")
+	in.Request = compressed
+	return next.HandleSerialize(ctx, in)
+}
@@ -0,0 +1,101 @@
+package cwlog
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// encodingHandler accepts every CloudWatch Logs call, recording the
+// Content-Encoding and decoded body of each operation.
+type encodingHandler struct {
+	mu        sync.Mutex
+	encodings map[string]string // operation => Content-Encoding
+	bodies    map[string]string // operation => decoded body
+}
+
+func (h *encodingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	encoding := r.Header.Get("Content-Encoding")
+	if encoding == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, op, _ := strings.Cut(r.Header.Get("X-Amz-Target"), ".")
+
+	h.mu.Lock()
+	h.encodings[op] = encoding
+	h.bodies[op] = string(data)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Write([]byte("{}"))
+}
+
+func TestCompressRequests(t *testing.T) {
+	handler := &encodingHandler{encodings: map[string]string{}, bodies: map[string]string{}}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	cw, err := New(Options{
+		AwsConfig:        awsTestConfig(srv.URL),
+		LogGroup:         "/cloudwatchlogs/gzip",
+		CompressRequests: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := strings.Repeat("compressible ", 200)
+	if err := cw.PutSimple(large); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if enc := handler.encodings["PutLogEvents"]; enc != "gzip" {
+		t.Errorf("PutLogEvents encoding: expected=gzip got=%q", enc)
+	}
+	if !strings.Contains(handler.bodies["PutLogEvents"], large) {
+		t.Errorf("decoded body missing message: %s", handler.bodies["PutLogEvents"])
+	}
+	if enc := handler.encodings["CreateLogStream"]; enc != "" {
+		t.Errorf("CreateLogStream encoding: expected none got=%q", enc)
+	}
+}
+
+func TestCompressRequestsSmall(t *testing.T) {
+	handler := &encodingHandler{encodings: map[string]string{}, bodies: map[string]string{}}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	cw, err := New(Options{
+		AwsConfig:        awsTestConfig(srv.URL),
+		LogGroup:         "/cloudwatchlogs/gzip-small",
+		CompressRequests: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("small"); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if enc := handler.encodings["PutLogEvents"]; enc != "" {
+		t.Errorf("small PutLogEvents encoding: expected none got=%q", enc)
+	}
+}
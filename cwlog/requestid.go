@@ -0,0 +1,39 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RequestID returns the AWS request ID of a failed call, or "" if err
+// does not carry it. Support tickets to AWS can reference it.
+func RequestID(err error) string {
+	var reqErr interface{ ServiceRequestID() string }
+	if errors.As(err, &reqErr) {
+		return reqErr.ServiceRequestID()
+	}
+	return ""
+}
+
+// HTTPStatus returns the HTTP status code of a failed AWS call, or 0 if
+// err does not carry it.
+func HTTPStatus(err error) int {
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode()
+	}
+	return 0
+}
+
+// callDetails formats the request ID and HTTP status of a failed AWS call
+// for error messages, like " request_id=abc http_status=400".
+func callDetails(err error) string {
+	var s string
+	if id := RequestID(err); id != "" {
+		s += " request_id=" + id
+	}
+	if status := HTTPStatus(err); status != 0 {
+		s += fmt.Sprintf(" http_status=%d", status)
+	}
+	return s
+}
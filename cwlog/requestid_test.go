@@ -0,0 +1,71 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func newResponseError(requestID string, status int) error {
+	return &smithy.OperationError{
+		ServiceID:     "CloudWatch Logs",
+		OperationName: "PutLogEvents",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      errors.New("service unavailable"),
+			},
+			RequestID: requestID,
+		},
+	}
+}
+
+// requestIDClient fails PutLogEvents with an SDK response error.
+type requestIDClient struct {
+	*cloudWatchLogMock
+}
+
+func (c *requestIDClient) PutLogEvents(_ context.Context,
+	_ *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return nil, newResponseError("req-1234", 503)
+}
+
+func TestRequestIDInErrors(t *testing.T) {
+	cw, err := New(Options{
+		Client:    &requestIDClient{cloudWatchLogMock: newCloudWatchLogMock()},
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errPut := cw.PutSimple("a")
+	if errPut == nil {
+		t.Fatal("expected put error")
+	}
+	if !strings.Contains(errPut.Error(), "request_id=req-1234 http_status=503") {
+		t.Errorf("missing request details: %v", errPut)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	err := newResponseError("req-1", 400)
+	if id := RequestID(err); id != "req-1" {
+		t.Errorf("unexpected request id: %q", id)
+	}
+	if status := HTTPStatus(err); status != 400 {
+		t.Errorf("unexpected status: %d", status)
+	}
+	plain := errors.New("plain")
+	if RequestID(plain) != "" || HTTPStatus(plain) != 0 || callDetails(plain) != "" {
+		t.Errorf("unexpected details for plain error")
+	}
+}
@@ -0,0 +1,131 @@
+package cwlog
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// PutResult reports where a batch landed and how much was ingested.
+type PutResult struct {
+	// Stream is the log stream that received the batch.
+	Stream string
+
+	// Events is the number of events ingested, excluding rejected ones.
+	Events int
+
+	// Bytes is the EventSize sum of ingested events.
+	Bytes int
+
+	// Rejected is non-nil when CloudWatch rejected part of the batch.
+	Rejected *RejectedInfo
+}
+
+// RejectedInfo reports events rejected by CloudWatch in an accepted batch.
+// Indexes refer to the events slice given to PutLogEventsResult,
+// and are -1 when not applicable.
+type RejectedInfo struct {
+	// TooOldEndIndex is the index after the last event older than the
+	// ingestion window (exclusive).
+	TooOldEndIndex int
+
+	// TooNewStartIndex is the first index of events newer than the
+	// ingestion window (inclusive).
+	TooNewStartIndex int
+
+	// ExpiredEndIndex is the last index of events older than the group
+	// retention (inclusive).
+	ExpiredEndIndex int
+}
+
+// Rejected reports whether the event at index i was rejected.
+func (r *RejectedInfo) Rejected(i int) bool {
+	if r == nil {
+		return false
+	}
+	if i < r.TooOldEndIndex || i <= r.ExpiredEndIndex {
+		return true
+	}
+	return r.TooNewStartIndex >= 0 && i >= r.TooNewStartIndex
+}
+
+func newRejectedInfo(info *types.RejectedLogEventsInfo) *RejectedInfo {
+	if info == nil {
+		return nil
+	}
+	index := func(p *int32) int {
+		if p == nil {
+			return -1
+		}
+		return int(*p)
+	}
+	return &RejectedInfo{
+		TooOldEndIndex:   index(info.TooOldLogEventEndIndex),
+		TooNewStartIndex: index(info.TooNewLogEventStartIndex),
+		ExpiredEndIndex:  index(info.ExpiredLogEventEndIndex),
+	}
+}
+
+func newPutResult(stream string, events []types.InputLogEvent,
+	out *cloudwatchlogs.PutLogEventsOutput) PutResult {
+	result := PutResult{Stream: stream}
+	if out != nil {
+		result.Rejected = newRejectedInfo(out.RejectedLogEventsInfo)
+	}
+	for i, e := range events {
+		if result.Rejected.Rejected(i) {
+			continue
+		}
+		result.Events++
+		result.Bytes += EventSize(e)
+	}
+	return result
+}
+
+// PutLogEventsResult is like PutLogEvents, but also reports where the
+// batch landed and how much was ingested.
+// In buffered mode events are only queued, hence the result reports
+// the queued events but no stream.
+func (l *Log) PutLogEventsResult(events []types.InputLogEvent) (PutResult, error) {
+	if l.prefix != "" {
+		events = prefixEvents(l.prefix, events)
+	}
+	events = l.prepare(events)
+	if len(events) == 0 {
+		return PutResult{}, nil // all dropped by Pipeline
+	}
+	if l.batcher != nil {
+		if err := l.batcher.enqueue(l, events, false); err != nil {
+			return PutResult{}, err
+		}
+		return newPutResult("", events, nil), nil
+	}
+	return l.sendLocked(l.options.Context, events)
+}
+
+// resubmit resends rejected events once. The caller must hold sendMu.
+func (l *Log) resubmit(ctx context.Context, events []types.InputLogEvent, rejected *RejectedInfo) error {
+	now := l.options.Now()
+	var retry []types.InputLogEvent
+	for i, e := range events {
+		if !rejected.Rejected(i) {
+			continue
+		}
+		if l.options.ClampTimestamps {
+			e.Timestamp = aws.Int64(now.UnixMilli())
+		} else if validateEvent(e, now) != nil {
+			continue // not eligible
+		}
+		retry = append(retry, e)
+	}
+	if len(retry) == 0 {
+		return nil
+	}
+	l.stats.resubmittedEvents.Add(int64(len(retry)))
+	l.debug("resubmitting rejected events", "group", l.options.LogGroup,
+		"events", len(retry), "clamp", l.options.ClampTimestamps)
+	_, err := l.putLogEvents(ctx, retry)
+	return err
+}
@@ -0,0 +1,94 @@
+package cwlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestPutLogEventsResult(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	events := []types.InputLogEvent{
+		newEvent("old", 0),
+		newEvent("ok 1", 0),
+		newEvent("ok 2", 0),
+		newEvent("new", 0),
+	}
+
+	result, err := cw.PutLogEventsResult(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stream != "/cloudwatchlogs/stream-0001-01-01-00" {
+		t.Errorf("stream: %s", result.Stream)
+	}
+	if result.Events != 4 || result.Rejected != nil {
+		t.Errorf("events: expected=4 got=%d rejected=%v", result.Events, result.Rejected)
+	}
+
+	client.rejected = &types.RejectedLogEventsInfo{
+		TooOldLogEventEndIndex:   aws.Int32(1),
+		TooNewLogEventStartIndex: aws.Int32(3),
+	}
+	result, err = cw.PutLogEventsResult(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Events != 2 {
+		t.Errorf("events: expected=2 got=%d", result.Events)
+	}
+	if expected := 2*EventOverhead + len("ok 1") + len("ok 2"); result.Bytes != expected {
+		t.Errorf("bytes: expected=%d got=%d", expected, result.Bytes)
+	}
+	if r := result.Rejected; r == nil || r.TooOldEndIndex != 1 ||
+		r.TooNewStartIndex != 3 || r.ExpiredEndIndex != -1 {
+		t.Errorf("rejected: %+v", r)
+	}
+}
+
+func TestResubmitRejected(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour).UnixMilli()
+
+	for _, clamp := range []bool{false, true} {
+		client := newCloudWatchLogMock()
+		cw, err := New(Options{
+			Client:           client,
+			Now:              func() time.Time { return now },
+			LogGroup:         "group",
+			LogStream:        "stream",
+			ResubmitRejected: true,
+			ClampTimestamps:  clamp,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client.rejected = &types.RejectedLogEventsInfo{
+			TooOldLogEventEndIndex: aws.Int32(1),
+		}
+		events := []types.InputLogEvent{newEvent("old", old), newEvent("ok", now.UnixMilli())}
+		if err := cw.PutLogEvents(events); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := int64(0)
+		if clamp {
+			expected = 1
+		}
+		if got := cw.Stats().ResubmittedEvents; got != expected {
+			t.Errorf("clamp=%t: resubmitted: expected=%d got=%d", clamp, expected, got)
+		}
+		if clamp {
+			s := client.groups["group"]["stream-2024-06-01-12"]
+			last := s[len(s)-1]
+			if aws.ToString(last.Message) != "old" || aws.ToInt64(last.Timestamp) != now.UnixMilli() {
+				t.Errorf("clamped event: %s %d", aws.ToString(last.Message), aws.ToInt64(last.Timestamp))
+			}
+		}
+	}
+}
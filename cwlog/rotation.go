@@ -0,0 +1,137 @@
+package cwlog
+
+import (
+	"text/template/parse"
+	"time"
+)
+
+// granularity is the finest time unit referenced by the stream template.
+type granularity int
+
+const (
+	granularityNone granularity = iota
+	granularityYear
+	granularityMonth
+	granularityDay
+	granularityHour
+)
+
+var fieldGranularity = map[string]granularity{
+	"LogGroup":  granularityNone,
+	"LogStream": granularityNone,
+	"YYYY":      granularityYear,
+	"MM":        granularityMonth,
+	"DD":        granularityDay,
+	"HH":        granularityHour,
+}
+
+// templateGranularity inspects the template parse tree to find how often
+// the rendered stream name may change. Constructs it cannot analyze are
+// conservatively assumed to change every hour.
+func templateGranularity(tree *parse.Tree) granularity {
+	if tree == nil || tree.Root == nil {
+		return granularityHour
+	}
+	return nodeGranularity(tree.Root)
+}
+
+func nodeGranularity(node parse.Node) granularity {
+	switch n := node.(type) {
+	case nil:
+		return granularityNone
+	case *parse.ListNode:
+		if n == nil {
+			return granularityNone
+		}
+		var g granularity
+		for _, child := range n.Nodes {
+			g = max(g, nodeGranularity(child))
+		}
+		return g
+	case *parse.TextNode, *parse.StringNode, *parse.NumberNode,
+		*parse.BoolNode, *parse.NilNode, *parse.IdentifierNode,
+		*parse.CommentNode:
+		return granularityNone
+	case *parse.ActionNode:
+		return nodeGranularity(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return granularityNone
+		}
+		var g granularity
+		for _, cmd := range n.Cmds {
+			g = max(g, nodeGranularity(cmd))
+		}
+		return g
+	case *parse.CommandNode:
+		var g granularity
+		for _, arg := range n.Args {
+			g = max(g, nodeGranularity(arg))
+		}
+		return g
+	case *parse.FieldNode:
+		g, found := fieldGranularity[n.Ident[0]]
+		if !found {
+			return granularityHour
+		}
+		return g
+	case *parse.IfNode:
+		return branchGranularity(&n.BranchNode)
+	case *parse.WithNode:
+		return branchGranularity(&n.BranchNode)
+	case *parse.RangeNode:
+		return branchGranularity(&n.BranchNode)
+	}
+	return granularityHour
+}
+
+func branchGranularity(n *parse.BranchNode) granularity {
+	return max(nodeGranularity(n.Pipe), nodeGranularity(n.List),
+		nodeGranularity(n.ElseList))
+}
+
+// period returns the rotation period [start, end) containing now.
+// For granularityNone the period never ends, signaled by a zero end.
+func (g granularity) period(now time.Time) (start, end time.Time) {
+	year, month, day := now.Date()
+	loc := now.Location()
+	switch g {
+	case granularityYear:
+		start = time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		end = time.Date(year+1, 1, 1, 0, 0, 0, 0, loc)
+	case granularityMonth:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		end = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+	case granularityDay:
+		start = time.Date(year, month, day, 0, 0, 0, 0, loc)
+		end = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	case granularityHour:
+		start = time.Date(year, month, day, now.Hour(), 0, 0, 0, loc)
+		end = time.Date(year, month, day, now.Hour()+1, 0, 0, 0, loc)
+	}
+	return
+}
+
+// streamCache holds the rendered stream name for the current rotation period.
+type streamCache struct {
+	name  string
+	start time.Time
+	end   time.Time
+	valid bool
+}
+
+func (c *streamCache) get(now time.Time) (string, bool) {
+	if !c.valid || now.Before(c.start) {
+		return "", false
+	}
+	if !c.end.IsZero() && !now.Before(c.end) {
+		return "", false
+	}
+	return c.name, true
+}
+
+func (c *streamCache) put(name string, g granularity, now time.Time) {
+	c.name = name
+	c.start, c.end = g.period(now)
+	c.valid = true
+}
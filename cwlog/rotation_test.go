@@ -0,0 +1,136 @@
+package cwlog
+
+import (
+	"fmt"
+	"html/template"
+	"testing"
+	"time"
+)
+
+type granularityTest struct {
+	name           string
+	streamTemplate string
+	expected       granularity
+}
+
+var granularityTestTable = []granularityTest{
+	{"default", defaultStreamTemplate, granularityHour},
+	{"daily", "{{.LogStream}}-{{.YYYY}}-{{.MM}}-{{.DD}}", granularityDay},
+	{"monthly", "{{.LogStream}}-{{.YYYY}}-{{.MM}}", granularityMonth},
+	{"yearly", "{{.LogStream}}-{{.YYYY}}", granularityYear},
+	{"static", "{{.LogGroup}}-{{.LogStream}}", granularityNone},
+	{"conditional", "{{if .LogStream}}{{.DD}}{{end}}", granularityDay},
+	{"dot", "{{.}}", granularityHour},
+}
+
+func TestTemplateGranularity(t *testing.T) {
+	for i, data := range granularityTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(granularityTestTable), data.name)
+
+		tmpl, errTemplate := template.New("logStream").Parse(data.streamTemplate)
+		if errTemplate != nil {
+			t.Fatalf("%s: template error: %v", name, errTemplate)
+		}
+
+		if g := templateGranularity(tmpl.Tree); g != data.expected {
+			t.Errorf("%s: granularity: expected=%d got=%d", name, data.expected, g)
+		}
+	}
+}
+
+func TestStreamCacheRotation(t *testing.T) {
+	now := time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC)
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:            client,
+		Now:               func() time.Time { return now },
+		LogGroup:          "group",
+		LogStream:         "stream",
+		LogStreamTemplate: "{{.LogStream}}-{{.YYYY}}-{{.MM}}-{{.DD}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		now      time.Time
+		expected string
+	}{
+		{now, "stream-2024-12-31"},
+		{now.Add(30 * time.Second), "stream-2024-12-31"},
+		{now.Add(time.Minute), "stream-2025-01-01"},
+		{now.Add(-24 * time.Hour), "stream-2024-12-30"},
+	}
+
+	for i, step := range steps {
+		now = step.now
+		stream, errStream := cw.generateStreamName()
+		if errStream != nil {
+			t.Fatal(errStream)
+		}
+		if stream != step.expected {
+			t.Errorf("step %d: stream: expected=%s got=%s", i+1, step.expected, stream)
+		}
+	}
+}
+
+func BenchmarkGenStream(b *testing.B) {
+	tmpl := template.Must(template.New("logStream").Parse(defaultStreamTemplate))
+	now := time.Now()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := genStream(tmpl, "group", "stream", now); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateStreamNameCached(b *testing.B) {
+	cw, err := New(Options{
+		Client:   newCloudWatchLogMock(),
+		LogGroup: "group",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cw.generateStreamName(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStreamLocation(t *testing.T) {
+	now := time.Date(2024, 6, 1, 1, 30, 0, 0, time.UTC)
+	saoPaulo := time.FixedZone("BRT", -3*3600)
+
+	table := []struct {
+		name     string
+		location *time.Location
+		expected string
+	}{
+		{"default utc", nil, "stream-2024-06-01-01"},
+		{"fixed zone", saoPaulo, "stream-2024-05-31-22"},
+	}
+
+	for _, data := range table {
+		cw, err := New(Options{
+			Client:    newCloudWatchLogMock(),
+			Now:       func() time.Time { return now.In(saoPaulo) },
+			LogGroup:  "group",
+			LogStream: "stream",
+			Location:  data.location,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, errStream := cw.generateStreamName()
+		if errStream != nil {
+			t.Fatal(errStream)
+		}
+		if stream != data.expected {
+			t.Errorf("%s: expected=%s got=%s", data.name, data.expected, stream)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Route sends leveled events to an additional destination.
+type Route struct {
+	// MinLevel selects events at or above this level.
+	MinLevel Level
+
+	// LogGroup is required.
+	LogGroup string
+
+	// LogStream defaults to the LogStream of the main destination.
+	LogStream string
+}
+
+type route struct {
+	minLevel Level
+	log      *Log
+}
+
+// newRoutes creates one Log per route, sharing the main options,
+// the main buffer and the in-flight limit, if any.
+func newRoutes(main *Log) ([]route, error) {
+	options := main.options
+	var routes []route
+	for _, r := range options.LevelRouting {
+		if r.LogGroup == "" {
+			closeRoutes(routes)
+			return nil, errors.New("route LogGroup is required")
+		}
+		routeOptions := options
+		routeOptions.LevelRouting = nil
+		routeOptions.Async = false             // uses the main buffer
+		routeOptions.MaxInflight = 0           // uses the main limit
+		routeOptions.SelfMetricsNamespace = "" // reported by the main log
+		routeOptions.Heartbeat = 0             // sent by the main log
+		routeOptions.TeeWriter = nil           // written by the main log
+		routeOptions.Multiline = nil           // leveled events only
+		routeOptions.LogGroup = r.LogGroup
+		if r.LogStream != "" {
+			routeOptions.LogStream = r.LogStream
+		}
+		l, err := New(routeOptions)
+		if err != nil {
+			closeRoutes(routes)
+			return nil, fmt.Errorf("route error: group=%s: %w", r.LogGroup, err)
+		}
+		l.batcher = main.batcher
+		l.inflight = main.inflight
+		l.errs = main.errs
+		l.stats = main.stats
+		l.ownsErrors = false
+		routes = append(routes, route{minLevel: r.MinLevel, log: l})
+	}
+	return routes, nil
+}
+
+func closeRoutes(routes []route) {
+	for _, r := range routes {
+		r.log.Close()
+	}
+}
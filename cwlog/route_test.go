@@ -0,0 +1,109 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLevelRouting(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/app/all",
+		LogStream: "stream",
+		LevelRouting: []Route{
+			{MinLevel: LevelError, LogGroup: "/app/errors"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Info("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Error("failed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	all := client.groups["/app/all"]["stream-0001-01-01-00"]
+	if len(all) != 2 {
+		t.Fatalf("all: expected=2 found=%d", len(all))
+	}
+	errs := client.groups["/app/errors"]["stream-0001-01-01-00"]
+	if len(errs) != 1 {
+		t.Fatalf("errors: expected=1 found=%d", len(errs))
+	}
+}
+
+func TestLevelRoutingMissingGroup(t *testing.T) {
+	_, err := New(Options{
+		Client:       newCloudWatchLogMock(),
+		LogGroup:     "/app/all",
+		LevelRouting: []Route{{MinLevel: LevelError}},
+	})
+	if err == nil {
+		t.Fatal("expected error for route without LogGroup")
+	}
+}
+
+func TestLevelRoutingAsyncWorkers(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:       client,
+		Now:          func() time.Time { return time.Time{} },
+		LogGroup:     "/app/all",
+		LogStream:    "stream",
+		Async:        true,
+		FlushWorkers: 3,
+		LevelRouting: []Route{
+			{MinLevel: LevelWarn, LogGroup: "/app/warn"},
+			{MinLevel: LevelError, LogGroup: "/app/errors"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, errClone := cw.Clone(Options{LogStream: "clone"})
+	if errClone != nil {
+		t.Fatal(errClone)
+	}
+
+	for i := range 100 {
+		if err := cw.Error("failed", "i", i); err != nil {
+			t.Fatal(err)
+		}
+		if err := clone.PutSimple("from clone"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := clone.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dest := range [][2]string{
+		{"/app/all", "stream-0001-01-01-00"},
+		{"/app/warn", "stream-0001-01-01-00"},
+		{"/app/errors", "stream-0001-01-01-00"},
+		{"/app/all", "clone-0001-01-01-00"},
+	} {
+		s := client.groups[dest[0]][dest[1]]
+		if len(s) != 100 {
+			t.Fatalf("%s %s: expected=100 found=%d", dest[0], dest[1], len(s))
+		}
+	}
+
+	// per-stream order preserved
+	for i, msg := range messages(client.groups["/app/errors"]["stream-0001-01-01-00"]) {
+		if expected := fmt.Sprintf(`{"level":"ERROR","msg":"failed","i":%d}`, i); msg != expected {
+			t.Fatalf("order: expected=%s got=%s", expected, msg)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cwlog
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// isStrippedControl reports control characters removed by StripControl.
+// Tab and newline are kept since they are meaningful in log messages.
+func isStrippedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n'
+}
+
+func hasStrippedControl(s string) bool {
+	return strings.IndexFunc(s, isStrippedControl) >= 0
+}
+
+// sanitizeMessage replaces invalid UTF-8 sequences with U+FFFD and
+// optionally removes control characters.
+// It reports whether the message was changed.
+func sanitizeMessage(s string, stripControl bool) (string, bool) {
+	valid := utf8.ValidString(s)
+	strip := stripControl && hasStrippedControl(s)
+	if valid && !strip {
+		return s, false
+	}
+	if !valid {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	if strip {
+		s = strings.Map(func(r rune) rune {
+			if isStrippedControl(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	return s, true
+}
+
+// sanitize returns events with sanitized messages.
+// The caller slice is copied only when some message needs changes.
+func (l *Log) sanitize(events []types.InputLogEvent) []types.InputLogEvent {
+	var result []types.InputLogEvent
+	for i, e := range events {
+		msg, changed := sanitizeMessage(aws.ToString(e.Message), l.options.StripControl)
+		if !changed {
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, len(events))
+			copy(result, events)
+		}
+		result[i].Message = aws.String(msg)
+		l.stats.sanitizedEvents.Add(1)
+	}
+	if result == nil {
+		return events
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+type sanitizeTest struct {
+	name         string
+	input        string
+	stripControl bool
+	expected     string
+	changed      bool
+}
+
+var sanitizeTestTable = []sanitizeTest{
+	{"valid", "hello", false, "hello", false},
+	{"invalid utf8", "bad \xff end", false, "bad � end", true},
+	{"control kept", "a\x00b", false, "a\x00b", false},
+	{"control stripped", "a\x00b\tc\n", true, "ab\tc\n", true},
+	{"both", "a\x07\xffb", true, "a�b", true},
+}
+
+func TestSanitizeMessage(t *testing.T) {
+	for i, data := range sanitizeTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(sanitizeTestTable), data.name)
+		result, changed := sanitizeMessage(data.input, data.stripControl)
+		if result != data.expected {
+			t.Errorf("%s: expected=%q got=%q", name, data.expected, result)
+		}
+		if changed != data.changed {
+			t.Errorf("%s: changed: expected=%t got=%t", name, data.changed, changed)
+		}
+	}
+}
+
+func TestSanitizeCounter(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:       client,
+		Now:          func() time.Time { return time.Time{} },
+		LogGroup:     "/cloudwatchlogs/group",
+		LogStream:    "/cloudwatchlogs/stream",
+		SanitizeUTF8: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("good"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("bad \xff"); err != nil {
+		t.Fatal(err)
+	}
+	if sanitized := cw.Stats().SanitizedEvents; sanitized != 1 {
+		t.Fatalf("sanitized: expected=1 got=%d", sanitized)
+	}
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if msg := aws.ToString(s[1].Message); msg != "bad �" {
+		t.Fatalf("message: expected=%q got=%q", "bad �", msg)
+	}
+}
@@ -0,0 +1,121 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// selfMetrics periodically publishes the Log counters as EMF documents.
+type selfMetrics struct {
+	log      *Log
+	last     Stats
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func startSelfMetrics(l *Log) *selfMetrics {
+	m := &selfMetrics{
+		log:  l,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	ticker := l.options.Clock.NewTicker(l.options.SelfMetricsInterval)
+	go m.run(ticker)
+	return m
+}
+
+func (m *selfMetrics) run(ticker Ticker) {
+	defer close(m.done)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			m.publish()
+		case <-m.quit:
+			m.publish() // report the last interval
+			return
+		}
+	}
+}
+
+// stop publishes the final counters and waits for the publisher to exit.
+func (m *selfMetrics) stop() {
+	m.stopOnce.Do(func() { close(m.quit) })
+	<-m.done
+}
+
+// emfMetric is a metric definition in the Embedded Metric Format.
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// selfMetricsDocument is the EMF document carrying the counter deltas
+// and the buffer gauges.
+type selfMetricsDocument struct {
+	AWS            emfMetadata `json:"_aws"`
+	LogGroup       string      `json:"LogGroup"`
+	DroppedEvents  int64       `json:"DroppedEvents"`
+	FailedEvents   int64       `json:"FailedEvents"`
+	FailedBatches  int64       `json:"FailedBatches"`
+	BufferedEvents int         `json:"BufferedEvents"`
+	BufferedBytes  int         `json:"BufferedBytes"`
+}
+
+// publish sends the counter deltas since the previous publication
+// and the current buffer occupancy.
+// A failed publication is itself counted by FailedEvents.
+func (m *selfMetrics) publish() {
+	l := m.log
+	stats := l.Stats()
+	doc := selfMetricsDocument{
+		AWS: emfMetadata{
+			Timestamp: l.options.Now().UnixMilli(),
+			CloudWatchMetrics: []emfDirective{{
+				Namespace:  l.options.SelfMetricsNamespace,
+				Dimensions: [][]string{{"LogGroup"}},
+				Metrics: []emfMetric{
+					{Name: "DroppedEvents", Unit: "Count"},
+					{Name: "FailedEvents", Unit: "Count"},
+					{Name: "FailedBatches", Unit: "Count"},
+					{Name: "BufferedEvents", Unit: "Count"},
+					{Name: "BufferedBytes", Unit: "Bytes"},
+				},
+			}},
+		},
+		LogGroup:       l.options.LogGroup,
+		DroppedEvents:  stats.DroppedEvents - m.last.DroppedEvents,
+		FailedEvents:   stats.FailedEvents - m.last.FailedEvents,
+		FailedBatches:  stats.FailedBatches - m.last.FailedBatches,
+		BufferedEvents: stats.BufferedEvents,
+		BufferedBytes:  stats.BufferedBytes,
+	}
+	m.last = stats
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		l.warn("self metrics encode failed", "error", err)
+		return
+	}
+	// skip message transforms, like Pipeline or SigningKey, which would
+	// break the document
+	event := newEvent(string(data), doc.AWS.Timestamp)
+	if err := l.putPrepared([]types.InputLogEvent{event}, false); err != nil {
+		l.warn("self metrics publish failed", "group", l.options.LogGroup,
+			"error", err)
+	}
+}
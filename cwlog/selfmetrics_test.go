@@ -0,0 +1,156 @@
+package cwlog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSelfMetrics(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:               client,
+		Now:                  func() time.Time { return time.Time{} },
+		LogGroup:             "/cloudwatchlogs/group",
+		LogStream:            "/cloudwatchlogs/stream",
+		SelfMetricsNamespace: "MyApp",
+		SelfMetricsInterval:  time.Hour, // only the final publication on Close
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = true
+	client.mu.Unlock()
+
+	if err := cw.PutSimple("lost"); err == nil {
+		t.Fatal("expected put error")
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = false
+	client.mu.Unlock()
+
+	if stats := cw.Stats(); stats.FailedEvents != 1 || stats.FailedBatches != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 {
+		t.Fatalf("expected one metrics document, got: %v", msgs)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(msgs[0]), &doc); err != nil {
+		t.Fatalf("bad metrics document: %v: %s", err, msgs[0])
+	}
+	if doc["FailedEvents"] != 1.0 || doc["FailedBatches"] != 1.0 || doc["DroppedEvents"] != 0.0 {
+		t.Errorf("unexpected counters: %s", msgs[0])
+	}
+	aws, _ := doc["_aws"].(map[string]any)
+	directives, _ := aws["CloudWatchMetrics"].([]any)
+	if len(directives) != 1 {
+		t.Fatalf("missing metric directive: %s", msgs[0])
+	}
+	if ns := directives[0].(map[string]any)["Namespace"]; ns != "MyApp" {
+		t.Errorf("unexpected namespace: %v", ns)
+	}
+}
+
+func TestSelfMetricsTransforms(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:               client,
+		Now:                  func() time.Time { return time.Time{} },
+		LogGroup:             "/cloudwatchlogs/group",
+		LogStream:            "/cloudwatchlogs/stream",
+		SelfMetricsNamespace: "MyApp",
+		SelfMetricsInterval:  time.Hour, // only the final publication on Close
+		DropIf:               func(e Event) bool { return e.Message == "dropped" },
+		Pipeline:             Pipeline{Enrich(Field{Key: "app", Value: "api"})},
+		SigningKey:           []byte("secret"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 {
+		t.Fatalf("expected one metrics document, got: %v", msgs)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(msgs[0]), &doc); err != nil {
+		t.Fatalf("bad metrics document: %v: %s", err, msgs[0])
+	}
+	if _, found := doc["app"]; found {
+		t.Errorf("document enriched: %s", msgs[0])
+	}
+	if _, found := doc[SignatureField]; found {
+		t.Errorf("document signed: %s", msgs[0])
+	}
+
+	_, err = New(Options{
+		Client:               client,
+		LogGroup:             "/cloudwatchlogs/group",
+		SelfMetricsNamespace: "MyApp",
+		AuditChain:           true,
+	})
+	if err == nil {
+		t.Error("expected error for SelfMetricsNamespace with AuditChain")
+	}
+}
+
+func TestSelfMetricsClones(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:               client,
+		Now:                  func() time.Time { return time.Time{} },
+		LogGroup:             "/cloudwatchlogs/group",
+		LogStream:            "/cloudwatchlogs/stream",
+		SelfMetricsNamespace: "MyApp",
+		SelfMetricsInterval:  time.Hour, // only the final publication on Close
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, errClone := cw.Clone(Options{LogStream: "/cloudwatchlogs/clone"})
+	if errClone != nil {
+		t.Fatal(errClone)
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = true
+	client.mu.Unlock()
+
+	if err := clone.PutSimple("lost"); err == nil {
+		t.Fatal("expected put error")
+	}
+
+	client.mu.Lock()
+	client.denyPutLog = false
+	client.mu.Unlock()
+
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 1 {
+		t.Fatalf("expected one metrics document, got: %v", msgs)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(msgs[0]), &doc); err != nil {
+		t.Fatalf("bad metrics document: %v: %s", err, msgs[0])
+	}
+	if doc["FailedEvents"] != 1.0 || doc["FailedBatches"] != 1.0 {
+		t.Errorf("clone failures not published: %s", msgs[0])
+	}
+}
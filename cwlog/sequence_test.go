@@ -0,0 +1,125 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// sequenceClient enforces the legacy sequence token protocol.
+type sequenceClient struct {
+	*cloudWatchLogMock
+	next     int // expected token, zero for none
+	rejected int
+}
+
+func (c *sequenceClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	var expected *string
+	if c.next > 0 {
+		expected = aws.String(strconv.Itoa(c.next))
+	}
+	if aws.ToString(params.SequenceToken) != aws.ToString(expected) {
+		c.rejected++
+		return nil, &types.InvalidSequenceTokenException{ExpectedSequenceToken: expected}
+	}
+	out, err := c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	c.next++
+	out.NextSequenceToken = aws.String(strconv.Itoa(c.next))
+	return out, nil
+}
+
+func TestSequenceTokens(t *testing.T) {
+	client := &sequenceClient{cloudWatchLogMock: newCloudWatchLogMock(), next: 41} // written by others
+	cw, err := New(Options{
+		Client:         client,
+		Now:            func() time.Time { return time.Time{} },
+		LogGroup:       "/cloudwatchlogs/group",
+		LogStream:      "/cloudwatchlogs/stream",
+		SequenceTokens: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := cw.PutSimple(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.rejected != 1 {
+		t.Errorf("rejected: expected=1 got=%d", client.rejected)
+	}
+	if client.next != 44 {
+		t.Errorf("next token: expected=44 got=%d", client.next)
+	}
+
+	client.next = 100 // another writer raced ahead
+	if err := cw.PutSimple("d"); err != nil {
+		t.Fatal(err)
+	}
+	if client.rejected != 2 {
+		t.Errorf("rejected: expected=2 got=%d", client.rejected)
+	}
+}
+
+func TestSequenceTokensDisabled(t *testing.T) {
+	client := &sequenceClient{cloudWatchLogMock: newCloudWatchLogMock(), next: 1}
+	cw, err := New(Options{
+		Client:    client,
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errToken *types.InvalidSequenceTokenException
+	if err := cw.PutSimple("a"); !errors.As(err, &errToken) {
+		t.Errorf("expected InvalidSequenceTokenException, got: %v", err)
+	}
+}
+
+// acceptedClient reports every batch as already accepted.
+type acceptedClient struct {
+	*cloudWatchLogMock
+}
+
+func (c *acceptedClient) PutLogEvents(_ context.Context,
+	_ *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return nil, &types.DataAlreadyAcceptedException{ExpectedSequenceToken: aws.String("7")}
+}
+
+func TestDataAlreadyAccepted(t *testing.T) {
+	client := &acceptedClient{cloudWatchLogMock: newCloudWatchLogMock()}
+	cw, err := New(Options{
+		Client:           client,
+		LogGroup:         "/cloudwatchlogs/group",
+		SequenceTokens:   true,
+		ResubmitRejected: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, errPut := cw.PutLogEventsResult([]types.InputLogEvent{newEvent("a", 0)})
+	if errPut != nil {
+		t.Fatalf("expected success, got: %v", errPut)
+	}
+	if result.Rejected != nil {
+		t.Errorf("unexpected rejected: %+v", result.Rejected)
+	}
+	if tok := aws.ToString(cw.writer.sequenceToken); tok != "7" {
+		t.Errorf("sequence token: expected=7 got=%s", tok)
+	}
+}
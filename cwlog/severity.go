@@ -0,0 +1,104 @@
+package cwlog
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// SeverityParser finds the level of a plain text line, for
+// PutLogEventsSeverity, Writer and the cwlogtail tailer.
+// ok is false when line has none.
+type SeverityParser func(line string) (level Level, ok bool)
+
+// severityNames maps severity keywords, in upper case, to levels.
+var severityNames = map[string]Level{
+	"TRACE":    LevelDebug,
+	"DEBUG":    LevelDebug,
+	"DBG":      LevelDebug,
+	"INFO":     LevelInfo,
+	"NOTICE":   LevelInfo,
+	"WARN":     LevelWarn,
+	"WARNING":  LevelWarn,
+	"ERROR":    LevelError,
+	"ERR":      LevelError,
+	"CRIT":     LevelError,
+	"CRITICAL": LevelError,
+	"SEVERE":   LevelError,
+	"FATAL":    LevelError,
+	"PANIC":    LevelError,
+	"ALERT":    LevelError,
+	"EMERG":    LevelError,
+}
+
+// KeywordSeverity returns a parser finding the first severity keyword,
+// like ERROR, WARN, INFO or FATAL, as a whole word in upper case or
+// title case, like "Error". Lower case is ignored, since it is common
+// in messages, like "no error", except for Go panics starting with "panic:".
+func KeywordSeverity() SeverityParser {
+	return func(line string) (Level, bool) {
+		if strings.HasPrefix(line, "panic: ") {
+			return LevelError, true
+		}
+		for word := range strings.FieldsFuncSeq(line, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if len(word) < 3 || !unicode.IsUpper(rune(word[0])) {
+				continue
+			}
+			upper := strings.ToUpper(word)
+			if word != upper && word[1:] != strings.ToLower(word[1:]) {
+				continue // mixed case
+			}
+			if level, found := severityNames[upper]; found {
+				return level, true
+			}
+		}
+		return LevelDebug, false
+	}
+}
+
+// RegexSeverity returns a parser taking the severity from the first
+// submatch of re, or the whole match if it has none, like
+// `level=(\w+)`. Names are case insensitive and accept the keywords of
+// KeywordSeverity, like "warning" or "fatal".
+func RegexSeverity(re *regexp.Regexp) SeverityParser {
+	return func(line string) (Level, bool) {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			return LevelDebug, false
+		}
+		name := m[0]
+		if len(m) > 1 {
+			name = m[1]
+		}
+		level, found := severityNames[strings.ToUpper(strings.TrimSpace(name))]
+		return level, found
+	}
+}
+
+// PutLogEventsSeverity sends plain text events as structured events, at
+// the level found by parse, or LevelInfo, keeping their timestamps. Hence
+// unstructured sources get a level field and go through MinLevel,
+// LevelRouting, LoadShedding and PriorityLevel like leveled events.
+func (l *Log) PutLogEventsSeverity(events []types.InputLogEvent, parse SeverityParser) error {
+	var errs []error
+	for _, e := range events {
+		msg := aws.ToString(e.Message)
+		level, found := parse(msg)
+		if !found {
+			level = LevelInfo
+		}
+		t := l.options.Now()
+		if e.Timestamp != nil {
+			t = time.UnixMilli(*e.Timestamp)
+		}
+		if err := l.putLevelAt(level, t, msg, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+package cwlog
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSeverityParsers(t *testing.T) {
+	keywords := KeywordSeverity()
+	regex := RegexSeverity(regexp.MustCompile(`level=(\w+)`))
+
+	table := []struct {
+		name  string
+		parse SeverityParser
+		line  string
+		ok    bool
+		level Level
+	}{
+		{"upper case", keywords, "2024-05-31 22:14:15 ERROR disk full", true, LevelError},
+		{"title case", keywords, "[Warning] low memory", true, LevelWarn},
+		{"bracketed", keywords, "[INFO] started", true, LevelInfo},
+		{"first keyword", keywords, "DEBUG retry after ERROR", true, LevelDebug},
+		{"fatal", keywords, "FATAL: cannot start", true, LevelError},
+		{"go panic", keywords, "panic: runtime error: index out of range", true, LevelError},
+		{"lower case ignored", keywords, "finished without error", false, LevelDebug},
+		{"prefix word ignored", keywords, "ERRORS counted: 3", false, LevelDebug},
+		{"no keyword", keywords, "hello world", false, LevelDebug},
+		{"regex", regex, "ts=1 level=warning msg=slow", true, LevelWarn},
+		{"regex unknown name", regex, "level=verbose", false, LevelDebug},
+		{"regex no match", regex, "ERROR without key", false, LevelDebug},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			level, ok := data.parse(data.line)
+			if ok != data.ok || level != data.level {
+				t.Errorf("expected=%v,%v got=%v,%v", data.level, data.ok, level, ok)
+			}
+		})
+	}
+}
+
+func TestWriterSeverity(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		MinLevel:  LevelInfo,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWriter(cw, WriterOptions{Severity: KeywordSeverity()})
+	if _, err := fmt.Fprint(w, "DEBUG noise\nWARN slow\nplain\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"level":"WARN","msg":"WARN slow"}`,
+		`{"level":"INFO","msg":"plain"}`,
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+}
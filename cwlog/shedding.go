@@ -0,0 +1,49 @@
+package cwlog
+
+import "math/rand/v2"
+
+// sheddingSteps map buffer occupancy thresholds to the fraction of
+// DEBUG and INFO events kept by Options.LoadShedding, from the highest.
+var sheddingSteps = []struct {
+	occupancy float64
+	keep      float64
+}{
+	{0.90, 0},
+	{0.75, 0.1},
+	{0.50, 0.5},
+}
+
+// shedRand returns a number in [0,1). It is a variable for testing.
+var shedRand = rand.Float64
+
+// sheddingKeep returns the fraction of DEBUG and INFO events to keep at
+// the buffer occupancy.
+func sheddingKeep(occupancy float64) float64 {
+	for _, step := range sheddingSteps {
+		if occupancy >= step.occupancy {
+			return step.keep
+		}
+	}
+	return 1
+}
+
+// shed reports whether a leveled event should be dropped by LoadShedding.
+// WARN and ERROR events are always kept.
+func (l *Log) shed(level Level) bool {
+	if !l.options.LoadShedding || l.batcher == nil || level >= LevelWarn {
+		return false
+	}
+	keep := sheddingKeep(l.batcher.occupancy())
+	if keep >= 1 || shedRand() < keep {
+		return false
+	}
+	l.stats.shedEvents.Add(1)
+	return true
+}
+
+// occupancy returns the fraction of the buffer in use.
+func (b *batcher) occupancy() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return float64(b.count) / float64(b.log.options.BufferEvents)
+}
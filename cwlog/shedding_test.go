@@ -0,0 +1,87 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSheddingKeep(t *testing.T) {
+	table := []struct {
+		occupancy float64
+		keep      float64
+	}{
+		{0, 1},
+		{0.49, 1},
+		{0.5, 0.5},
+		{0.8, 0.1},
+		{0.9, 0},
+		{1, 0},
+	}
+	for i, data := range table {
+		if keep := sheddingKeep(data.occupancy); keep != data.keep {
+			t.Errorf("%02d of %02d: occupancy=%v expected=%v got=%v",
+				i+1, len(table), data.occupancy, data.keep, keep)
+		}
+	}
+}
+
+func TestLoadShedding(t *testing.T) {
+	saved := shedRand
+	defer func() { shedRand = saved }()
+	shedRand = func() float64 { return 0.4 }
+
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/cloudwatchlogs/group",
+		LogStream:     "/cloudwatchlogs/stream",
+		Async:         true,
+		BufferEvents:  10,
+		FlushInterval: -1,
+		LoadShedding:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fill := func(n int) {
+		t.Helper()
+		for i := range n {
+			if err := cw.PutSimple(fmt.Sprintf("fill %d", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	fill(6) // 60%: half kept, 0.4 is kept
+	if err := cw.Info("kept at 60%"); err != nil {
+		t.Fatal(err)
+	}
+	if cw.Stats().ShedEvents != 0 {
+		t.Errorf("unexpected shed at 60%%")
+	}
+
+	fill(2) // 90%: none kept
+	if err := cw.Debug("shed debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Info("shed info"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Warn("kept warn"); err != nil {
+		t.Fatal(err)
+	}
+	if shed := cw.Stats().ShedEvents; shed != 2 {
+		t.Errorf("expected 2 shed events, got %d", shed)
+	}
+
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 10 {
+		t.Errorf("expected 10 messages, got %d: %v", len(msgs), msgs)
+	}
+}
@@ -0,0 +1,56 @@
+package cwlog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// shutdownExit terminates the process after a shutdown signal was handled.
+// It is a variable for testing.
+var shutdownExit = reraise
+
+// FlushOnShutdown installs signal handling that closes l, flushing queued
+// events, before the process exits on SIGINT or SIGTERM, so the final log
+// lines are not lost. Custom signals may be given instead.
+// After closing l, the signal is raised again with default handling,
+// terminating the process as it would without FlushOnShutdown.
+// Call the returned stop function to uninstall the handler.
+func FlushOnShutdown(l *Log, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-ch:
+			l.Close()
+			signal.Stop(ch)
+			shutdownExit(sig)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// reraise delivers sig again to the process, now with default handling.
+func reraise(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		if err := p.Signal(sig); err == nil {
+			return
+		}
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package cwlog
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFlushOnShutdown(t *testing.T) {
+	exited := make(chan os.Signal, 1)
+	shutdownExit = func(sig os.Signal) { exited <- sig }
+	defer func() { shutdownExit = reraise }()
+
+	client := newCloudWatchLogMock()
+	cw := newAsyncLog(t, client, 0)
+	if err := cw.PutSimple("last words"); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := FlushOnShutdown(cw, syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case sig := <-exited:
+		if sig != syscall.SIGUSR1 {
+			t.Fatalf("signal: %v", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+
+	s := client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]
+	if len(s) != 1 {
+		t.Fatalf("log lines: expected=1 found=%d", len(s))
+	}
+}
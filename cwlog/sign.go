@@ -0,0 +1,156 @@
+package cwlog
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// SignatureField is the JSON field added to JSON object messages signed
+// by Options.SigningKey. Its value is the hex encoded HMAC-SHA256.
+const SignatureField = "cwlog_sig"
+
+// SignatureSeparator separates other messages signed by Options.SigningKey
+// from their hex encoded HMAC-SHA256.
+const SignatureSeparator = " cwlog-sig:"
+
+// ErrInvalidSignature reports a message failing signature verification.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// signatureFieldPrefix starts the signature added to JSON objects.
+const signatureFieldPrefix = `"` + SignatureField + `":"`
+
+// signatureHexLen is the length of the hex encoded signature.
+const signatureHexLen = 2 * sha256.Size
+
+func messageMAC(key []byte, msg string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isJSONObject reports whether msg is exactly one JSON object,
+// without surrounding spaces.
+func isJSONObject(msg string) bool {
+	return strings.HasPrefix(msg, "{") && strings.HasSuffix(msg, "}") && json.Valid([]byte(msg))
+}
+
+// signMessage returns msg with its signature, as the last field of JSON
+// objects, so they stay valid JSON for Logs Insights, or as a suffix.
+func signMessage(key []byte, msg string) string {
+	sig := messageMAC(key, msg)
+	if !isJSONObject(msg) {
+		return msg + SignatureSeparator + sig
+	}
+	body := msg[:len(msg)-1]
+	if strings.TrimSpace(body) != "{" {
+		body += ","
+	}
+	return body + signatureFieldPrefix + sig + `"}`
+}
+
+// sign returns a copy of events with signed messages.
+func (l *Log) sign(events []types.InputLogEvent) []types.InputLogEvent {
+	result := make([]types.InputLogEvent, len(events))
+	for i, e := range events {
+		result[i] = types.InputLogEvent{
+			Message:   aws.String(signMessage(l.options.SigningKey, aws.ToString(e.Message))),
+			Timestamp: e.Timestamp,
+		}
+	}
+	return result
+}
+
+// splitSignature separates a signed message into the original message
+// and the hex signature.
+func splitSignature(msg string) (string, string, bool) {
+	if n := len(signatureFieldPrefix) + signatureHexLen + 2; len(msg) > n && strings.HasSuffix(msg, `"}`) {
+		tail := msg[len(msg)-n:]
+		if strings.HasPrefix(tail, signatureFieldPrefix) {
+			body := strings.TrimSuffix(msg[:len(msg)-n], ",")
+			return body + "}", tail[len(signatureFieldPrefix) : n-2], true
+		}
+	}
+	if i := strings.LastIndex(msg, SignatureSeparator); i >= 0 {
+		return msg[:i], msg[i+len(SignatureSeparator):], true
+	}
+	return "", "", false
+}
+
+// VerifySignature checks a message signed by Options.SigningKey,
+// returning it without the signature. When AuditChain is also enabled,
+// strip the chain hash first, like with ChainVerifier.
+func VerifySignature(key []byte, message string) (string, error) {
+	original, sig, found := splitSignature(message)
+	if !found {
+		return "", fmt.Errorf("%w: missing signature", ErrInvalidSignature)
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(original))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return "", fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return original, nil
+}
+
+// VerifyLines copies r to w line by line, verifying every message with
+// VerifySignature and removing the signatures. Like DecodeLines, a
+// message may be preceded by other text, like the timestamp and stream
+// printed by "cloudwatchlog tail". It fails on the first line without
+// a valid signature.
+func VerifyLines(w io.Writer, r io.Reader, key []byte) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, errRead := br.ReadString('\n')
+		if line != "" {
+			text, newline := strings.CutSuffix(line, "\n")
+			verified, err := verifyLine(key, text)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if newline {
+				verified += "\n"
+			}
+			if _, err := io.WriteString(w, verified); err != nil {
+				return err
+			}
+		}
+		if errRead == io.EOF {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+}
+
+// verifyLine tries the whole line as the message, then drops leading
+// space separated fields until a message verifies.
+func verifyLine(key []byte, text string) (string, error) {
+	var prefix string
+	msg := text
+	for {
+		original, err := VerifySignature(key, msg)
+		if err == nil {
+			return prefix + original, nil
+		}
+		i := strings.IndexByte(msg, ' ')
+		if i < 0 {
+			return "", err
+		}
+		prefix, msg = text[:len(prefix)+i+1], msg[i+1:]
+	}
+}
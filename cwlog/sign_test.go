@@ -0,0 +1,111 @@
+package cwlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignMessage(t *testing.T) {
+	key := []byte("secret")
+
+	table := []struct {
+		name     string
+		message  string
+		jsonForm bool
+	}{
+		{"text", "hello world", false},
+		{"empty", "", false},
+		{"json object", `{"level":"info","msg":"hi"}`, true},
+		{"empty json object", `{}`, true},
+		{"json object with spaces", `{ "a": 1 }`, true},
+		{"json array", `[1,2]`, false},
+		{"invalid json", `{"a":}`, false},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			signed := signMessage(key, data.message)
+			if data.jsonForm {
+				var fields map[string]any
+				if err := json.Unmarshal([]byte(signed), &fields); err != nil {
+					t.Fatalf("signed json: %v: %s", err, signed)
+				}
+				if _, found := fields[SignatureField]; !found {
+					t.Errorf("missing signature field: %s", signed)
+				}
+			} else if !strings.Contains(signed, SignatureSeparator) {
+				t.Errorf("missing signature suffix: %s", signed)
+			}
+
+			original, err := VerifySignature(key, signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if original != data.message {
+				t.Errorf("original: expected=%q got=%q", data.message, original)
+			}
+
+			if _, err := VerifySignature([]byte("wrong"), signed); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("wrong key: expected ErrInvalidSignature, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureInvalid(t *testing.T) {
+	key := []byte("secret")
+	for _, msg := range []string{
+		"unsigned",
+		strings.Replace(signMessage(key, "pay 10"), "10", "99", 1),
+		strings.Replace(signMessage(key, `{"pay":10}`), "10", "99", 1),
+		"message" + SignatureSeparator + "zz",
+	} {
+		if _, err := VerifySignature(key, msg); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%q: expected ErrInvalidSignature, got: %v", msg, err)
+		}
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	key := []byte("secret")
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:     client,
+		Now:        func() time.Time { return time.Time{} },
+		LogGroup:   "/cloudwatchlogs/group",
+		LogStream:  "/cloudwatchlogs/stream",
+		SigningKey: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"plain", `{"a":1}`} {
+		if err := cw.PutSimple(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var input bytes.Buffer
+	for _, e := range client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"] {
+		fmt.Fprintf(&input, "1970-01-01T00:00:00Z stream %s\n", *e.Message)
+	}
+	var output bytes.Buffer
+	if err := VerifyLines(&output, &input, key); err != nil {
+		t.Fatal(err)
+	}
+	const expected = "1970-01-01T00:00:00Z stream plain\n1970-01-01T00:00:00Z stream {\"a\":1}\n"
+	if output.String() != expected {
+		t.Errorf("output: expected=%q got=%q", expected, output.String())
+	}
+
+	err = VerifyLines(&output, strings.NewReader("signed line?\n"), key)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("unsigned line: expected ErrInvalidSignature, got: %v", err)
+	}
+}
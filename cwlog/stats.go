@@ -0,0 +1,112 @@
+package cwlog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds counters about the Log activity.
+type Stats struct {
+	// SanitizedEvents counts events whose message was modified
+	// by SanitizeUTF8 or StripControl.
+	SanitizedEvents int64
+
+	// ResubmittedEvents counts rejected events sent again by ResubmitRejected.
+	ResubmittedEvents int64
+
+	// CompressedEvents counts events compressed by CompressLarge.
+	CompressedEvents int64
+
+	// SplitEvents counts events too large even once compressed by
+	// CompressLarge, sent split into several events instead.
+	SplitEvents int64
+
+	// DroppedEvents counts events dropped or evicted because the
+	// buffered mode queue was full.
+	DroppedEvents int64
+
+	// FailedEvents counts events whose PutLogEvents delivery failed.
+	FailedEvents int64
+
+	// FailedBatches counts failed PutLogEvents deliveries.
+	FailedBatches int64
+
+	// ShedEvents counts DEBUG and INFO events dropped by LoadShedding.
+	ShedEvents int64
+
+	// FilteredEvents counts events dropped by DropIf or Pipeline stages.
+	FilteredEvents int64
+
+	// ExpiredEvents counts too old events handed to OnExpired, or
+	// dropped by AuditChain.
+	ExpiredEvents int64
+
+	// FallbackEvents counts failed events written to FallbackWriter.
+	FallbackEvents int64
+
+	// ThrottledBatches counts background sends throttled by AWS,
+	// tracked by AdaptiveBatching.
+	ThrottledBatches int64
+
+	// BufferedEvents and BufferedBytes measure the buffered mode queue,
+	// with BufferedBytes as the sum of EventSize.
+	BufferedEvents int
+	BufferedBytes  int
+
+	// PeakBufferedEvents and PeakBufferedBytes are the high watermarks
+	// of BufferedEvents and BufferedBytes, for buffer capacity planning.
+	PeakBufferedEvents int
+	PeakBufferedBytes  int
+
+	// FlushInterval, FlushEvents and FlushBytes are the current buffered
+	// mode flush parameters, adjusted by AdaptiveBatching.
+	FlushInterval time.Duration
+	FlushEvents   int
+	FlushBytes    int
+}
+
+type logStats struct {
+	sanitizedEvents   atomic.Int64
+	resubmittedEvents atomic.Int64
+	droppedEvents     atomic.Int64
+	compressedEvents  atomic.Int64
+	splitEvents       atomic.Int64
+	failedEvents      atomic.Int64
+	failedBatches     atomic.Int64
+	throttledBatches  atomic.Int64
+	shedEvents        atomic.Int64
+	filteredEvents    atomic.Int64
+	expiredEvents     atomic.Int64
+	fallbackEvents    atomic.Int64
+}
+
+// Stats returns a snapshot of the Log counters. Counters are shared by a
+// Log, its LevelRouting destinations and its clones, like the buffer.
+func (l *Log) Stats() Stats {
+	s := Stats{
+		SanitizedEvents:   l.stats.sanitizedEvents.Load(),
+		ResubmittedEvents: l.stats.resubmittedEvents.Load(),
+		CompressedEvents:  l.stats.compressedEvents.Load(),
+		SplitEvents:       l.stats.splitEvents.Load(),
+		DroppedEvents:     l.stats.droppedEvents.Load(),
+		FailedEvents:      l.stats.failedEvents.Load(),
+		FailedBatches:     l.stats.failedBatches.Load(),
+		ThrottledBatches:  l.stats.throttledBatches.Load(),
+		ShedEvents:        l.stats.shedEvents.Load(),
+		FilteredEvents:    l.stats.filteredEvents.Load(),
+		ExpiredEvents:     l.stats.expiredEvents.Load(),
+		FallbackEvents:    l.stats.fallbackEvents.Load(),
+	}
+	if b := l.batcher; b != nil {
+		b.mu.Lock()
+		s.FlushInterval = b.flushInterval()
+		s.FlushEvents = b.flushEvents
+		s.FlushBytes = b.flushBytes
+		s.BufferedEvents = b.count
+		s.BufferedBytes = b.bytes
+		s.PeakBufferedEvents = b.peakCount
+		s.PeakBufferedBytes = b.peakBytes
+		b.mu.Unlock()
+	}
+	return s
+}
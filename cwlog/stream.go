@@ -0,0 +1,188 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// GroupManager manages one log group, handing out a StreamWriter per
+// stream. Several StreamWriters may share one GroupManager, so advanced
+// users can drive many streams and compose them with custom batching.
+// Log is built on top of GroupManager and StreamWriter.
+type GroupManager struct {
+	client CloudWatchLogClient
+	group  string
+}
+
+// NewGroupManager creates the group, if missing, and sets its retention.
+// See EnsureGroup.
+func NewGroupManager(ctx context.Context, client CloudWatchLogClient, spec GroupSpec) (*GroupManager, error) {
+	if err := EnsureGroup(ctx, client, spec); err != nil {
+		return nil, err
+	}
+	return newGroupManager(client, spec.Name), nil
+}
+
+// newGroupManager creates the GroupManager for an existing group.
+func newGroupManager(client CloudWatchLogClient, group string) *GroupManager {
+	return &GroupManager{client: client, group: group}
+}
+
+// Group returns the log group name.
+func (g *GroupManager) Group() string {
+	return g.group
+}
+
+// StreamOptions define optional parameters for StreamWriter.
+type StreamOptions struct {
+	// SequenceTokens enables tracking PutLogEvents sequence tokens.
+	// See Options.SequenceTokens.
+	SequenceTokens bool
+
+	// CompressRequests gzip compresses PutLogEvents request bodies.
+	// See Options.CompressRequests.
+	CompressRequests bool
+
+	// DebugLogger optionally receives internal events. See Options.DebugLogger.
+	DebugLogger *slog.Logger
+}
+
+// Stream returns a StreamWriter for stream. The stream is created
+// on the first call to Create or PutLogEvents.
+func (g *GroupManager) Stream(stream string, options StreamOptions) *StreamWriter {
+	w := &StreamWriter{
+		manager:    g,
+		options:    options,
+		groupName:  aws.String(g.group),
+		streamName: aws.String(stream),
+	}
+	if options.CompressRequests {
+		w.optFns = []func(*cloudwatchlogs.Options){withRequestGzip}
+	}
+	return w
+}
+
+// StreamWriter sends batches to one log stream. Batching is up to the
+// caller: each PutLogEvents is one PutLogEvents call, hence events must
+// respect ValidateBatch. It is safe for concurrent use.
+type StreamWriter struct {
+	manager *GroupManager
+	options StreamOptions
+
+	mu            sync.Mutex
+	created       bool
+	groupName     *string
+	streamName    *string
+	sequenceToken *string                          // next token when SequenceTokens is enabled
+	input         cloudwatchlogs.PutLogEventsInput // reused across puts
+	optFns        []func(*cloudwatchlogs.Options)  // per call client options
+}
+
+// Name returns the log stream name.
+func (w *StreamWriter) Name() string {
+	return *w.streamName
+}
+
+// Create creates the stream, if not created yet by this StreamWriter.
+// An existing stream is not an error.
+func (w *StreamWriter) Create(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.create(ctx)
+}
+
+func (w *StreamWriter) create(ctx context.Context) error {
+	if w.created {
+		return nil
+	}
+
+	if _, errCreateStream := w.manager.client.CreateLogStream(ctx,
+		&cloudwatchlogs.CreateLogStreamInput{LogGroupName: w.groupName,
+			LogStreamName: w.streamName}); errCreateStream != nil {
+
+		var errExists *types.ResourceAlreadyExistsException
+		if !errors.As(errCreateStream, &errExists) {
+			// other error than "already exists" must be reported
+
+			var errNotFound *types.ResourceNotFoundException
+			if errors.As(errCreateStream, &errNotFound) {
+				forgetGroup(w.manager.group) // deleted, bootstrap again
+			}
+
+			return fmt.Errorf("create log stream error: group=%s stream=%s%s: %w",
+				w.manager.group, w.Name(), callDetails(errCreateStream), errCreateStream)
+		}
+
+		// here: already exists error is benign
+	}
+
+	w.created = true
+	w.sequenceToken = nil // unknown for the new stream
+	return nil
+}
+
+// maxSequenceTokenRetries bounds resends after InvalidSequenceTokenException,
+// which may repeat when other writers share the stream.
+const maxSequenceTokenRetries = 3
+
+// PutLogEvents sends events in one PutLogEvents call, creating the stream
+// first if needed, and tracking the sequence token when SequenceTokens
+// is enabled. DataAlreadyAcceptedException is reported as success, since
+// the batch was ingested by a previous attempt.
+func (w *StreamWriter) PutLogEvents(ctx context.Context, events []types.InputLogEvent) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.create(ctx); err != nil {
+		return nil, err
+	}
+
+	input := &w.input
+	input.LogEvents = events
+	input.LogGroupName = w.groupName
+	input.LogStreamName = w.streamName
+	defer func() {
+		input.LogEvents = nil // do not retain caller events
+		input.SequenceToken = nil
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if w.options.SequenceTokens {
+			input.SequenceToken = w.sequenceToken
+		}
+		out, err := w.manager.client.PutLogEvents(ctx, input, w.optFns...)
+		if err == nil {
+			w.sequenceToken = out.NextSequenceToken
+			return out, nil
+		}
+		var errAccepted *types.DataAlreadyAcceptedException
+		if errors.As(err, &errAccepted) {
+			w.debug("data already accepted", "group", w.manager.group,
+				"stream", w.Name())
+			w.sequenceToken = errAccepted.ExpectedSequenceToken
+			return &cloudwatchlogs.PutLogEventsOutput{}, nil
+		}
+		var errToken *types.InvalidSequenceTokenException
+		if !w.options.SequenceTokens || !errors.As(err, &errToken) ||
+			attempt == maxSequenceTokenRetries {
+			return out, err
+		}
+		w.debug("invalid sequence token, retrying", "group", w.manager.group,
+			"stream", w.Name(),
+			"expected", aws.ToString(errToken.ExpectedSequenceToken))
+		w.sequenceToken = errToken.ExpectedSequenceToken
+	}
+}
+
+func (w *StreamWriter) debug(msg string, args ...any) {
+	if w.options.DebugLogger != nil {
+		w.options.DebugLogger.Debug(msg, args...)
+	}
+}
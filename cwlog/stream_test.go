@@ -0,0 +1,66 @@
+package cwlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestGroupManagerStreams(t *testing.T) {
+	client := newCloudWatchLogMock()
+	ctx := context.TODO()
+
+	groups, err := NewGroupManager(ctx, client, GroupSpec{Name: "/app/group", RetentionInDays: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups.Group() != "/app/group" {
+		t.Errorf("unexpected group: %s", groups.Group())
+	}
+
+	a := groups.Stream("a", StreamOptions{})
+	b := groups.Stream("b", StreamOptions{})
+
+	for _, w := range []*StreamWriter{a, b, a} {
+		events := []types.InputLogEvent{newEvent("to "+w.Name(), 0)}
+		if _, err := w.PutLogEvents(ctx, events); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := messages(client.groups["/app/group"]["a"]); len(got) != 2 || got[0] != "to a" {
+		t.Errorf("unexpected stream a: %v", got)
+	}
+	if got := messages(client.groups["/app/group"]["b"]); len(got) != 1 || got[0] != "to b" {
+		t.Errorf("unexpected stream b: %v", got)
+	}
+}
+
+func TestStreamWriterCreateError(t *testing.T) {
+	client := newCloudWatchLogMock()
+	ctx := context.TODO()
+
+	groups, err := NewGroupManager(ctx, client, GroupSpec{Name: "/app/group"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.denyCreateStream = true
+	w := groups.Stream("a", StreamOptions{})
+	if _, err := w.PutLogEvents(ctx, []types.InputLogEvent{newEvent("x", 0)}); err == nil {
+		t.Fatal("expected create stream error")
+	}
+
+	// creation is retried on the next call
+	client.denyCreateStream = false
+	if err := w.Create(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.PutLogEvents(ctx, []types.InputLogEvent{newEvent("y", 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(client.groups["/app/group"]["a"]); len(got) != 1 || got[0] != "y" {
+		t.Errorf("unexpected stream a: %v", got)
+	}
+}
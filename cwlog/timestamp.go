@@ -0,0 +1,92 @@
+package cwlog
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// prepare applies the message options, filters and timestamp fixes.
+// The caller slice is never modified.
+func (l *Log) prepare(events []types.InputLogEvent) []types.InputLogEvent {
+	if l.options.SanitizeUTF8 || l.options.StripControl {
+		events = l.sanitize(events)
+	}
+	if l.options.DropIf != nil {
+		events = l.dropFiltered(events)
+	}
+	if l.options.Grok != nil {
+		events = l.grok(events)
+	}
+	if len(l.options.Pipeline) > 0 {
+		n := len(events)
+		events = l.options.Pipeline.Apply(events)
+		l.stats.filteredEvents.Add(int64(n - len(events)))
+	}
+	if l.options.CompressLarge {
+		events = l.compressLarge(events)
+	}
+	if len(l.options.SigningKey) > 0 {
+		events = l.sign(events)
+	}
+	events = fixTimestamps(events, l.options.Now().UnixMilli(), l.options.MonotonicTimestamps)
+	if l.options.OnExpired != nil {
+		events = l.divertExpired(events)
+	}
+	if l.options.TeeWriter != nil {
+		l.tee(events)
+	}
+	return events
+}
+
+// fixTimestamps fills undefined timestamps with now and optionally
+// enforces non-decreasing timestamps.
+// The events slice is copied only when some timestamp needs changes.
+func fixTimestamps(events []types.InputLogEvent, now int64, monotonic bool) []types.InputLogEvent {
+	var result []types.InputLogEvent
+	var prev int64
+	for i, e := range events {
+		ts := now
+		if e.Timestamp != nil {
+			ts = *e.Timestamp
+		}
+		if monotonic && i > 0 {
+			ts = max(ts, prev)
+		}
+		prev = ts
+		if e.Timestamp != nil && *e.Timestamp == ts {
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, len(events))
+			copy(result, events)
+		}
+		result[i].Timestamp = aws.Int64(ts)
+	}
+	if result == nil {
+		return events
+	}
+	return result
+}
+
+// raiseTimestamps raises timestamps lower than floor, returning the
+// events, copied only when some timestamp needs changes, and the new
+// floor.
+func raiseTimestamps(events []types.InputLogEvent, floor int64) ([]types.InputLogEvent, int64) {
+	var result []types.InputLogEvent
+	for i, e := range events {
+		ts := aws.ToInt64(e.Timestamp)
+		if ts >= floor {
+			floor = ts
+			continue
+		}
+		if result == nil {
+			result = make([]types.InputLogEvent, len(events))
+			copy(result, events)
+		}
+		result[i].Timestamp = aws.Int64(floor)
+	}
+	if result == nil {
+		return events, floor
+	}
+	return result, floor
+}
@@ -0,0 +1,89 @@
+package cwlog
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type timestampTest struct {
+	name      string
+	input     []*int64
+	monotonic bool
+	expected  []int64
+}
+
+var timestampTestTable = []timestampTest{
+	{"unchanged", []*int64{aws.Int64(1), aws.Int64(1)}, false, []int64{1, 1}},
+	{"fill nil", []*int64{nil, aws.Int64(5)}, false, []int64{100, 5}},
+	{"monotonic equal", []*int64{aws.Int64(1), aws.Int64(1), aws.Int64(1)}, true, []int64{1, 1, 1}},
+	{"monotonic backwards", []*int64{aws.Int64(10), aws.Int64(5), aws.Int64(20)}, true, []int64{10, 10, 20}},
+	{"monotonic fill", []*int64{aws.Int64(200), nil}, true, []int64{200, 200}},
+}
+
+func TestFixTimestamps(t *testing.T) {
+	for i, data := range timestampTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(timestampTestTable), data.name)
+
+		events := make([]types.InputLogEvent, len(data.input))
+		for j, ts := range data.input {
+			events[j] = types.InputLogEvent{Message: aws.String("x"), Timestamp: ts}
+		}
+
+		result := fixTimestamps(events, 100, data.monotonic)
+
+		for j, e := range result {
+			if got := aws.ToInt64(e.Timestamp); got != data.expected[j] {
+				t.Errorf("%s: event %d: expected=%d got=%d", name, j, data.expected[j], got)
+			}
+		}
+		for j, ts := range data.input {
+			if events[j].Timestamp != ts {
+				t.Errorf("%s: caller event %d modified", name, j)
+			}
+		}
+	}
+}
+
+func TestMonotonicBuffered(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:              client,
+		Now:                 func() time.Time { return time.Time{} },
+		LogGroup:            "/cloudwatchlogs/group",
+		LogStream:           "/cloudwatchlogs/stream",
+		Async:               true,
+		FlushInterval:       -1,
+		MonotonicTimestamps: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the high priority lane is sent ahead of the older low priority event
+	if err := cw.PutLogEvents([]types.InputLogEvent{newEvent("a", 30)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutLogEventsPriority([]types.InputLogEvent{newEvent("b", 20)}); err != nil {
+		t.Fatal(err)
+	}
+	// concurrent callers queue events out of order
+	if err := cw.PutLogEvents([]types.InputLogEvent{newEvent("c", 10)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int64
+	for _, e := range client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"] {
+		got = append(got, aws.ToInt64(e.Timestamp))
+	}
+	if !slices.IsSorted(got) || len(got) != 3 {
+		t.Errorf("flushed timestamps not non-decreasing: %v", got)
+	}
+}
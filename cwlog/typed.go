@@ -0,0 +1,122 @@
+package cwlog
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Leveler is optionally implemented by events given to Put and PutBatch
+// to choose their level, which otherwise defaults to LevelInfo.
+type Leveler interface {
+	Level() Level
+}
+
+// Put sends a typed event rendered by Options.Encoder, giving compile-time
+// checked structured logging for strict event schemas. The message is the
+// type name, like "OrderPlaced", and the fields are the exported struct
+// fields in declaration order, named and omitted according to their json
+// tags. Exported embedded structs are flattened. Non-struct events are reported
+// under the "event" field. Events below Options.MinLevel are dropped,
+// as well as events shed by Options.LoadShedding.
+func Put[T any](l *Log, event T) error {
+	level, msg, fields := typedEvent(event)
+	return l.putLevel(level, msg, fields)
+}
+
+// PutBatch is like Put for many events, sending them in as few
+// PutLogEvents calls as the AWS batch limits allow.
+// Unlike Put, events are not forwarded to Options.LevelRouting.
+func PutBatch[T any](l *Log, events []T) error {
+	now := l.options.Now()
+	batch := make([]types.InputLogEvent, 0, min(len(events), MaxBatchEvents))
+	var size int
+	for _, event := range events {
+		level, msg, fields := typedEvent(event)
+		if level < l.options.MinLevel || l.shed(level) {
+			continue
+		}
+		s, err := l.options.Encoder.Encode(Entry{Time: now, Level: level,
+			Message: msg, Fields: l.structuredFields(fields)})
+		if err != nil {
+			return err
+		}
+		e := newEvent(s, now.UnixMilli())
+		if len(batch) == MaxBatchEvents || size+EventSize(e) > MaxBatchBytes {
+			if err := l.putEvents(batch, false); err != nil {
+				return err
+			}
+			batch = make([]types.InputLogEvent, 0, min(len(events), MaxBatchEvents))
+			size = 0
+		}
+		batch = append(batch, e)
+		size += EventSize(e)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return l.putEvents(batch, false)
+}
+
+// typedEvent extracts level, message and fields from event.
+// The message is the name of the event dynamic type, so that interface
+// type arguments like any still name the event.
+func typedEvent[T any](event T) (Level, string, []Field) {
+	t := reflect.TypeOf(event)
+	if t == nil {
+		t = reflect.TypeFor[T]() // nil interface
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	msg := t.Name()
+
+	v := reflect.ValueOf(event)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return LevelInfo, msg, nil
+	}
+
+	level := LevelInfo
+	if lv, ok := any(event).(Leveler); ok {
+		level = lv.Level()
+	}
+
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return level, msg, []Field{{Key: "event", Value: v.Interface()}}
+	}
+	return level, msg, appendStructFields(nil, v)
+}
+
+// appendStructFields appends the exported fields of struct v,
+// following encoding/json naming and omitempty rules.
+func appendStructFields(fields []Field, v reflect.Value) []Field {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			fields = appendStructFields(fields, fv)
+			continue
+		}
+		if slices.Contains(strings.Split(opts, ","), "omitempty") && fv.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, Field{Key: name, Value: fv.Interface()})
+	}
+	return fields
+}
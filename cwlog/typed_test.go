@@ -0,0 +1,125 @@
+package cwlog
+
+import (
+	"fmt"
+	"testing"
+)
+
+type typedBase struct {
+	Service string `json:"service"`
+}
+
+type TypedCommon struct {
+	Region string `json:"region"`
+}
+
+type OrderPlaced struct {
+	TypedCommon
+	typedBase
+	OrderID  string  `json:"order_id"`
+	Amount   float64 `json:"amount"`
+	Coupon   string  `json:"coupon,omitempty"`
+	Internal string  `json:"-"`
+	secret   string
+}
+
+type PaymentFailed struct {
+	OrderID string `json:"order_id"`
+}
+
+func (PaymentFailed) Level() Level { return LevelError }
+
+func TestPutTyped(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	order := OrderPlaced{
+		TypedCommon: TypedCommon{Region: "us-east-1"},
+		typedBase:   typedBase{Service: "hidden"},
+		OrderID:     "o-1",
+		Amount:      9.5,
+		Internal:    "x",
+		secret:      "y",
+	}
+	if err := Put(cw, order); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put(cw, &PaymentFailed{OrderID: "o-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put(cw, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"level":"INFO","msg":"OrderPlaced","region":"us-east-1","order_id":"o-1","amount":9.5}`,
+		`{"level":"ERROR","msg":"PaymentFailed","order_id":"o-1"}`,
+		`{"level":"INFO","msg":"int","event":42}`,
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != len(expected) {
+		t.Fatalf("expected %d messages, got: %v", len(expected), msgs)
+	}
+	for i, e := range expected {
+		if msgs[i] != e {
+			t.Errorf("%02d of %02d: expected=%s got=%s", i+1, len(expected), e, msgs[i])
+		}
+	}
+}
+
+func TestPutBatch(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+	cw.options.MinLevel = LevelWarn
+
+	events := []PaymentFailed{{OrderID: "a"}, {OrderID: "b"}}
+	if err := PutBatch(cw, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := PutBatch(cw, []OrderPlaced{{OrderID: "dropped"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got: %v", msgs)
+	}
+	for i, id := range []string{"a", "b"} {
+		want := fmt.Sprintf(`{"level":"ERROR","msg":"PaymentFailed","order_id":"%s"}`, id)
+		if msgs[i] != want {
+			t.Errorf("expected=%s got=%s", want, msgs[i])
+		}
+	}
+	if client.puts != 1 {
+		t.Errorf("expected one PutLogEvents call, got %d", client.puts)
+	}
+}
+
+func TestPutInterface(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	if err := Put[any](cw, OrderPlaced{OrderID: "o-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put[any](cw, &PaymentFailed{OrderID: "o-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put[any](cw, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Put[any](cw, (*PaymentFailed)(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"level":"INFO","msg":"OrderPlaced","region":"","order_id":"o-2","amount":0}`,
+		`{"level":"ERROR","msg":"PaymentFailed","order_id":"o-2"}`,
+		`{"level":"INFO","msg":""}`,
+		`{"level":"INFO","msg":"PaymentFailed"}`,
+	}
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Errorf("expected=%q got=%q", expected, msgs)
+	}
+}
@@ -0,0 +1,51 @@
+package cwlog
+
+import (
+	"runtime/debug"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// modulePath identifies this library in the User-Agent.
+const modulePath = "github.com/udhos/cloudwatchlog"
+
+// libraryVersion returns the module version linked into the binary,
+// or "devel" when unknown, like in its own tests.
+var libraryVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				return dep.Version
+			}
+		}
+	}
+	return "devel"
+})
+
+// userAgentOption appends "lib/cwlog#<version>" and, when defined,
+// "app/<AppName>#<AppVersion>" to the SDK User-Agent.
+func userAgentOption(options Options) func(*cloudwatchlogs.Options) {
+	return func(o *cloudwatchlogs.Options) {
+		o.APIOptions = append(o.APIOptions,
+			awsmiddleware.AddSDKAgentKeyValue(awsmiddleware.FrameworkMetadata, "cwlog", libraryVersion()))
+		switch {
+		case options.AppName == "":
+		case options.AppVersion == "":
+			o.APIOptions = append(o.APIOptions,
+				awsmiddleware.AddSDKAgentKey(awsmiddleware.ApplicationIdentifier, options.AppName))
+		default:
+			o.APIOptions = append(o.APIOptions,
+				awsmiddleware.AddSDKAgentKeyValue(awsmiddleware.ApplicationIdentifier,
+					options.AppName, options.AppVersion))
+		}
+	}
+}
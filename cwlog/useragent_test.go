@@ -0,0 +1,67 @@
+package cwlog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	table := []struct {
+		name     string
+		options  Options
+		expected []string
+		absent   string
+	}{
+		{"library only", Options{}, []string{"lib/cwlog#"}, "app/"},
+		{"app name", Options{AppName: "billing"}, []string{"lib/cwlog#", "app/billing"}, "app/billing#"},
+		{"app version", Options{AppName: "billing", AppVersion: "1.2.3"}, []string{"lib/cwlog#", "app/billing#1.2.3"}, ""},
+		{"version without name", Options{AppVersion: "1.2.3"}, []string{"lib/cwlog#"}, "app/"},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var agents []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				agents = append(agents, r.Header.Get("User-Agent"))
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.Write([]byte("{}"))
+			}))
+			defer srv.Close()
+
+			options := data.options
+			options.AwsConfig = awsTestConfig(srv.URL)
+			options.LogGroup = fmt.Sprintf("/cloudwatchlogs/useragent-%d", i)
+			cw, err := New(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cw.PutSimple("hello"); err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(agents) == 0 {
+				t.Fatal("no requests")
+			}
+			for _, agent := range agents {
+				for _, e := range data.expected {
+					if !strings.Contains(agent, e) {
+						t.Errorf("user agent missing %q: %s", e, agent)
+					}
+				}
+				if data.absent != "" && strings.Contains(agent, data.absent) {
+					t.Errorf("user agent has unexpected %q: %s", data.absent, agent)
+				}
+			}
+		})
+	}
+}
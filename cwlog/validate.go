@@ -0,0 +1,120 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// AWS limits for PutLogEvents.
+const (
+	// MaxBatchEvents is the maximum number of events in one PutLogEvents call.
+	MaxBatchEvents = 10000
+
+	// MaxBatchBytes is the maximum sum of EventSize for one PutLogEvents call.
+	MaxBatchBytes = 1048576
+
+	// MaxEventSize is the maximum EventSize of a single event.
+	MaxEventSize = 262144
+
+	// EventOverhead is added by AWS to the message length of every event.
+	EventOverhead = 26
+
+	// MaxEventAge is how far in the past an event timestamp may be.
+	MaxEventAge = 14 * 24 * time.Hour
+
+	// MaxEventFuture is how far in the future an event timestamp may be.
+	MaxEventFuture = 2 * time.Hour
+
+	// MaxBatchSpan is the maximum timestamp range of one PutLogEvents call.
+	MaxBatchSpan = 24 * time.Hour
+
+	// MaxNameLength is the maximum length of log group and stream names.
+	MaxNameLength = 512
+)
+
+// Errors reported by ValidateEvent.
+var (
+	ErrEmptyMessage     = errors.New("empty message")
+	ErrEventTooLarge    = errors.New("event too large")
+	ErrInvalidUTF8      = errors.New("invalid UTF-8 message")
+	ErrMissingTimestamp = errors.New("missing timestamp")
+	ErrTooOld           = errors.New("timestamp too old")
+	ErrTooNew           = errors.New("timestamp too new")
+)
+
+// Errors reported by ValidateGroupName and ValidateStreamName.
+var (
+	ErrInvalidGroupName  = errors.New("invalid log group name")
+	ErrInvalidStreamName = errors.New("invalid log stream name")
+)
+
+// ValidateGroupName checks name against the CloudWatch Logs rules:
+// 1 to 512 characters among a-z, A-Z, 0-9, '_', '-', '/', '.' and '#'.
+func ValidateGroupName(name string) error {
+	if name == "" || len(name) > MaxNameLength {
+		return fmt.Errorf("%w: %q: length must be 1 to %d", ErrInvalidGroupName, name, MaxNameLength)
+	}
+	if i := strings.IndexFunc(name, func(r rune) bool { return !validGroupRune(r) }); i >= 0 {
+		return fmt.Errorf("%w: %q: invalid character %q", ErrInvalidGroupName, name, name[i:i+1])
+	}
+	return nil
+}
+
+func validGroupRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		strings.ContainsRune("_-/.#", r)
+}
+
+// ValidateStreamName checks name against the CloudWatch Logs rules:
+// 1 to 512 characters, excluding ':' and '*'.
+func ValidateStreamName(name string) error {
+	if name == "" || len(name) > MaxNameLength {
+		return fmt.Errorf("%w: %q: length must be 1 to %d", ErrInvalidStreamName, name, MaxNameLength)
+	}
+	if i := strings.IndexAny(name, ":*"); i >= 0 {
+		return fmt.Errorf("%w: %q: invalid character %q", ErrInvalidStreamName, name, name[i:i+1])
+	}
+	return nil
+}
+
+// EventSize returns the size AWS accounts for the event:
+// message bytes plus EventOverhead.
+func EventSize(e types.InputLogEvent) int {
+	return len(aws.ToString(e.Message)) + EventOverhead
+}
+
+// ValidateEvent checks the event against the PutLogEvents constraints:
+// size, UTF-8 encoding and timestamp window.
+func ValidateEvent(e types.InputLogEvent) error {
+	return validateEvent(e, time.Now())
+}
+
+func validateEvent(e types.InputLogEvent, now time.Time) error {
+	msg := aws.ToString(e.Message)
+	if msg == "" {
+		return ErrEmptyMessage
+	}
+	if size := EventSize(e); size > MaxEventSize {
+		return fmt.Errorf("%w: size=%d max=%d", ErrEventTooLarge, size, MaxEventSize)
+	}
+	if !utf8.ValidString(msg) {
+		return ErrInvalidUTF8
+	}
+	if e.Timestamp == nil {
+		return ErrMissingTimestamp
+	}
+	ts := time.UnixMilli(*e.Timestamp)
+	if ts.Before(now.Add(-MaxEventAge)) {
+		return fmt.Errorf("%w: timestamp=%s", ErrTooOld, ts.UTC().Format(time.RFC3339))
+	}
+	if ts.After(now.Add(MaxEventFuture)) {
+		return fmt.Errorf("%w: timestamp=%s", ErrTooNew, ts.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
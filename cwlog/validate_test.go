@@ -0,0 +1,95 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type validateTest struct {
+	name     string
+	event    types.InputLogEvent
+	expected error
+}
+
+func TestValidateEvent(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ms := func(t time.Time) *int64 { return aws.Int64(t.UnixMilli()) }
+
+	table := []validateTest{
+		{"ok", types.InputLogEvent{Message: aws.String("hello"), Timestamp: ms(now)}, nil},
+		{"empty", types.InputLogEvent{Message: aws.String(""), Timestamp: ms(now)}, ErrEmptyMessage},
+		{"large", types.InputLogEvent{Message: aws.String(strings.Repeat("x", MaxEventSize)), Timestamp: ms(now)}, ErrEventTooLarge},
+		{"utf8", types.InputLogEvent{Message: aws.String("bad \xff"), Timestamp: ms(now)}, ErrInvalidUTF8},
+		{"no timestamp", types.InputLogEvent{Message: aws.String("hello")}, ErrMissingTimestamp},
+		{"old", types.InputLogEvent{Message: aws.String("hello"), Timestamp: ms(now.Add(-15 * 24 * time.Hour))}, ErrTooOld},
+		{"new", types.InputLogEvent{Message: aws.String("hello"), Timestamp: ms(now.Add(3 * time.Hour))}, ErrTooNew},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		err := validateEvent(data.event, now)
+		if data.expected == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, data.expected) {
+			t.Errorf("%s: expected=%v got=%v", name, data.expected, err)
+		}
+	}
+}
+
+func TestEventSize(t *testing.T) {
+	e := types.InputLogEvent{Message: aws.String("hello")}
+	if size := EventSize(e); size != 5+EventOverhead {
+		t.Fatalf("size: expected=%d got=%d", 5+EventOverhead, size)
+	}
+}
+
+func TestValidateNames(t *testing.T) {
+	table := []struct {
+		name     string
+		validate func(string) error
+		input    string
+		expected error
+	}{
+		{"group ok", ValidateGroupName, "/aws/lambda/my-func_1.#x", nil},
+		{"group empty", ValidateGroupName, "", ErrInvalidGroupName},
+		{"group long", ValidateGroupName, strings.Repeat("g", MaxNameLength+1), ErrInvalidGroupName},
+		{"group space", ValidateGroupName, "my group", ErrInvalidGroupName},
+		{"group colon", ValidateGroupName, "app:prod", ErrInvalidGroupName},
+		{"stream ok", ValidateStreamName, "host 1/app [x]", nil},
+		{"stream colon", ValidateStreamName, "i-123:app", ErrInvalidStreamName},
+		{"stream star", ValidateStreamName, "app*", ErrInvalidStreamName},
+		{"stream long", ValidateStreamName, strings.Repeat("s", MaxNameLength+1), ErrInvalidStreamName},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			if err := data.validate(data.input); !errors.Is(err, data.expected) {
+				t.Errorf("expected=%v got=%v", data.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewValidatesNames(t *testing.T) {
+	client := newCloudWatchLogMock()
+	if _, err := New(Options{Client: client, LogGroup: "bad group"}); !errors.Is(err, ErrInvalidGroupName) {
+		t.Errorf("group: expected ErrInvalidGroupName, got: %v", err)
+	}
+	if _, err := New(Options{Client: client, LogGroup: "group", LogStream: "host:1"}); !errors.Is(err, ErrInvalidStreamName) {
+		t.Errorf("stream: expected ErrInvalidStreamName, got: %v", err)
+	}
+	if len(client.groups) != 0 {
+		t.Errorf("groups created for invalid names: %v", client.groups)
+	}
+}
@@ -0,0 +1,109 @@
+package cwlog
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// With creates a lightweight child Log that injects fields into every
+// structured event, and prefixes plain messages with them as key=value
+// pairs. Fields override same-named fields bound by the parent.
+// The child shares the parent client, buffers and routes, hence Flush,
+// Close and Stats on either act on the same shared state.
+func (l *Log) With(fields map[string]any) *Log {
+	bound := slices.Clone(l.fields)
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		f := Field{Key: key, Value: fields[key]}
+		if i := slices.IndexFunc(bound, func(b Field) bool { return b.Key == key }); i >= 0 {
+			bound[i] = f
+			continue
+		}
+		bound = append(bound, f)
+	}
+	return &Log{
+		core:   l.core,
+		fields: bound,
+		prefix: renderPrefix(bound),
+	}
+}
+
+// structuredFields returns the fields for a structured event: global fields
+// not overridden, then fields bound by With, then the per-call extra fields.
+func (l *Log) structuredFields(extra []Field) []Field {
+	if len(l.globalFields) == 0 {
+		if len(l.fields) == 0 {
+			return extra
+		}
+		return append(l.fields[:len(l.fields):len(l.fields)], extra...)
+	}
+	fields := make([]Field, 0, len(l.globalFields)+len(l.fields)+len(extra))
+	for _, g := range l.globalFields {
+		if !hasField(l.fields, g.Key) && !hasField(extra, g.Key) {
+			fields = append(fields, g)
+		}
+	}
+	fields = append(fields, l.fields...)
+	return append(fields, extra...)
+}
+
+func hasField(fields []Field, key string) bool {
+	return slices.ContainsFunc(fields, func(f Field) bool { return f.Key == key })
+}
+
+// renderPrefix renders fields as "key=value " pairs for plain messages.
+func renderPrefix(fields []Field) string {
+	var sb strings.Builder
+	for _, f := range fields {
+		sb.WriteString(f.Key)
+		sb.WriteByte('=')
+		v := fmt.Sprint(f.Value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = strconv.Quote(v)
+		}
+		sb.WriteString(v)
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// prefixEvents returns a copy of events with prefixed messages.
+func prefixEvents(prefix string, events []types.InputLogEvent) []types.InputLogEvent {
+	result := make([]types.InputLogEvent, len(events))
+	for i, e := range events {
+		result[i] = e
+		msg := prefix
+		if e.Message != nil {
+			msg += *e.Message
+		}
+		result[i].Message = &msg
+	}
+	return result
+}
+
+// appendFields splices fields into the JSON object data.
+func appendFields(data []byte, fields []Field) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return data, nil // not an object, leave it alone
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, f := range fields {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(&buf, f.Key)
+		buf.WriteByte(':')
+		if err := writeJSONValue(&buf, f.Value); err != nil {
+			return nil, fmt.Errorf("json encode error: field=%s: %w", f.Key, err)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
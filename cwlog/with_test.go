@@ -0,0 +1,95 @@
+package cwlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWith(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	child := cw.With(map[string]any{"tenant": "acme", "request": 7})
+	grandchild := child.With(map[string]any{"request": 8, "user": "alice smith"})
+
+	if err := child.Info("hello", "count", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := grandchild.PutSimple("plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := child.PutError(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("parent"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	expected := []string{
+		`{"level":"INFO","msg":"hello","request":7,"tenant":"acme","count":1}`,
+		`request=8 tenant=acme user="alice smith" plain`,
+		`{"error":"boom","type":"*errors.errorString","request":7,"tenant":"acme"}`,
+		`parent`,
+	}
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%q got=%q", expected, msgs)
+	}
+}
+
+func TestWithLines(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw := newTestLog(t, client)
+
+	child := cw.With(map[string]any{"job": 1})
+	if err := child.PutLines([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]) {
+		if !strings.HasPrefix(msg, "job=1 ") {
+			t.Errorf("missing prefix: %q", msg)
+		}
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:       client,
+		Now:          func() time.Time { return time.Time{} },
+		LogGroup:     "/cloudwatchlogs/group",
+		LogStream:    "/cloudwatchlogs/stream",
+		GlobalFields: map[string]string{"service": "api", "env": "prod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := cw.With(map[string]any{"env": "staging"})
+	if err := cw.Info("hello", "service", "override"); err != nil {
+		t.Fatal(err)
+	}
+	if err := child.Warn("careful"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutError(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+	expected := []string{
+		`{"level":"INFO","msg":"hello","env":"prod","service":"override"}`,
+		`{"level":"WARN","msg":"careful","service":"api","env":"staging"}`,
+		`{"error":"boom","type":"*errors.errorString","env":"prod","service":"api"}`,
+		`plain`,
+	}
+	if fmt.Sprint(msgs) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%q got=%q", expected, msgs)
+	}
+}
@@ -0,0 +1,191 @@
+package cwlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// notFoundClient fails the operation named by fail with
+// ResourceNotFoundException.
+type notFoundClient struct {
+	*cloudWatchLogMock
+	fail string
+}
+
+func (c *notFoundClient) check(op string) error {
+	if c.fail == op {
+		return &types.ResourceNotFoundException{Message: aws.String(op + " not found")}
+	}
+	return nil
+}
+
+func (c *notFoundClient) CreateLogGroup(ctx context.Context,
+	params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if err := c.check("CreateLogGroup"); err != nil {
+		return nil, err
+	}
+	return c.cloudWatchLogMock.CreateLogGroup(ctx, params, optFns...)
+}
+
+func (c *notFoundClient) PutRetentionPolicy(ctx context.Context,
+	params *cloudwatchlogs.PutRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	if err := c.check("PutRetentionPolicy"); err != nil {
+		return nil, err
+	}
+	return c.cloudWatchLogMock.PutRetentionPolicy(ctx, params, optFns...)
+}
+
+func (c *notFoundClient) CreateLogStream(ctx context.Context,
+	params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	if err := c.check("CreateLogStream"); err != nil {
+		return nil, err
+	}
+	return c.cloudWatchLogMock.CreateLogStream(ctx, params, optFns...)
+}
+
+func (c *notFoundClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if err := c.check("PutLogEvents"); err != nil {
+		return nil, err
+	}
+	return c.cloudWatchLogMock.PutLogEvents(ctx, params, optFns...)
+}
+
+func (c *notFoundClient) DescribeLogGroups(_ context.Context,
+	_ *cloudwatchlogs.DescribeLogGroupsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &cloudwatchlogs.DescribeLogGroupsOutput{}, c.check("DescribeLogGroups")
+}
+
+func (c *notFoundClient) PutMetricFilter(_ context.Context,
+	_ *cloudwatchlogs.PutMetricFilterInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutMetricFilterOutput, error) {
+	return &cloudwatchlogs.PutMetricFilterOutput{}, c.check("PutMetricFilter")
+}
+
+func (c *notFoundClient) PutAccountPolicy(_ context.Context,
+	_ *cloudwatchlogs.PutAccountPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutAccountPolicyOutput, error) {
+	return &cloudwatchlogs.PutAccountPolicyOutput{}, c.check("PutAccountPolicy")
+}
+
+func (c *notFoundClient) DescribeAccountPolicies(_ context.Context,
+	_ *cloudwatchlogs.DescribeAccountPoliciesInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeAccountPoliciesOutput, error) {
+	return &cloudwatchlogs.DescribeAccountPoliciesOutput{}, c.check("DescribeAccountPolicies")
+}
+
+func (c *notFoundClient) DeleteAccountPolicy(_ context.Context,
+	_ *cloudwatchlogs.DeleteAccountPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteAccountPolicyOutput, error) {
+	return &cloudwatchlogs.DeleteAccountPolicyOutput{}, c.check("DeleteAccountPolicy")
+}
+
+func (c *notFoundClient) StartQuery(_ context.Context,
+	_ *cloudwatchlogs.StartQueryInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	return &cloudwatchlogs.StartQueryOutput{}, c.check("StartQuery")
+}
+
+func (c *notFoundClient) GetQueryResults(_ context.Context,
+	_ *cloudwatchlogs.GetQueryResultsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	return &cloudwatchlogs.GetQueryResultsOutput{Status: types.QueryStatusComplete}, nil
+}
+
+func (c *notFoundClient) StopQuery(_ context.Context,
+	_ *cloudwatchlogs.StopQueryInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	return &cloudwatchlogs.StopQueryOutput{}, nil
+}
+
+func newWrapLog(c *notFoundClient) (*Log, error) {
+	return New(Options{
+		Client:    c,
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+}
+
+// putWith creates a Log, then sends one event.
+func putWith(c *notFoundClient) error {
+	cw, err := newWrapLog(c)
+	if err != nil {
+		return err
+	}
+	return cw.PutSimple("a")
+}
+
+type wrapTestCase struct {
+	fail string
+	run  func(c *notFoundClient) error
+}
+
+var wrapTestTable = []wrapTestCase{
+	{"CreateLogGroup", func(c *notFoundClient) error { _, err := newWrapLog(c); return err }},
+	{"PutRetentionPolicy", func(c *notFoundClient) error { _, err := newWrapLog(c); return err }},
+	{"CreateLogStream", putWith},
+	{"PutLogEvents", putWith},
+	{"CreateLogStream", func(c *notFoundClient) error {
+		return EnsureStream(context.TODO(), c, "/cloudwatchlogs/group", "stream")
+	}},
+	{"DescribeLogGroups", func(c *notFoundClient) error {
+		cw, err := newWrapLog(c)
+		if err != nil {
+			return err
+		}
+		_, err = cw.GroupARN(context.TODO())
+		return err
+	}},
+	{"PutMetricFilter", func(c *notFoundClient) error {
+		cw, err := newWrapLog(c)
+		if err != nil {
+			return err
+		}
+		return cw.ErrorAlarm(context.TODO(), &alarmMock{}, ErrorAlarmOptions{})
+	}},
+	{"PutAccountPolicy", func(c *notFoundClient) error {
+		return PutAccountPolicy(context.TODO(), c, AccountPolicy{Name: "p"})
+	}},
+	{"DescribeAccountPolicies", func(c *notFoundClient) error {
+		_, err := ListAccountPolicies(context.TODO(), c, types.PolicyTypeDataProtectionPolicy)
+		return err
+	}},
+	{"DeleteAccountPolicy", func(c *notFoundClient) error {
+		return DeleteAccountPolicy(context.TODO(), c, "p", types.PolicyTypeDataProtectionPolicy)
+	}},
+	{"StartQuery", func(c *notFoundClient) error {
+		_, err := RunQuery(context.TODO(), c, QueryOptions{
+			LogGroups: []string{"/cloudwatchlogs/group"}, Query: "fields @message"})
+		return err
+	}},
+}
+
+// TestWrappedErrors verifies AWS errors are wrapped with %w,
+// so callers can match them with errors.As.
+func TestWrappedErrors(t *testing.T) {
+	for i, data := range wrapTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(wrapTestTable), data.fail)
+		t.Run(name, func(t *testing.T) {
+			client := &notFoundClient{cloudWatchLogMock: newCloudWatchLogMock(), fail: data.fail}
+			err := data.run(client)
+			var errNotFound *types.ResourceNotFoundException
+			if !errors.As(err, &errNotFound) {
+				t.Errorf("expected ResourceNotFoundException in chain, got: %v", err)
+			}
+			if Classify(err) != ClassNotFound {
+				t.Errorf("expected ClassNotFound, got: %s", Classify(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cwlog
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// maxMessageBytes is the largest message fitting in MaxEventSize.
+const maxMessageBytes = MaxEventSize - EventOverhead
+
+// WriterOptions define settings for Writer.
+type WriterOptions struct {
+	// Multiline optionally enables multiline mode, joining continuation
+	// lines (for example Java or Go stack traces) into a single event.
+	// A line matching Multiline starts a new record, any other line is
+	// appended to the current record.
+	// Examples: `^\d{4}-\d{2}-\d{2}` or `^[^\s]`.
+	Multiline *regexp.Regexp
+
+	// MultilineTimeout sends the pending multiline record once no line
+	// was written for between one and two timeouts, so that the last
+	// record of a quiet source is not held until Close. The ticker is
+	// created from the Log Clock. Defaults to 1s. Negative disables it,
+	// leaving the record to the next record start, Flush and Close.
+	MultilineTimeout time.Duration
+
+	// Severity optionally sends every line or record as a structured
+	// event with the level found by Severity, like KeywordSeverity,
+	// see Log.PutLogEventsSeverity.
+	Severity SeverityParser
+}
+
+// Writer adapts Log to io.Writer, sending one event per line,
+// or one event per record in multiline mode.
+// It can be used with log.SetOutput or as os/exec Stdout.
+type Writer struct {
+	log     *Log
+	options WriterOptions
+
+	mu      sync.Mutex
+	partial []byte // incomplete line
+	lines   multiline
+	timer   *multilineTimer // nil unless multiline mode
+}
+
+// NewWriter creates an io.Writer adapter sending to Log.
+// In multiline mode, Close must be called to stop the MultilineTimeout
+// ticker.
+func NewWriter(l *Log, options WriterOptions) *Writer {
+	w := &Writer{log: l, options: options}
+	if options.Multiline != nil {
+		w.lines.start = options.Multiline
+		w.timer = startMultilineTimer(l.options.Clock, options.MultilineTimeout, w.expire)
+	}
+	return w
+}
+
+// expire sends the pending multiline record of a quiet source.
+func (w *Writer) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := w.lines.expire(nil)
+	if len(events) == 0 {
+		return
+	}
+	if err := w.put(events); err != nil {
+		w.log.warn("multiline record send failed", "group", w.log.options.LogGroup,
+			"error", err)
+	}
+}
+
+// Write splits p into lines and sends complete lines or records.
+// Incomplete trailing data is kept until the next Write or Flush.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []types.InputLogEvent
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, data...)
+			for len(w.partial) > maxMessageBytes {
+				// line too long, send what fits in one event
+				cut := RuneCut(w.partial, maxMessageBytes)
+				events = w.addChunk(events, w.partial[:cut])
+				w.partial = append(w.partial[:0], w.partial[cut:]...)
+			}
+			break
+		}
+		line := data[:i]
+		if len(w.partial) > 0 {
+			w.partial = append(w.partial, line...)
+			line = w.partial
+		}
+		events = w.addLine(events, line)
+		w.partial = w.partial[:0]
+		data = data[i+1:]
+	}
+
+	if len(events) == 0 {
+		return len(p), nil
+	}
+	return len(p), w.put(events)
+}
+
+// addLine handles one complete line, returning events ready to send.
+// Lines larger than the maximum event size are split.
+func (w *Writer) addLine(events []types.InputLogEvent, line []byte) []types.InputLogEvent {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	for len(line) > maxMessageBytes {
+		cut := RuneCut(line, maxMessageBytes)
+		events = w.addChunk(events, line[:cut])
+		line = line[cut:]
+	}
+	return w.addChunk(events, line)
+}
+
+// addChunk handles a line fitting in one event.
+func (w *Writer) addChunk(events []types.InputLogEvent, line []byte) []types.InputLogEvent {
+	now := w.log.options.Now().UnixMilli()
+
+	if w.options.Multiline == nil {
+		if len(line) == 0 {
+			return events // empty messages are rejected by AWS
+		}
+		return append(events, newEvent(string(line), now))
+	}
+
+	return w.lines.add(events, "", line, now)
+}
+
+// Flush sends any incomplete line and the pending multiline record.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []types.InputLogEvent
+	if len(w.partial) > 0 {
+		events = w.addLine(events, w.partial)
+		w.partial = w.partial[:0]
+	}
+	events = w.lines.take(events)
+
+	if len(events) == 0 {
+		return nil
+	}
+	return w.put(events)
+}
+
+func (w *Writer) put(events []types.InputLogEvent) error {
+	if w.options.Severity != nil {
+		return w.log.PutLogEventsSeverity(events, w.options.Severity)
+	}
+	return w.log.PutLogEvents(events)
+}
+
+// Close flushes pending data and stops the MultilineTimeout ticker.
+// It does not close the underlying Log.
+func (w *Writer) Close() error {
+	w.timer.stop()
+	return w.Flush()
+}
+
+func newEvent(msg string, timestamp int64) types.InputLogEvent {
+	return types.InputLogEvent{
+		Message:   aws.String(msg),
+		Timestamp: aws.Int64(timestamp),
+	}
+}
+
+// tee mirrors the messages of events to Options.TeeWriter.
+func (l *Log) tee(events []types.InputLogEvent) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	for _, e := range events {
+		buf.WriteString(aws.ToString(e.Message))
+		buf.WriteByte('\n')
+	}
+	l.localMu.Lock()
+	defer l.localMu.Unlock()
+	l.options.TeeWriter.Write(buf.Bytes()) // best effort
+}
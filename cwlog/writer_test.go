@@ -0,0 +1,193 @@
+package cwlog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type writerTest struct {
+	name      string
+	multiline string
+	writes    []string
+	expected  []string
+}
+
+var writerTestTable = []writerTest{
+	{
+		name:     "lines",
+		writes:   []string{"line 1\nline 2\n"},
+		expected: []string{"line 1", "line 2"},
+	},
+	{
+		name:     "partial writes",
+		writes:   []string{"li", "ne 1\r\nli", "ne 2"},
+		expected: []string{"line 1", "line 2"},
+	},
+	{
+		name:     "empty lines",
+		writes:   []string{"\n\nline 1\n\n"},
+		expected: []string{"line 1"},
+	},
+	{
+		name:      "go panic",
+		multiline: `^[^\s]`,
+		writes: []string{
+			"panic: boom\n",
+			"\n",
+			"goroutine 1 [running]:\n",
+			"main.main()\n\t/tmp/main.go:5 +0x25\n",
+			"after\n",
+		},
+		expected: []string{
+			"panic: boom",
+			"goroutine 1 [running]:",
+			"main.main()\n\t/tmp/main.go:5 +0x25",
+			"after",
+		},
+	},
+	{
+		name:      "java trace",
+		multiline: `^\d{4}-\d{2}-\d{2}`,
+		writes: []string{
+			"2024-01-01 ERROR failed\n",
+			"java.lang.Exception: x\n",
+			"    at Main.main(Main.java:1)\n",
+			"2024-01-01 INFO ok\n",
+		},
+		expected: []string{
+			"2024-01-01 ERROR failed\njava.lang.Exception: x\n    at Main.main(Main.java:1)",
+			"2024-01-01 INFO ok",
+		},
+	},
+	{
+		name:     "long line",
+		writes:   []string{strings.Repeat("a", 400000) + "\n"},
+		expected: []string{strings.Repeat("a", maxMessageBytes), strings.Repeat("a", 400000-maxMessageBytes)},
+	},
+	{
+		name:     "long partial writes",
+		writes:   []string{strings.Repeat("a", 200000), strings.Repeat("a", 200000), "b\n"},
+		expected: []string{strings.Repeat("a", maxMessageBytes), strings.Repeat("a", 400000-maxMessageBytes) + "b"},
+	},
+	{
+		name:      "long multiline record",
+		multiline: `^[^\s]`,
+		writes:    []string{"start\n", " " + strings.Repeat("a", 300000) + "\n"},
+		expected:  []string{"start", " " + strings.Repeat("a", maxMessageBytes-1), strings.Repeat("a", 300001-maxMessageBytes)},
+	},
+}
+
+func TestWriter(t *testing.T) {
+	for i, data := range writerTestTable {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(writerTestTable), data.name)
+
+		client := newCloudWatchLogMock()
+		cw, err := New(Options{
+			Client:    client,
+			Now:       func() time.Time { return time.Time{} },
+			LogGroup:  "/cloudwatchlogs/group",
+			LogStream: "/cloudwatchlogs/stream",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var options WriterOptions
+		if data.multiline != "" {
+			options.Multiline = regexp.MustCompile(data.multiline)
+		}
+		w := NewWriter(cw, options)
+
+		for _, s := range data.writes {
+			if _, err := w.Write([]byte(s)); err != nil {
+				t.Fatalf("%s: write: %v", name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: close: %v", name, err)
+		}
+
+		got := messages(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"])
+		if fmt.Sprint(got) != fmt.Sprint(data.expected) {
+			t.Errorf("%s: expected=%q got=%q", name, data.expected, got)
+		}
+	}
+}
+
+func messages(events []types.InputLogEvent) []string {
+	var list []string
+	for _, e := range events {
+		list = append(list, aws.ToString(e.Message))
+	}
+	return list
+}
+
+func TestTeeWriter(t *testing.T) {
+	var tee bytes.Buffer
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:    client,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+		TeeWriter: &tee,
+		DropIf:    func(e Event) bool { return e.Message == "dropped" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("simple"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutLines([]string{"line 1", "dropped", "line 2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Info("structured"); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "simple\nline 1\nline 2\n{\"level\":\"INFO\",\"msg\":\"structured\"}\n"
+	if tee.String() != expected {
+		t.Errorf("expected=%q got=%q", expected, tee.String())
+	}
+	if n := len(client.groups["/cloudwatchlogs/group"]["/cloudwatchlogs/stream-0001-01-01-00"]); n != 4 {
+		t.Errorf("sent events: expected=4 got=%d", n)
+	}
+}
+
+func TestTeeWriterRouting(t *testing.T) {
+	var tee bytes.Buffer
+	client := newCloudWatchLogMock()
+	cw, err := New(Options{
+		Client:       client,
+		Now:          func() time.Time { return time.Time{} },
+		LogGroup:     "/cloudwatchlogs/group",
+		LogStream:    "/cloudwatchlogs/stream",
+		TeeWriter:    &tee,
+		LevelRouting: []Route{{MinLevel: LevelError, LogGroup: "/cloudwatchlogs/errors"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Info("ok"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Error("failed"); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "{\"level\":\"INFO\",\"msg\":\"ok\"}\n{\"level\":\"ERROR\",\"msg\":\"failed\"}\n"
+	if tee.String() != expected {
+		t.Errorf("expected=%q got=%q", expected, tee.String())
+	}
+	if n := len(client.groups["/cloudwatchlogs/errors"]["/cloudwatchlogs/stream-0001-01-01-00"]); n != 1 {
+		t.Errorf("routed events: expected=1 got=%d", n)
+	}
+}
@@ -0,0 +1,297 @@
+// Package cwlogfluent implements a receiver for the Fluentd/Fluent Bit
+// forward protocol, forwarding records to CloudWatch Logs through cwlog,
+// so existing fluent agents can point at a Go service built on cwlog.
+// The optional shared key handshake is not supported.
+package cwlogfluent
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Options define settings for Server.
+type Options struct {
+	// Log is required. Enable cwlog.Options.Async to batch records.
+	Log *cwlog.Log
+
+	// StreamByTag sends each tag to its own stream, named after the tag,
+	// using the stream template of Log.
+	StreamByTag bool
+
+	// Resolve optionally maps a tag to its destination, for example a Log
+	// for another group. It takes precedence over StreamByTag.
+	// Results are cached per tag.
+	Resolve func(tag string) (*cwlog.Log, error)
+}
+
+// Server receives forward protocol messages over TCP.
+type Server struct {
+	options Options
+	mu      sync.Mutex
+	tags    map[string]*cwlog.Log
+}
+
+// New creates a forward protocol server.
+func New(options Options) (*Server, error) {
+	if options.Log == nil {
+		return nil, errors.New("Log is required")
+	}
+	return &Server{options: options, tags: map[string]*cwlog.Log{}}, nil
+}
+
+// Serve accepts connections until ln is closed.
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	dec := msgpack.NewDecoder(bufio.NewReader(conn))
+	for {
+		msg, err := dec.DecodeSlice()
+		if err != nil {
+			return
+		}
+		chunk, errHandle := s.handle(msg)
+		if errHandle != nil {
+			return
+		}
+		if chunk != "" {
+			ack, _ := msgpack.Marshal(map[string]string{"ack": chunk})
+			if _, err := conn.Write(ack); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle forwards one message in Message, Forward, PackedForward or
+// CompressedPackedForward mode, returning the chunk to acknowledge.
+func (s *Server) handle(msg []any) (string, error) {
+	if len(msg) < 2 {
+		return "", errors.New("short forward message")
+	}
+	tag, ok := msg[0].(string)
+	if !ok {
+		return "", errors.New("bad forward tag")
+	}
+	option := messageOption(msg)
+
+	var events []types.InputLogEvent
+	var err error
+	switch entries := msg[1].(type) {
+	case []any: // Forward
+		events, err = forwardEvents(entries)
+	case string: // PackedForward
+		events, err = packedEvents([]byte(entries), option["compressed"] == "gzip")
+	case []byte:
+		events, err = packedEvents(entries, option["compressed"] == "gzip")
+	default: // Message
+		if len(msg) < 3 {
+			return "", errors.New("short forward message")
+		}
+		var e types.InputLogEvent
+		e, err = newEvent(msg[1], msg[2])
+		events = []types.InputLogEvent{e}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	l, errLog := s.logFor(tag)
+	if errLog != nil {
+		return "", errLog
+	}
+	if err := l.PutLogEvents(events); err != nil {
+		return "", err
+	}
+	chunk, _ := option["chunk"].(string)
+	return chunk, nil
+}
+
+// messageOption returns the trailing option map, if any.
+func messageOption(msg []any) map[string]any {
+	last := len(msg) - 1
+	if last < 2 {
+		return nil
+	}
+	option, _ := msg[last].(map[string]any)
+	return option
+}
+
+func forwardEvents(entries []any) ([]types.InputLogEvent, error) {
+	events := make([]types.InputLogEvent, 0, len(entries))
+	for _, entry := range entries {
+		pair, ok := entry.([]any)
+		if !ok || len(pair) < 2 {
+			return nil, errors.New("bad forward entry")
+		}
+		e, err := newEvent(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func packedEvents(data []byte, compressed bool) ([]types.InputLogEvent, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	}
+	dec := msgpack.NewDecoder(r)
+	var entries []any
+	for {
+		entry, err := dec.DecodeSlice()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return forwardEvents(entries)
+}
+
+// newEvent converts a forward entry time and record into an event
+// holding the record as JSON.
+func newEvent(t, record any) (types.InputLogEvent, error) {
+	var ts time.Time
+	switch v := t.(type) {
+	case *EventTime:
+		ts = v.Time
+	default:
+		sec, ok := toInt64(v)
+		if !ok {
+			return types.InputLogEvent{}, fmt.Errorf("bad forward time: %T", t)
+		}
+		ts = time.Unix(sec, 0)
+	}
+	data, err := json.Marshal(jsonValue(record))
+	if err != nil {
+		return types.InputLogEvent{}, err
+	}
+	return types.InputLogEvent{
+		Message:   aws.String(string(data)),
+		Timestamp: aws.Int64(ts.UnixMilli()),
+	}, nil
+}
+
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// jsonValue converts binary values, which fluent agents often use for
+// strings, so they are not encoded as base64.
+func jsonValue(v any) any {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case map[string]any:
+		for k, e := range x {
+			x[k] = jsonValue(e)
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = jsonValue(e)
+		}
+	}
+	return v
+}
+
+// logFor returns the destination for tag.
+func (s *Server) logFor(tag string) (*cwlog.Log, error) {
+	if s.options.Resolve == nil && !s.options.StreamByTag {
+		return s.options.Log, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, found := s.tags[tag]; found {
+		return l, nil
+	}
+	var l *cwlog.Log
+	var err error
+	if s.options.Resolve != nil {
+		l, err = s.options.Resolve(tag)
+	} else {
+		l, err = s.options.Log.Clone(cwlog.Options{LogStream: tag})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("forward tag error: %s: %w", tag, err)
+	}
+	s.tags[tag] = l
+	return l, nil
+}
+
+// EventTime is the forward protocol nanosecond timestamp, msgpack ext type 0.
+type EventTime struct {
+	time.Time
+}
+
+func init() {
+	msgpack.RegisterExt(0, (*EventTime)(nil))
+}
+
+// MarshalMsgpack encodes seconds and nanoseconds as big-endian uint32.
+func (t *EventTime) MarshalMsgpack() ([]byte, error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()))
+	binary.BigEndian.PutUint32(b[4:], uint32(t.Nanosecond()))
+	return b, nil
+}
+
+// UnmarshalMsgpack decodes seconds and nanoseconds as big-endian uint32.
+func (t *EventTime) UnmarshalMsgpack(b []byte) error {
+	if len(b) != 8 {
+		return fmt.Errorf("bad EventTime length: %d", len(b))
+	}
+	t.Time = time.Unix(int64(binary.BigEndian.Uint32(b)), int64(binary.BigEndian.Uint32(b[4:])))
+	return nil
+}
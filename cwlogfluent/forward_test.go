@@ -0,0 +1,85 @@
+package cwlogfluent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestServer(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/fluent",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, errNew := New(Options{Log: l, StreamByTag: true})
+	if errNew != nil {
+		t.Fatal(errNew)
+	}
+
+	ln, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatal(errListen)
+	}
+	defer ln.Close()
+	go s.Serve(ln)
+
+	conn, errDial := net.Dial("tcp", ln.Addr().String())
+	if errDial != nil {
+		t.Fatal(errDial)
+	}
+	defer conn.Close()
+
+	et := &EventTime{time.Unix(1717193655, 5000000)}
+	record := map[string]any{"log": []byte("hello")}
+
+	// packed entries, gzip compressed
+	var packed bytes.Buffer
+	gz := gzip.NewWriter(&packed)
+	enc := msgpack.NewEncoder(gz)
+	enc.Encode([]any{1717193656, map[string]any{"n": 3}})
+	gz.Close()
+
+	for _, msg := range [][]any{
+		{"app.web", et, record}, // Message
+		{"app.web", []any{[]any{et, map[string]any{"n": 1}}, []any{1717193655, map[string]any{"n": 2}}}}, // Forward
+		{"app.db", packed.Bytes(), map[string]any{"compressed": "gzip", "chunk": "abc"}},                 // CompressedPackedForward
+	} {
+		data, err := msgpack.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var ack map[string]string
+	if err := msgpack.NewDecoder(conn).Decode(&ack); err != nil {
+		t.Fatal(err)
+	}
+	if ack["ack"] != "abc" {
+		t.Errorf("ack: %v", ack)
+	}
+
+	for stream, expected := range map[string][]string{
+		"app.web-0001-01-01-00": {`{"log":"hello"}`, `{"n":1}`, `{"n":2}`},
+		"app.db-0001-01-01-00":  {`{"n":3}`},
+	} {
+		if msgs := client.StreamMessages("/fluent", stream); !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("stream %s: expected=%v got=%v", stream, expected, msgs)
+		}
+	}
+}
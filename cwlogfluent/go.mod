@@ -0,0 +1,21 @@
+module github.com/udhos/cloudwatchlog/cwlogfluent
+
+go 1.25.9 // minimum
+
+toolchain go1.26.2 // preferred
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.41.6
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.69.1
+	github.com/udhos/cloudwatchlog v0.0.0-20261016184442-114805276ca4
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.7.9 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.4.22 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.7.22 // indirect
+	github.com/aws/aws-sdk-go-v2/service/cloudwatch v1.56.2 // indirect
+	github.com/aws/smithy-go v1.25.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+)
@@ -0,0 +1,79 @@
+// Package cwloggin provides a Gin middleware logging to CloudWatch Logs through cwlog.
+package cwloggin
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Options define settings for Middleware.
+type Options struct {
+	// SkipPaths lists request paths never logged, like health checks.
+	SkipPaths []string
+
+	// RequestIDHeader is the header holding the request ID.
+	// Defaults to "X-Request-Id".
+	RequestIDHeader string
+}
+
+// Middleware creates a Gin middleware logging one structured event per
+// request into l, including the route template and handler name.
+// Panics in later handlers are recovered, logged as ERROR with the stack,
+// and answered with status 500.
+// Status 500 and above is logged as ERROR, 400 and above as WARN,
+// other requests as INFO.
+func Middleware(l *cwlog.Log, options Options) gin.HandlerFunc {
+	if options.RequestIDHeader == "" {
+		options.RequestIDHeader = "X-Request-Id"
+	}
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if slices.Contains(options.SkipPaths, path) {
+			c.Next()
+			return
+		}
+		begin := time.Now()
+		defer func() {
+			if v := recover(); v != nil {
+				l.Error("panic recovered",
+					"panic", fmt.Sprint(v),
+					"stack", string(debug.Stack()),
+					"method", c.Request.Method,
+					"path", path,
+					"route", c.FullPath(),
+					"handler", c.HandlerName(),
+				)
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+			status := c.Writer.Status()
+			l.PutLevel(statusLevel(status), "http request",
+				"method", c.Request.Method,
+				"path", path,
+				"route", c.FullPath(),
+				"handler", c.HandlerName(),
+				"status", status,
+				"latency_ms", time.Since(begin).Milliseconds(),
+				"bytes", max(c.Writer.Size(), 0),
+				"remote_ip", c.ClientIP(),
+				"request_id", c.GetHeader(options.RequestIDHeader),
+			)
+		}()
+		c.Next()
+	}
+}
+
+func statusLevel(status int) cwlog.Level {
+	switch {
+	case status >= 500:
+		return cwlog.LevelError
+	case status >= 400:
+		return cwlog.LevelWarn
+	}
+	return cwlog.LevelInfo
+}
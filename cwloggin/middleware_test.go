@@ -0,0 +1,67 @@
+package cwloggin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+func hello(c *gin.Context) {
+	c.String(http.StatusOK, "hello")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(Middleware(l, Options{SkipPaths: []string{"/health"}}))
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/user/:id", hello)
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/health", "/user/42", "/panic"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if path == "/panic" && w.Code != http.StatusInternalServerError {
+			t.Errorf("panic status: expected=500 got=%d", w.Code)
+		}
+	}
+
+	msgs := client.Messages("/app")
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 events, got: %v", msgs)
+	}
+	for _, want := range []string{
+		`"level":"INFO"`, `"path":"/user/42"`, `"route":"/user/:id"`,
+		`"handler":"github.com/udhos/cloudwatchlog/cwloggin.hello"`,
+		`"status":200`, `"bytes":5`,
+	} {
+		if !strings.Contains(msgs[0], want) {
+			t.Errorf("request event missing %s: %s", want, msgs[0])
+		}
+	}
+	for _, want := range []string{`"level":"ERROR"`, `"msg":"panic recovered"`, `"panic":"boom"`, `"stack":`} {
+		if !strings.Contains(msgs[1], want) {
+			t.Errorf("panic event missing %s: %s", want, msgs[1])
+		}
+	}
+	if !strings.Contains(msgs[2], `"status":500`) {
+		t.Errorf("panic request event: %s", msgs[2])
+	}
+}
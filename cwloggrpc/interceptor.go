@@ -0,0 +1,116 @@
+// Package cwloggrpc provides gRPC server interceptors logging to CloudWatch Logs through cwlog.
+package cwloggrpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Options define settings for the interceptors.
+type Options struct {
+	// SampleRate is the fraction, from 0 (none) to 1, of calls logged
+	// when they do not fail with a server error, like aws.Float64(0.1).
+	// Server errors are always logged. If nil, every call is logged.
+	SampleRate *float64
+
+	// MethodSampleRates overrides SampleRate per full method name,
+	// like "/helloworld.Greeter/SayHello". A rate of 0 logs no call of
+	// that method, except server errors.
+	MethodSampleRates map[string]float64
+
+	// ExcludeMethods lists full method names never logged, like health checks.
+	ExcludeMethods []string
+}
+
+// UnaryServerInterceptor logs one structured event per unary call into l,
+// with method, status code, latency and peer address.
+func UnaryServerInterceptor(l *cwlog.Log, options Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		if slices.Contains(options.ExcludeMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		begin := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, l, options, info.FullMethod, "unary", begin, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs one structured event per streaming call
+// into l, when the stream ends, like UnaryServerInterceptor.
+func StreamServerInterceptor(l *cwlog.Log, options Options) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if slices.Contains(options.ExcludeMethods, info.FullMethod) {
+			return handler(srv, ss)
+		}
+		begin := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), l, options, info.FullMethod, "stream", begin, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, l *cwlog.Log, options Options, method, kind string,
+	begin time.Time, err error) {
+
+	code := status.Code(err)
+	level := codeLevel(code)
+
+	rate := 1.0
+	if options.SampleRate != nil {
+		rate = *options.SampleRate
+	}
+	if r, found := options.MethodSampleRates[method]; found {
+		rate = r
+	}
+	if level < cwlog.LevelError && !sampled(rate) {
+		return
+	}
+
+	keyvals := []any{
+		"method", method,
+		"kind", kind,
+		"code", code.String(),
+		"latency_ms", time.Since(begin).Milliseconds(),
+		"peer", peerAddr(ctx),
+	}
+	if err != nil {
+		keyvals = append(keyvals, "error", status.Convert(err).Message())
+	}
+	l.PutLevel(level, "grpc call", keyvals...)
+}
+
+// sampled reports whether to log a call at rate, never for 0.
+func sampled(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// codeLevel logs client errors as WARN and server errors as ERROR.
+func codeLevel(code codes.Code) cwlog.Level {
+	switch code {
+	case codes.OK:
+		return cwlog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		return cwlog.LevelWarn
+	}
+	return cwlog.LevelError
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package cwloggrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLog(t *testing.T) (*cwlog.Log, *cwlogtest.Client) {
+	t.Helper()
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, client
+}
+
+func rate(v float64) *float64 { return &v }
+
+func peerContext() context.Context {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	table := []struct {
+		name     string
+		options  Options
+		err      error
+		expected string
+	}{
+		{"ok", Options{}, nil,
+			`{"level":"INFO","msg":"grpc call","method":"/svc/Get","kind":"unary","code":"OK","latency_ms":0,"peer":"10.0.0.1:1234"}`},
+		{"client error", Options{}, status.Error(codes.NotFound, "missing"),
+			`{"level":"WARN","msg":"grpc call","method":"/svc/Get","kind":"unary","code":"NotFound","latency_ms":0,"peer":"10.0.0.1:1234","error":"missing"}`},
+		{"server error", Options{SampleRate: rate(0)}, status.Error(codes.Internal, "boom"),
+			`{"level":"ERROR","msg":"grpc call","method":"/svc/Get","kind":"unary","code":"Internal","latency_ms":0,"peer":"10.0.0.1:1234","error":"boom"}`},
+		{"sampled out", Options{SampleRate: rate(0)}, nil, ""},
+		{"method sampled out", Options{MethodSampleRates: map[string]float64{"/svc/Get": 0}}, nil, ""},
+		{"method sampled in", Options{SampleRate: rate(0), MethodSampleRates: map[string]float64{"/svc/Get": 1}}, nil,
+			`{"level":"INFO","msg":"grpc call","method":"/svc/Get","kind":"unary","code":"OK","latency_ms":0,"peer":"10.0.0.1:1234"}`},
+		{"excluded", Options{ExcludeMethods: []string{"/svc/Get"}}, nil, ""},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			l, client := newTestLog(t)
+			interceptor := UnaryServerInterceptor(l, data.options)
+			_, err := interceptor(peerContext(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Get"},
+				func(context.Context, any) (any, error) { return nil, data.err })
+			if err != data.err {
+				t.Errorf("error not propagated: %v", err)
+			}
+
+			msgs := client.Messages("/app")
+			if data.expected == "" {
+				if len(msgs) != 0 {
+					t.Fatalf("expected no event, got: %v", msgs)
+				}
+				return
+			}
+			if len(msgs) != 1 || msgs[0] != data.expected {
+				t.Errorf("expected=%s got=%v", data.expected, msgs)
+			}
+		})
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, client := newTestLog(t)
+	interceptor := StreamServerInterceptor(l, Options{})
+	err := interceptor(nil, testStream{ctx: peerContext()}, &grpc.StreamServerInfo{FullMethod: "/svc/Watch"},
+		func(any, grpc.ServerStream) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"level":"INFO","msg":"grpc call","method":"/svc/Watch","kind":"stream","code":"OK","latency_ms":0,"peer":"10.0.0.1:1234"}`
+	if msgs := client.Messages("/app"); len(msgs) != 1 || msgs[0] != expected {
+		t.Errorf("expected=%s got=%v", expected, msgs)
+	}
+}
@@ -0,0 +1,99 @@
+package cwloghttp
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// IngestOptions define settings for IngestHandler.
+type IngestOptions struct {
+	// Token optionally requires requests to carry "Authorization: Bearer <Token>".
+	Token string
+
+	// MaxBodyBytes limits the request body size. Defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+// IngestHandler accepts POSTed bodies and forwards them into l, one event
+// per line, so edge services can relay logs through one credentialed
+// process. Bodies with Content-Type application/x-ndjson must hold one
+// JSON value per line, otherwise the whole body is rejected; any other
+// content type is taken as plain text.
+//
+// It answers 204 on success, 401 for a bad token, 413 for a large body,
+// 400 for invalid NDJSON and 503 when events could not be forwarded.
+func IngestHandler(l *cwlog.Log, options IngestOptions) http.Handler {
+	if options.MaxBodyBytes < 1 {
+		options.MaxBodyBytes = 1 << 20
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r, options.Token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		lines, status, err := readLines(w, r, options.MaxBodyBytes)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		if err := l.PutLines(lines); err != nil {
+			http.Error(w, "forward error", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	got, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// readLines reads the body lines, validating NDJSON.
+// On error it returns the HTTP status to answer.
+func readLines(w http.ResponseWriter, r *http.Request, limit int64) ([]string, int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	ndjson := mediaType == "application/x-ndjson"
+
+	body := http.MaxBytesReader(w, r.Body, limit)
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, int(limit)+1)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if ndjson && !json.Valid([]byte(line)) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid json at line %d", len(lines)+1)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, errors.New("body too large")
+		}
+		return nil, http.StatusBadRequest, err
+	}
+	return lines, 0, nil
+}
@@ -0,0 +1,52 @@
+package cwloghttp
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIngestHandler(t *testing.T) {
+	table := []struct {
+		name        string
+		method      string
+		contentType string
+		auth        string
+		body        string
+		status      int
+		expected    []string
+	}{
+		{"plain", "POST", "text/plain", "Bearer secret", "a\n\nb\r\n", 204, []string{"a", "b"}},
+		{"ndjson", "POST", "application/x-ndjson", "Bearer secret", "{\"a\":1}\n[2]\n", 204, []string{`{"a":1}`, "[2]"}},
+		{"bad ndjson", "POST", "application/x-ndjson", "Bearer secret", "{\"a\":1}\nnope\n", 400, nil},
+		{"bad token", "POST", "text/plain", "Bearer wrong", "a\n", 401, nil},
+		{"no token", "POST", "text/plain", "", "a\n", 401, nil},
+		{"too large", "POST", "text/plain", "Bearer secret", strings.Repeat("x\n", 100), 413, nil},
+		{"get", "GET", "text/plain", "Bearer secret", "", 405, nil},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			l, client := newTestLog(t)
+			h := IngestHandler(l, IngestOptions{Token: "secret", MaxBodyBytes: 64})
+
+			req := httptest.NewRequest(data.method, "/ingest", strings.NewReader(data.body))
+			req.Header.Set("Content-Type", data.contentType)
+			if data.auth != "" {
+				req.Header.Set("Authorization", data.auth)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != data.status {
+				t.Errorf("status: expected=%d got=%d", data.status, w.Code)
+			}
+			if msgs := client.Messages("/app"); !reflect.DeepEqual(msgs, data.expected) {
+				t.Errorf("expected=%v got=%v", data.expected, msgs)
+			}
+		})
+	}
+}
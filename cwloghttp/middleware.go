@@ -0,0 +1,162 @@
+// Package cwloghttp provides net/http helpers logging to CloudWatch Logs through cwlog.
+package cwloghttp
+
+import (
+	"bufio"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Options define settings for Middleware.
+type Options struct {
+	// SampleRate is the fraction, from 0 (none) to 1, of successful
+	// requests logged, like aws.Float64(0.1). Requests failing with status
+	// 500 or above are always logged. If nil, every request is logged.
+	SampleRate *float64
+
+	// ExcludePaths lists request paths never logged, like health checks.
+	// A path ending with "/" excludes every path under it.
+	ExcludePaths []string
+
+	// RequestIDHeader is the header holding the request ID.
+	// Defaults to "X-Request-Id".
+	RequestIDHeader string
+
+	// TrustForwarded takes the remote IP from the first X-Forwarded-For
+	// address, when present. Enable only behind a trusted proxy.
+	TrustForwarded bool
+}
+
+// Middleware creates a net/http middleware logging one structured
+// access-log event per request into l, with method, path, status,
+// latency, bytes, remote IP and request ID.
+// Status 500 and above is logged as ERROR, 400 and above as WARN,
+// other requests as INFO.
+//
+// The event is a cwlog.RequestEvent carried by the request context, so
+// handlers can add fields to it, or escalate its level, with
+// cwlog.RequestEventFrom(r.Context()). Requests escalated to ERROR are
+// always logged.
+func Middleware(l *cwlog.Log, options Options) func(http.Handler) http.Handler {
+	if options.RequestIDHeader == "" {
+		options.RequestIDHeader = "X-Request-Id"
+	}
+	rate := 1.0
+	if options.SampleRate != nil {
+		rate = *options.SampleRate
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excluded(options.ExcludePaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			begin := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			ev := l.NewRequestEvent("http request")
+			next.ServeHTTP(rw, r.WithContext(cwlog.ContextWithRequestEvent(r.Context(), ev)))
+			ev.Escalate(statusLevel(rw.status))
+			if ev.Level() < cwlog.LevelError && !sampled(rate) {
+				return
+			}
+			ev.Emit(
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rw.status,
+				"latency_ms", time.Since(begin).Milliseconds(),
+				"bytes", rw.bytes,
+				"remote_ip", remoteIP(r, options.TrustForwarded),
+				"request_id", r.Header.Get(options.RequestIDHeader),
+			)
+		})
+	}
+}
+
+func excluded(paths []string, path string) bool {
+	return slices.ContainsFunc(paths, func(p string) bool {
+		if strings.HasSuffix(p, "/") {
+			return strings.HasPrefix(path, p)
+		}
+		return path == p
+	})
+}
+
+// sampled reports whether to log a request at rate, never for 0.
+func sampled(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
+
+func statusLevel(status int) cwlog.Level {
+	switch {
+	case status >= 500:
+		return cwlog.LevelError
+	case status >= 400:
+		return cwlog.LevelWarn
+	}
+	return cwlog.LevelInfo
+}
+
+func remoteIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			first, _, _ := strings.Cut(fwd, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseWriter records status and body size.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush supports http.Flusher, for streaming responses like server-sent
+// events.
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush() // best effort, like http.Flusher
+}
+
+// Hijack supports http.Hijacker, for protocol upgrades like websockets.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap supports http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
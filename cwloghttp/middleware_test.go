@@ -0,0 +1,161 @@
+package cwloghttp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+func newTestLog(t *testing.T) (*cwlog.Log, *cwlogtest.Client) {
+	t.Helper()
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, client
+}
+
+func rate(v float64) *float64 { return &v }
+
+func TestMiddleware(t *testing.T) {
+	table := []struct {
+		name     string
+		options  Options
+		path     string
+		status   int
+		expected string
+	}{
+		{"info", Options{}, "/hello", 200,
+			`{"level":"INFO","msg":"http request","method":"GET","path":"/hello","status":200,"latency_ms":0,"bytes":5,"remote_ip":"10.0.0.1","request_id":"abc"}`},
+		{"error", Options{}, "/fail", 503,
+			`{"level":"ERROR","msg":"http request","method":"GET","path":"/fail","status":503,"latency_ms":0,"bytes":5,"remote_ip":"10.0.0.1","request_id":"abc"}`},
+		{"forwarded", Options{TrustForwarded: true}, "/hello", 404,
+			`{"level":"WARN","msg":"http request","method":"GET","path":"/hello","status":404,"latency_ms":0,"bytes":5,"remote_ip":"192.168.0.9","request_id":"abc"}`},
+		{"excluded exact", Options{ExcludePaths: []string{"/health"}}, "/health", 200, ""},
+		{"excluded prefix", Options{ExcludePaths: []string{"/metrics/"}}, "/metrics/x", 200, ""},
+		{"sampled out", Options{SampleRate: rate(0)}, "/hello", 200, ""},
+		{"sampled in", Options{SampleRate: rate(1)}, "/hello", 200,
+			`{"level":"INFO","msg":"http request","method":"GET","path":"/hello","status":200,"latency_ms":0,"bytes":5,"remote_ip":"10.0.0.1","request_id":"abc"}`},
+		{"sampled error", Options{SampleRate: rate(0)}, "/fail", 500,
+			`{"level":"ERROR","msg":"http request","method":"GET","path":"/fail","status":500,"latency_ms":0,"bytes":5,"remote_ip":"10.0.0.1","request_id":"abc"}`},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			l, client := newTestLog(t)
+			h := Middleware(l, data.options)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(data.status)
+				w.Write([]byte("hello"))
+			}))
+
+			req := httptest.NewRequest("GET", data.path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Request-Id", "abc")
+			req.Header.Set("X-Forwarded-For", "192.168.0.9, 10.0.0.2")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			msgs := client.Messages("/app")
+			if data.expected == "" {
+				if len(msgs) != 0 {
+					t.Fatalf("expected no event, got: %v", msgs)
+				}
+				return
+			}
+			if len(msgs) != 1 {
+				t.Fatalf("expected one event, got: %v", msgs)
+			}
+			if msg := strings.Replace(msgs[0], `"latency_ms":1,`, `"latency_ms":0,`, 1); msg != data.expected {
+				t.Errorf("expected=%s got=%s", data.expected, msg)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRequestEvent(t *testing.T) {
+	l, client := newTestLog(t)
+	h := Middleware(l, Options{SampleRate: rate(0)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ev := cwlog.RequestEventFrom(r.Context())
+		ev.Set("user", "alice")
+		ev.Set("cache", "miss")
+		ev.Escalate(cwlog.LevelError)
+		w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	msgs := client.Messages("/app")
+	if len(msgs) != 1 {
+		t.Fatalf("expected one event, got: %v", msgs)
+	}
+	expected := `{"level":"ERROR","msg":"http request","method":"GET","path":"/orders","status":200,"latency_ms":0,"bytes":5,"remote_ip":"10.0.0.1","request_id":"","user":"alice","cache":"miss"}`
+	if msg := strings.Replace(msgs[0], `"latency_ms":1,`, `"latency_ms":0,`, 1); msg != expected {
+		t.Errorf("expected=%s got=%s", expected, msg)
+	}
+}
+
+func TestMiddlewareFlushHijack(t *testing.T) {
+	l, client := newTestLog(t)
+	h := Middleware(l, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+			return
+		}
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		buf.Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	if !rec.Flushed {
+		t.Error("response not flushed")
+	}
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: x\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status: expected=101 got=%d", resp.StatusCode)
+	}
+
+	// the access-log event is sent once the hijacking handler returns
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.Messages("/app")) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	msgs := client.Messages("/app")
+	if len(msgs) != 2 || !strings.Contains(msgs[1], `"path":"/ws","status":101`) {
+		t.Errorf("unexpected events: %v", msgs)
+	}
+}
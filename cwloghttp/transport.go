@@ -0,0 +1,133 @@
+package cwloghttp
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Transport is an http.RoundTripper logging outbound calls into Log,
+// with method, URL, status, latency and retries, for auditing
+// third-party API usage. Query strings are omitted from the logged URL
+// unless IncludeQuery is enabled, since they often carry credentials.
+type Transport struct {
+	// Log is required.
+	Log *cwlog.Log
+
+	// Base performs the calls. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	// MaxRetries retries idempotent requests failing with a network error
+	// or status 502, 503 or 504. If undefined, requests are not retried.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// following retry. Defaults to 100ms.
+	RetryBackoff time.Duration
+
+	// IncludeQuery logs the URL query string.
+	IncludeQuery bool
+}
+
+// RoundTrip performs the call, retrying if enabled, and logs one event.
+// Network errors are logged as ERROR, like status 500 and above.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	begin := time.Now()
+	resp, retries, err := t.roundTrip(req)
+
+	keyvals := []any{
+		"method", req.Method,
+		"url", t.logURL(req),
+		"latency_ms", time.Since(begin).Milliseconds(),
+		"retries", retries,
+	}
+	level := cwlog.LevelError
+	if err != nil {
+		keyvals = append(keyvals, "error", err.Error())
+	} else {
+		level = statusLevel(resp.StatusCode)
+		keyvals = append(keyvals, "status", resp.StatusCode)
+	}
+	t.Log.PutLevel(level, "http client request", keyvals...)
+
+	return resp, err
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, int, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	backoff := t.RetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	for retries := 0; ; retries++ {
+		resp, err := base.RoundTrip(req)
+		if retries >= t.MaxRetries || !shouldRetry(req, resp, err) {
+			return resp, retries, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, retries, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+
+		if req, err = rewind(req); err != nil {
+			return nil, retries, err
+		}
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false // body cannot be replayed
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind clones req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+func (t *Transport) logURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	if !t.IncludeQuery {
+		u.RawQuery = ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,73 @@
+package cwloghttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/flaky":
+			if calls < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	table := []struct {
+		name     string
+		method   string
+		path     string
+		expected []string
+		calls    int
+	}{
+		{"ok", "GET", "/hello?token=secret", []string{`"level":"INFO"`, `"url":"` + server.URL + `/hello"`, `"retries":0`, `"status":200`}, 1},
+		{"retried", "GET", "/flaky", []string{`"level":"INFO"`, `"retries":2`, `"status":200`}, 3},
+		{"not idempotent", "POST", "/flaky", []string{`"level":"ERROR"`, `"retries":0`, `"status":503`}, 1},
+		{"client error", "GET", "/missing", []string{`"level":"WARN"`, `"retries":0`, `"status":404`}, 1},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			calls = 0
+			l, client := newTestLog(t)
+			httpClient := &http.Client{Transport: &Transport{Log: l, MaxRetries: 3, RetryBackoff: 1}}
+
+			req, errReq := http.NewRequest(data.method, server.URL+data.path, nil)
+			if errReq != nil {
+				t.Fatal(errReq)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if calls != data.calls {
+				t.Errorf("calls: expected=%d got=%d", data.calls, calls)
+			}
+			msgs := client.Messages("/app")
+			if len(msgs) != 1 {
+				t.Fatalf("expected one event, got: %v", msgs)
+			}
+			for _, want := range data.expected {
+				if !strings.Contains(msgs[0], want) {
+					t.Errorf("event missing %s: %s", want, msgs[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+//go:build linux
+
+// Package cwlogjournal forwards systemd journal entries to CloudWatch Logs
+// through cwlog, making it usable as a host-level shipper. It reads the
+// journal with journalctl, so it works without cgo.
+package cwlogjournal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Options define settings for Forwarder.
+type Options struct {
+	// Log is required. Enable cwlog.Options.Async to batch entries.
+	Log *cwlog.Log
+
+	// Units optionally restricts forwarding to these systemd units.
+	Units []string
+
+	// CursorFile optionally persists the journal cursor, so forwarding
+	// resumes after the last entry sent. Without a saved cursor,
+	// only new entries are forwarded.
+	CursorFile string
+
+	// CheckpointInterval is how often the cursor is saved, flushing the
+	// Log first. Defaults to 5s.
+	CheckpointInterval time.Duration
+
+	// Journalctl is the journalctl binary. Defaults to "journalctl".
+	Journalctl string
+}
+
+// Entry is the JSON event sent for each journal entry.
+type Entry struct {
+	Priority   string `json:"priority,omitempty"`
+	Unit       string `json:"unit,omitempty"`
+	Hostname   string `json:"host,omitempty"`
+	Identifier string `json:"ident,omitempty"`
+	PID        string `json:"pid,omitempty"`
+	Message    string `json:"msg"`
+}
+
+// Forwarder follows the journal.
+type Forwarder struct {
+	options Options
+	cursor  string
+	saved   string
+}
+
+// New creates a journal forwarder.
+func New(options Options) (*Forwarder, error) {
+	if options.Log == nil {
+		return nil, errors.New("Log is required")
+	}
+	if options.CheckpointInterval <= 0 {
+		options.CheckpointInterval = 5 * time.Second
+	}
+	if options.Journalctl == "" {
+		options.Journalctl = "journalctl"
+	}
+	f := &Forwarder{options: options}
+	if options.CursorFile != "" {
+		data, err := os.ReadFile(options.CursorFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("journal cursor load error: %w", err)
+		}
+		f.cursor = strings.TrimSpace(string(data))
+		f.saved = f.cursor
+	}
+	return f, nil
+}
+
+// Run forwards entries until ctx is done, journalctl exits, or sending
+// an entry or saving the cursor fails, then saves the cursor.
+func (f *Forwarder) Run(ctx context.Context) error {
+	ctxCmd, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctxCmd, f.options.Journalctl, f.args()...)
+	stdout, errPipe := cmd.StdoutPipe()
+	if errPipe != nil {
+		return errPipe
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("journalctl start error: %w", err)
+	}
+
+	errRead := f.read(stdout)
+	if errRead != nil {
+		cancel() // journalctl would block writing to the abandoned pipe
+	}
+	errWait := cmd.Wait()
+	errSave := f.checkpoint()
+	if errRead != nil {
+		return errors.Join(errRead, errSave) // errWait reports the kill
+	}
+	if ctx.Err() != nil {
+		return errSave // interrupted
+	}
+	return errors.Join(errWait, errSave)
+}
+
+func (f *Forwarder) args() []string {
+	// --all keeps fields over 4096 bytes, otherwise rendered as null
+	args := []string{"--output=json", "--all", "--follow"}
+	if f.cursor != "" {
+		args = append(args, "--after-cursor="+f.cursor)
+	} else {
+		args = append(args, "--lines=0")
+	}
+	for _, u := range f.options.Units {
+		args = append(args, "--unit="+u)
+	}
+	return args
+}
+
+func (f *Forwarder) read(r io.Reader) error {
+	last := time.Now()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 4<<20)
+	for scanner.Scan() {
+		event, cursor, err := parseEntry(scanner.Bytes())
+		if err != nil {
+			continue // not an entry
+		}
+		if err := f.options.Log.PutLogEvents([]types.InputLogEvent{event}); err != nil {
+			return err
+		}
+		f.cursor = cursor
+		if time.Since(last) >= f.options.CheckpointInterval {
+			if err := f.checkpoint(); err != nil {
+				return err
+			}
+			last = time.Now()
+		}
+	}
+	return scanner.Err()
+}
+
+// checkpoint saves the cursor, if changed, after flushing the Log.
+func (f *Forwarder) checkpoint() error {
+	if f.options.CursorFile == "" || f.cursor == f.saved {
+		return nil
+	}
+	if err := f.options.Log.Flush(); err != nil {
+		return err
+	}
+	tmp := f.options.CursorFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(f.cursor+"\n"), 0o600); err != nil {
+		return fmt.Errorf("journal cursor save error: %w", err)
+	}
+	if err := os.Rename(tmp, f.options.CursorFile); err != nil {
+		return fmt.Errorf("journal cursor save error: %s: %w",
+			filepath.Base(f.options.CursorFile), err)
+	}
+	f.saved = f.cursor
+	return nil
+}
+
+var priorities = []string{"emerg", "alert", "crit", "err", "warning",
+	"notice", "info", "debug"}
+
+// parseEntry converts one journalctl JSON line.
+func parseEntry(line []byte) (types.InputLogEvent, string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return types.InputLogEvent{}, "", err
+	}
+	cursor := field(raw, "__CURSOR")
+	if cursor == "" {
+		return types.InputLogEvent{}, "", errors.New("missing journal cursor")
+	}
+	entry := Entry{
+		Unit:       field(raw, "_SYSTEMD_UNIT"),
+		Hostname:   field(raw, "_HOSTNAME"),
+		Identifier: field(raw, "SYSLOG_IDENTIFIER"),
+		PID:        field(raw, "_PID"),
+		Message:    field(raw, "MESSAGE"),
+	}
+	if p, err := strconv.Atoi(field(raw, "PRIORITY")); err == nil && p >= 0 && p < len(priorities) {
+		entry.Priority = priorities[p]
+	}
+	ts := time.Now()
+	if usec, err := strconv.ParseInt(field(raw, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+		ts = time.UnixMicro(usec)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return types.InputLogEvent{}, "", err
+	}
+	return types.InputLogEvent{
+		Message:   aws.String(string(data)),
+		Timestamp: aws.Int64(ts.UnixMilli()),
+	}, cursor, nil
+}
+
+// field decodes a journal field, which journalctl renders as a string,
+// or as an array of bytes when not valid UTF-8.
+func field(raw map[string]json.RawMessage, name string) string {
+	v, found := raw[name]
+	if !found {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	var b []byte
+	var ints []int
+	if err := json.Unmarshal(v, &ints); err == nil {
+		for _, i := range ints {
+			b = append(b, byte(i))
+		}
+	}
+	return string(b)
+}
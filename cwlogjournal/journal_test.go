@@ -0,0 +1,132 @@
+//go:build linux
+
+package cwlogjournal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+// fakeJournalctl writes a script printing entries and its arguments.
+func fakeJournalctl(t *testing.T, dir string) string {
+	t.Helper()
+	script := `#!/bin/sh
+echo "$@" > ` + filepath.Join(dir, "args") + `
+echo '{"__CURSOR":"c1","__REALTIME_TIMESTAMP":"1717193655000000","PRIORITY":"3","_SYSTEMD_UNIT":"nginx.service","_HOSTNAME":"web1","SYSLOG_IDENTIFIER":"nginx","_PID":"42","MESSAGE":"upstream down"}'
+echo 'garbage'
+echo '{"__CURSOR":"c2","__REALTIME_TIMESTAMP":"1717193656000000","PRIORITY":"6","MESSAGE":[104,105]}'
+`
+	path := filepath.Join(dir, "journalctl")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestForwarder(t *testing.T) {
+	dir := t.TempDir()
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/journal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := Options{
+		Log:        l,
+		Units:      []string{"nginx.service"},
+		CursorFile: filepath.Join(dir, "cursor"),
+		Journalctl: fakeJournalctl(t, dir),
+	}
+	f, errNew := New(options)
+	if errNew != nil {
+		t.Fatal(errNew)
+	}
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"priority":"err","unit":"nginx.service","host":"web1","ident":"nginx","pid":"42","msg":"upstream down"}`,
+		`{"priority":"info","msg":"hi"}`,
+	}
+	if msgs := client.Messages("/journal"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "--output=json --all --follow --lines=0 --unit=nginx.service" {
+		t.Errorf("first run args: %s", got)
+	}
+
+	// restart resumes after the saved cursor
+	f, errNew = New(options)
+	if errNew != nil {
+		t.Fatal(errNew)
+	}
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	args, _ = os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "--output=json --all --follow --after-cursor=c2 --unit=nginx.service" {
+		t.Errorf("resume args: %s", got)
+	}
+}
+
+// denyingClient fails every PutLogEvents.
+type denyingClient struct {
+	*cwlogtest.Client
+}
+
+func (c denyingClient) PutLogEvents(context.Context,
+	*cloudwatchlogs.PutLogEventsInput,
+	...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return nil, errors.New("put log denied")
+}
+
+func TestForwarderPutError(t *testing.T) {
+	dir := t.TempDir()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   denyingClient{cwlogtest.NewClient()},
+		LogGroup: "/journal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// follows forever, blocking once the pipe is full
+	script := `#!/bin/sh
+exec yes '{"__CURSOR":"c1","MESSAGE":"hi"}'
+`
+	path := filepath.Join(dir, "journalctl")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, errNew := New(Options{Log: l, Journalctl: path})
+	if errNew != nil {
+		t.Fatal(errNew)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- f.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "put log denied") {
+			t.Errorf("expected put error, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run hangs after put error")
+	}
+}
@@ -0,0 +1,63 @@
+// Package cwlognet ships newline-delimited text received over TCP or UDP
+// to CloudWatch Logs through cwlog, for devices that can only emit plain
+// sockets. Enable cwlog.Options.Async on the Log to batch lines.
+package cwlognet
+
+import (
+	"bufio"
+	"errors"
+	"net"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// ServeTCP accepts connections until ln is closed, sending each line
+// received as one event.
+func ServeTCP(l *cwlog.Log, ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(l, conn)
+	}
+}
+
+// serveConn sends the lines of conn one at a time, so a line failing
+// to send, like one dropped with cwlog.ErrBufferFull in buffered mode,
+// does not stop reading the connection. Failed lines are counted by the
+// Log Stats, as DroppedEvents or FailedEvents.
+func serveConn(l *cwlog.Log, conn net.Conn) {
+	defer conn.Close()
+	w := cwlog.NewWriter(l, cwlog.WriterOptions{})
+	defer w.Close() // last line may lack newline
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadSlice('\n')
+		w.Write(line)
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return
+		}
+	}
+}
+
+// ServeUDP reads datagrams until conn is closed, sending each line
+// received as one event. A datagram always ends a line.
+func ServeUDP(l *cwlog.Log, conn net.PacketConn) error {
+	w := cwlog.NewWriter(l, cwlog.WriterOptions{})
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		w.Write(buf[:n])
+		w.Flush()
+	}
+}
@@ -0,0 +1,134 @@
+package cwlognet
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+func newTestLog(t *testing.T) (*cwlog.Log, *cwlogtest.Client) {
+	t.Helper()
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/net",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, client
+}
+
+func waitMessages(client *cwlogtest.Client, n int) []string {
+	var msgs []string
+	for range 100 {
+		if msgs = client.Messages("/net"); len(msgs) >= n {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return msgs
+}
+
+func TestServeTCP(t *testing.T) {
+	l, client := newTestLog(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go ServeTCP(l, ln)
+
+	conn, errDial := net.Dial("tcp", ln.Addr().String())
+	if errDial != nil {
+		t.Fatal(errDial)
+	}
+	conn.Write([]byte("line 1\nline"))
+	conn.Write([]byte(" 2\nline 3"))
+	conn.Close()
+
+	expected := []string{"line 1", "line 2", "line 3"}
+	if msgs := waitMessages(client, 3); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+func TestServeUDP(t *testing.T) {
+	l, client := newTestLog(t)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go ServeUDP(l, pc)
+
+	conn, errDial := net.Dial("udp", pc.LocalAddr().String())
+	if errDial != nil {
+		t.Fatal(errDial)
+	}
+	defer conn.Close()
+	conn.Write([]byte("a\nb"))
+
+	expected := []string{"a", "b"}
+	if msgs := waitMessages(client, 2); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+func TestServeTCPBufferFull(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:        client,
+		Now:           func() time.Time { return time.Time{} },
+		LogGroup:      "/net",
+		Async:         true,
+		BufferEvents:  1,
+		FlushInterval: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ln, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatal(errListen)
+	}
+	defer ln.Close()
+	go ServeTCP(l, ln)
+
+	conn, errDial := net.Dial("tcp", ln.Addr().String())
+	if errDial != nil {
+		t.Fatal(errDial)
+	}
+	defer conn.Close()
+	conn.Write([]byte("a\nb\n"))
+	for i := 0; l.Stats().DroppedEvents < 1; i++ {
+		if i == 100 {
+			t.Fatal("expected a dropped line")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reading goes on after the drop
+	conn.Write([]byte("c\n"))
+	expected := []string{"a", "c"}
+	var msgs []string
+	for range 100 {
+		l.Flush()
+		if msgs = client.Messages("/net"); len(msgs) >= 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
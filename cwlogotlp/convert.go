@@ -0,0 +1,122 @@
+package cwlogotlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// forward sends the records of each resource to its destination.
+func (h *handler) forward(req *collogs.ExportLogsServiceRequest) error {
+	var errs []error
+	for _, rl := range req.GetResourceLogs() {
+		resource := attributes(rl.GetResource().GetAttributes())
+		l, err := h.logFor(resource)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if h.options.OmitResource {
+			resource = nil
+		}
+		var events []types.InputLogEvent
+		for _, sl := range rl.GetScopeLogs() {
+			scope := sl.GetScope().GetName()
+			for _, lr := range sl.GetLogRecords() {
+				e, err := newEvent(lr, resource, scope)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				events = append(events, e)
+			}
+		}
+		if len(events) > 0 {
+			errs = append(errs, l.PutLogEvents(events))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newEvent(lr *logspb.LogRecord, resource map[string]any, scope string) (types.InputLogEvent, error) {
+	severity := lr.GetSeverityText()
+	if severity == "" && lr.GetSeverityNumber() != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		severity = severityName(lr.GetSeverityNumber())
+	}
+	rec := Record{
+		Severity:   severity,
+		Body:       value(lr.GetBody()),
+		Attributes: attributes(lr.GetAttributes()),
+		Resource:   resource,
+		Scope:      scope,
+		TraceID:    hexID(lr.GetTraceId()),
+		SpanID:     hexID(lr.GetSpanId()),
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return types.InputLogEvent{}, fmt.Errorf("otlp record encode: %w", err)
+	}
+	ts := eventTime(lr.GetTimeUnixNano(), lr.GetObservedTimeUnixNano())
+	return types.InputLogEvent{
+		Message:   aws.String(string(data)),
+		Timestamp: aws.Int64(ts.UnixMilli()),
+	}, nil
+}
+
+// severityName returns the severity number name without its prefix,
+// like WARN, or the number itself when outside the enum.
+func severityName(n logspb.SeverityNumber) string {
+	name, found := logspb.SeverityNumber_name[int32(n)]
+	if !found {
+		return strconv.Itoa(int(n))
+	}
+	return strings.TrimPrefix(name, "SEVERITY_NUMBER_")
+}
+
+func attributes(kvs []*commonpb.KeyValue) map[string]any {
+	if len(kvs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		m[kv.GetKey()] = value(kv.GetValue())
+	}
+	return m
+}
+
+func value(v *commonpb.AnyValue) any {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return x.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return x.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		if math.IsNaN(x.DoubleValue) || math.IsInf(x.DoubleValue, 0) {
+			return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64) // not valid JSON numbers
+		}
+		return x.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return x.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		values := x.ArrayValue.GetValues()
+		list := make([]any, 0, len(values))
+		for _, e := range values {
+			list = append(list, value(e))
+		}
+		return list
+	case *commonpb.AnyValue_KvlistValue:
+		return attributes(x.KvlistValue.GetValues())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package cwlogotlp bridges OTLP/HTTP log exports, like the ones sent by
+// OpenTelemetry collectors, into CloudWatch Logs through cwlog.
+package cwlogotlp
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Options define settings for Handler.
+type Options struct {
+	// StreamAttribute optionally names a resource attribute, like
+	// "service.name", whose value is mapped into the LogStream field of
+	// the Log stream template, sending each resource to its own stream.
+	StreamAttribute string
+
+	// OmitResource leaves resource attributes out of the JSON events.
+	OmitResource bool
+
+	// MaxBodyBytes limits the request body size. Defaults to 4 MiB.
+	MaxBodyBytes int64
+}
+
+// Record is the JSON event sent for each OTLP log record.
+type Record struct {
+	Severity   string         `json:"severity,omitempty"`
+	Body       any            `json:"body,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Resource   map[string]any `json:"resource,omitempty"`
+	Scope      string         `json:"scope,omitempty"`
+	TraceID    string         `json:"traceId,omitempty"`
+	SpanID     string         `json:"spanId,omitempty"`
+}
+
+type handler struct {
+	log     *cwlog.Log
+	options Options
+	mu      sync.Mutex
+	streams map[string]*cwlog.Log
+}
+
+// Handler accepts OTLP/HTTP log export requests, usually served at
+// "/v1/logs", encoded as protobuf or JSON, optionally gzip compressed,
+// and forwards each log record into l as a JSON event.
+func Handler(l *cwlog.Log, options Options) http.Handler {
+	if options.MaxBodyBytes < 1 {
+		options.MaxBodyBytes = 4 << 20
+	}
+	return &handler{log: l, options: options, streams: map[string]*cwlog.Log{}}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+	if !isJSON && mediaType != "application/x-protobuf" {
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	req, status, err := h.decode(w, r, isJSON)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if err := h.forward(req); err != nil {
+		http.Error(w, "forward error", http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := &collogs.ExportLogsServiceResponse{}
+	var data []byte
+	if isJSON {
+		data, _ = protojson.Marshal(resp)
+	} else {
+		data, _ = proto.Marshal(resp)
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Write(data)
+}
+
+func (h *handler) decode(w http.ResponseWriter, r *http.Request,
+	isJSON bool) (*collogs.ExportLogsServiceRequest, int, error) {
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, h.options.MaxBodyBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		body = io.LimitReader(gz, h.options.MaxBodyBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, errors.New("body too large")
+		}
+		return nil, http.StatusBadRequest, err
+	}
+	if int64(len(data)) > h.options.MaxBodyBytes {
+		return nil, http.StatusRequestEntityTooLarge, errors.New("body too large")
+	}
+
+	req := &collogs.ExportLogsServiceRequest{}
+	if isJSON {
+		err = protojson.Unmarshal(data, req)
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("bad otlp request: %w", err)
+	}
+	return req, 0, nil
+}
+
+// logFor returns the destination for a resource.
+func (h *handler) logFor(resource map[string]any) (*cwlog.Log, error) {
+	if h.options.StreamAttribute == "" {
+		return h.log, nil
+	}
+	stream, _ := resource[h.options.StreamAttribute].(string)
+	if stream == "" {
+		return h.log, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l, found := h.streams[stream]; found {
+		return l, nil
+	}
+	l, err := h.log.Clone(cwlog.Options{LogStream: stream})
+	if err != nil {
+		return nil, err
+	}
+	h.streams[stream] = l
+	return l, nil
+}
+
+func hexID(b []byte) string {
+	for _, c := range b {
+		if c != 0 {
+			return hex.EncodeToString(b)
+		}
+	}
+	return "" // unset
+}
+
+func eventTime(timeUnixNano, observedUnixNano uint64) time.Time {
+	switch {
+	case timeUnixNano != 0:
+		return time.Unix(0, int64(timeUnixNano))
+	case observedUnixNano != 0:
+		return time.Unix(0, int64(observedUnixNano))
+	}
+	return time.Now()
+}
@@ -0,0 +1,178 @@
+package cwlogotlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func str(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{
+		Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func exportRequest(service string) *collogs.ExportLogsServiceRequest {
+	return &collogs.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{str("service.name", service)},
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope: &commonpb.InstrumentationScope{Name: "lib"},
+				LogRecords: []*logspb.LogRecord{{
+					TimeUnixNano:   uint64(time.Unix(1700000000, 0).UnixNano()),
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+					Body: &commonpb.AnyValue{
+						Value: &commonpb.AnyValue_StringValue{StringValue: "hello"}},
+					Attributes: []*commonpb.KeyValue{str("user", "bob")},
+					TraceId:    bytes.Repeat([]byte{1}, 16),
+					SpanId:     make([]byte, 8),
+				}},
+			}},
+		}},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/otlp",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler(l, Options{StreamAttribute: "service.name"})
+
+	// protobuf, gzip compressed
+	data, _ := proto.Marshal(exportRequest("web"))
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/logs", &buf)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("protobuf: status=%d body=%s", rec.Code, rec.Body)
+	}
+	if err := proto.Unmarshal(rec.Body.Bytes(), &collogs.ExportLogsServiceResponse{}); err != nil {
+		t.Errorf("protobuf response: %v", err)
+	}
+
+	// JSON
+	data, _ = protojson.Marshal(exportRequest("db"))
+	req = httptest.NewRequest(http.MethodPost, "/v1/logs", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("json: status=%d body=%s", rec.Code, rec.Body)
+	}
+
+	web := client.StreamMessages("/otlp", "web-0001-01-01-00")
+	if len(web) != 1 {
+		t.Fatalf("web stream: expected 1 message, got %v", web)
+	}
+	if len(client.StreamMessages("/otlp", "db-0001-01-01-00")) != 1 {
+		t.Errorf("db stream: expected 1 message")
+	}
+
+	var got Record
+	if err := json.Unmarshal([]byte(web[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Severity != "WARN" || got.Body != "hello" || got.Scope != "lib" ||
+		got.Attributes["user"] != "bob" || got.Resource["service.name"] != "web" ||
+		got.TraceID != "01010101010101010101010101010101" || got.SpanID != "" {
+		t.Errorf("unexpected record: %+v", got)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{Client: client, LogGroup: "/otlp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Handler(l, Options{MaxBodyBytes: 10})
+
+	table := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"get", http.MethodGet, "application/json", "", http.StatusMethodNotAllowed},
+		{"content type", http.MethodPost, "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{"too large", http.MethodPost, "application/json", "{\"resourceLogs\":[]}", http.StatusRequestEntityTooLarge},
+		{"bad json", http.MethodPost, "application/json", "{", http.StatusBadRequest},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(data.method, "/v1/logs", bytes.NewBufferString(data.body))
+			req.Header.Set("Content-Type", data.contentType)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != data.status {
+				t.Errorf("expected status %d, got %d", data.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	double := func(f float64) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}}
+	}
+
+	table := []struct {
+		name     string
+		record   *logspb.LogRecord
+		expected string
+	}{
+		{"known severity", &logspb.LogRecord{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR2},
+			`{"severity":"ERROR2"}`},
+		{"unknown severity", &logspb.LogRecord{SeverityNumber: 30},
+			`{"severity":"30"}`},
+		{"NaN body", &logspb.LogRecord{Body: double(math.NaN())},
+			`{"body":"NaN"}`},
+		{"infinite attribute", &logspb.LogRecord{Attributes: []*commonpb.KeyValue{
+			{Key: "x", Value: double(math.Inf(-1))}}},
+			`{"attributes":{"x":"-Inf"}}`},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			e, err := newEvent(data.record, nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg := *e.Message; msg != data.expected {
+				t.Errorf("expected=%s got=%s", data.expected, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package cwlogsyslog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a parsed syslog message.
+type Message struct {
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData string
+	Message        string
+}
+
+var errNoPriority = errors.New("missing syslog priority")
+
+// Parse parses an RFC5424 or RFC3164 message. Missing or unparsable
+// timestamps are set to now. RFC3164 timestamps, lacking a year,
+// are taken in the year of now, in its location.
+func Parse(s string, now time.Time) (Message, error) {
+	s = strings.TrimRight(s, "\r\n\x00")
+	pri, rest, err := parsePriority(s)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{Facility: pri / 8, Severity: pri % 8, Timestamp: now}
+	if after, found := strings.CutPrefix(rest, "1 "); found {
+		parse5424(&m, after)
+	} else {
+		parse3164(&m, rest, now)
+	}
+	return m, nil
+}
+
+func parsePriority(s string) (int, string, error) {
+	if !strings.HasPrefix(s, "<") {
+		return 0, "", errNoPriority
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, "", errNoPriority
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri > 191 {
+		return 0, "", errNoPriority
+	}
+	return pri, s[end+1:], nil
+}
+
+// parse5424 parses the part after "<PRI>1 ".
+func parse5424(m *Message, s string) {
+	var ts string
+	ts, s = nextField(s)
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		m.Timestamp = t
+	}
+	m.Hostname, s = nextField(s)
+	m.AppName, s = nextField(s)
+	m.ProcID, s = nextField(s)
+	m.MsgID, s = nextField(s)
+	m.StructuredData, s = structuredData(s)
+	m.Message = strings.TrimPrefix(s, "\ufeff") // optional BOM
+}
+
+// nextField splits the next space-delimited field, mapping "-" to empty.
+func nextField(s string) (string, string) {
+	field, rest, _ := strings.Cut(s, " ")
+	if field == "-" {
+		field = ""
+	}
+	return field, rest
+}
+
+// structuredData splits the leading SD elements, honoring escaped "]".
+func structuredData(s string) (string, string) {
+	if !strings.HasPrefix(s, "[") {
+		_, rest := nextField(s)
+		return "", rest
+	}
+	var inQuote, escaped bool
+	depth := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 && !strings.HasPrefix(s[i+1:], "[") {
+				return s[:i+1], strings.TrimPrefix(s[i+1:], " ")
+			}
+		}
+	}
+	return s, ""
+}
+
+// parse3164 parses the part after "<PRI>": "Mmm dd hh:mm:ss HOST TAG[PID]: MSG".
+func parse3164(m *Message, s string, now time.Time) {
+	const stampLen = len(time.Stamp)
+	if len(s) > stampLen {
+		if t, err := time.ParseInLocation(time.Stamp, s[:stampLen], now.Location()); err == nil {
+			m.Timestamp = t.AddDate(now.Year(), 0, 0)
+			m.Hostname, s = nextField(s[stampLen+1:])
+		}
+	}
+	tag, msg, found := strings.Cut(s, ": ")
+	if !found || strings.ContainsAny(tag, " ") {
+		m.Message = s
+		return
+	}
+	if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+		m.ProcID = tag[open+1 : len(tag)-1]
+		tag = tag[:open]
+	}
+	m.AppName = tag
+	m.Message = msg
+}
@@ -0,0 +1,46 @@
+package cwlogsyslog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	table := []struct {
+		name     string
+		input    string
+		expected Message
+	}{
+		{"rfc5424", `<165>1 2024-05-31T22:14:15.003Z host1 app 1234 ID47 [ex@1 a="x\]y"] hello world`,
+			Message{Facility: 20, Severity: 5, Timestamp: time.Date(2024, 5, 31, 22, 14, 15, 3000000, time.UTC),
+				Hostname: "host1", AppName: "app", ProcID: "1234", MsgID: "ID47",
+				StructuredData: `[ex@1 a="x\]y"]`, Message: "hello world"}},
+		{"rfc5424 nil fields", "<14>1 - - - - - - msg\n",
+			Message{Facility: 1, Severity: 6, Timestamp: now, Message: "msg"}},
+		{"rfc3164", "<34>Oct 11 22:14:15 mymachine su[99]: 'su root' failed",
+			Message{Facility: 4, Severity: 2, Timestamp: time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname: "mymachine", AppName: "su", ProcID: "99", Message: "'su root' failed"}},
+		{"rfc3164 no header", "<13>just text",
+			Message{Facility: 1, Severity: 5, Timestamp: now, Message: "just text"}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			m, err := Parse(data.input, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != data.expected {
+				t.Errorf("expected=%+v\ngot=%+v", data.expected, m)
+			}
+		})
+	}
+
+	if _, err := Parse("no priority", now); err == nil {
+		t.Error("expected error for missing priority")
+	}
+}
@@ -0,0 +1,279 @@
+// Package cwlogsyslog implements a syslog receiver forwarding messages to
+// CloudWatch Logs through cwlog.
+package cwlogsyslog
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Options define settings for Server.
+type Options struct {
+	// Log is required. Enable cwlog.Options.Async to batch messages.
+	Log *cwlog.Log
+
+	// StreamByHost sends each host to its own stream, named after the
+	// hostname, using the stream template of Log. Characters forbidden in
+	// stream names, like the colons of IPv6 addresses, are replaced by '_'.
+	StreamByHost bool
+
+	// MaxHosts bounds how many per-host streams are kept with
+	// StreamByHost, least recently used closed first. Defaults to 1000.
+	MaxHosts int
+
+	// OnError optionally receives errors handling messages received by
+	// ServeUDP and ServeTCP, which are otherwise dropped silently.
+	OnError func(err error)
+
+	// Now defaults to time.Now. Used for messages without timestamp.
+	Now func() time.Time
+
+	// MaxMessageBytes limits TCP message size. Defaults to 65536.
+	MaxMessageBytes int
+}
+
+// Event is the JSON event sent for each syslog message.
+type Event struct {
+	Facility       string `json:"facility"`
+	Severity       string `json:"severity"`
+	Host           string `json:"host,omitempty"`
+	App            string `json:"app,omitempty"`
+	ProcID         string `json:"pid,omitempty"`
+	MsgID          string `json:"msgid,omitempty"`
+	StructuredData string `json:"sd,omitempty"`
+	Message        string `json:"msg"`
+}
+
+// Server receives syslog messages over UDP and TCP.
+type Server struct {
+	options Options
+	mu      sync.Mutex
+	lru     *list.List // of *hostLog
+	hosts   map[string]*list.Element
+}
+
+// hostLog is the per-host clone of Log.
+type hostLog struct {
+	host string
+	log  *cwlog.Log
+}
+
+// New creates a syslog server.
+func New(options Options) (*Server, error) {
+	if options.Log == nil {
+		return nil, errors.New("Log is required")
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.MaxMessageBytes < 1 {
+		options.MaxMessageBytes = 65536
+	}
+	if options.MaxHosts < 1 {
+		options.MaxHosts = 1000
+	}
+	return &Server{options: options, lru: list.New(), hosts: map[string]*list.Element{}}, nil
+}
+
+// report hands err to Options.OnError.
+func (s *Server) report(err error) {
+	if err != nil && s.options.OnError != nil {
+		s.options.OnError(err)
+	}
+}
+
+// ServeUDP handles one message per datagram until conn is closed.
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.report(s.Handle(string(buf[:n])))
+	}
+}
+
+// ServeTCP accepts connections until ln is closed, handling messages
+// framed by octet counting (RFC6587) or newline delimited.
+func (s *Server) ServeTCP(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, s.options.MaxMessageBytes)
+	for {
+		msg, err := readFrame(r, s.options.MaxMessageBytes)
+		if msg != "" {
+			s.report(s.Handle(msg))
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.report(err)
+			}
+			return
+		}
+	}
+}
+
+// readFrame reads one octet-counted or newline-delimited message.
+func readFrame(r *bufio.Reader, limit int) (string, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			err = nil // overlong message, send it in pieces
+		}
+		return strings.TrimRight(string(line), "\r\n"), err
+	}
+	n, err := readFrameLength(r, limit)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
+
+// readFrameLength reads the octet count ending with a space, failing
+// as soon as it exceeds limit.
+func readFrameLength(r *bufio.Reader, limit int) (int, error) {
+	var n int
+	for digits := 0; ; digits++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == ' ' && digits > 0 {
+			return n, nil
+		}
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("bad syslog frame length: unexpected %q", c)
+		}
+		n = 10*n + int(c-'0')
+		if n > limit {
+			return 0, fmt.Errorf("bad syslog frame length: over %d", limit)
+		}
+	}
+}
+
+// Handle parses and forwards one syslog message.
+// Messages without syslog priority are forwarded as plain user notices.
+func (s *Server) Handle(raw string) error {
+	now := s.options.Now()
+	m, err := Parse(raw, now)
+	if err != nil {
+		m = Message{Facility: 1, Severity: 5, Timestamp: now, Message: raw}
+	}
+	data, errJSON := json.Marshal(Event{
+		Facility:       facilityName(m.Facility),
+		Severity:       severityName(m.Severity),
+		Host:           m.Hostname,
+		App:            m.AppName,
+		ProcID:         m.ProcID,
+		MsgID:          m.MsgID,
+		StructuredData: m.StructuredData,
+		Message:        m.Message,
+	})
+	if errJSON != nil {
+		return errJSON
+	}
+	return s.logFor(m.Hostname).PutLogEvents([]types.InputLogEvent{{
+		Message:   aws.String(string(data)),
+		Timestamp: aws.Int64(m.Timestamp.UnixMilli()),
+	}})
+}
+
+// logFor returns the Log for host, cloned on first use with StreamByHost.
+// Hosts failing to clone are reported and use Log.
+func (s *Server) logFor(host string) *cwlog.Log {
+	if !s.options.StreamByHost || host == "" {
+		return s.options.Log
+	}
+	s.mu.Lock()
+	if elem, found := s.hosts[host]; found {
+		s.lru.MoveToFront(elem)
+		s.mu.Unlock()
+		return elem.Value.(*hostLog).log
+	}
+	l, err := s.options.Log.Clone(cwlog.Options{LogStream: streamReplacer.Replace(host)})
+	if err != nil {
+		s.mu.Unlock()
+		s.report(fmt.Errorf("syslog host %q: %w", host, err))
+		return s.options.Log
+	}
+	s.hosts[host] = s.lru.PushFront(&hostLog{host: host, log: l})
+	var evicted *hostLog
+	if s.lru.Len() > s.options.MaxHosts {
+		evicted = s.lru.Remove(s.lru.Back()).(*hostLog)
+		delete(s.hosts, evicted.host)
+	}
+	s.mu.Unlock()
+
+	if evicted != nil {
+		s.report(evicted.log.Close()) // flushes the clone
+	}
+	return l
+}
+
+// streamReplacer replaces characters forbidden in stream names.
+var streamReplacer = strings.NewReplacer(":", "_", "*", "_")
+
+// Flush flushes per-host streams and Log.
+func (s *Server) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := []error{s.options.Log.Flush()}
+	for _, elem := range s.hosts {
+		errs = append(errs, elem.Value.(*hostLog).log.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+var facilities = []string{"kern", "user", "mail", "daemon", "auth", "syslog",
+	"lpr", "news", "uucp", "cron", "authpriv", "ftp", "ntp", "security",
+	"console", "solaris-cron", "local0", "local1", "local2", "local3",
+	"local4", "local5", "local6", "local7"}
+
+var severities = []string{"emerg", "alert", "crit", "err", "warning",
+	"notice", "info", "debug"}
+
+func facilityName(f int) string {
+	if f < len(facilities) {
+		return facilities[f]
+	}
+	return strconv.Itoa(f)
+}
+
+func severityName(s int) string {
+	return severities[s&7]
+}
@@ -0,0 +1,116 @@
+package cwlogsyslog
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+func TestServerTCP(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/syslog",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, errServer := New(Options{Log: l, StreamByHost: true})
+	if errServer != nil {
+		t.Fatal(errServer)
+	}
+
+	ln, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatal(errListen)
+	}
+	go s.ServeTCP(ln)
+	defer ln.Close()
+
+	conn, errDial := net.Dial("tcp", ln.Addr().String())
+	if errDial != nil {
+		t.Fatal(errDial)
+	}
+	msg := "<11>1 2024-05-31T22:14:15Z web1 nginx - - - upstream down"
+	conn.Write([]byte("<13>Oct 11 22:14:15 db1 cron: job done\n"))
+	conn.Write([]byte(strconv.Itoa(len(msg)) + " " + msg))
+	conn.Close()
+
+	var msgs []string
+	for range 100 {
+		if msgs = client.Messages("/syslog"); len(msgs) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expected := []string{
+		`{"facility":"user","severity":"notice","host":"db1","app":"cron","msg":"job done"}`,
+		`{"facility":"user","severity":"err","host":"web1","app":"nginx","msg":"upstream down"}`,
+	}
+	if len(msgs) != 2 || msgs[0] != expected[0] || msgs[1] != expected[1] {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.hosts) != 2 {
+		t.Errorf("expected one stream per host, got: %d", len(s.hosts))
+	}
+}
+
+func TestServerHosts(t *testing.T) {
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/syslog",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, errServer := New(Options{Log: l, StreamByHost: true, MaxHosts: 2})
+	if errServer != nil {
+		t.Fatal(errServer)
+	}
+
+	for _, host := range []string{"2001:db8::1", "web1", "web2"} {
+		if err := s.Handle("<13>1 2024-05-31T22:14:15Z " + host + " app - - - hello"); err != nil {
+			t.Fatalf("host %s: %v", host, err)
+		}
+	}
+	if msgs := client.StreamMessages("/syslog", "2001_db8__1-0001-01-01-00"); len(msgs) != 1 {
+		t.Errorf("IPv6 host stream: %v", msgs)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.hosts["2001:db8::1"]; found || len(s.hosts) != 2 {
+		t.Errorf("expected least recently used host evicted, got: %d hosts", len(s.hosts))
+	}
+}
+
+func TestReadFrameLength(t *testing.T) {
+	table := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"valid", "5 hello", true},
+		{"over limit", "11 hello world", false},
+		{"endless digits", strings.Repeat("9", 100000), false},
+		{"not a number", "5x hello", false},
+	}
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		msg, err := readFrame(bufio.NewReader(strings.NewReader(data.input)), 10)
+		if ok := err == nil; ok != data.ok {
+			t.Errorf("%s: expected ok=%t got msg=%q err=%v", name, data.ok, msg, err)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package cwlogtail
+
+import "github.com/udhos/cloudwatchlog/cwlog"
+
+type accessLogParser struct{}
+
+// NewAccessLogParser parses Apache/NGINX Common or Combined Log Format
+// lines into JSON events timestamped by the request time, as described
+// in cwlog.ParseAccessLog. Unparsable lines are sent as is.
+func NewAccessLogParser() Parser {
+	return accessLogParser{}
+}
+
+func (accessLogParser) Parse(line string) (Event, bool) {
+	msg, ts, ok := cwlog.AccessLogEvent(line)
+	if !ok {
+		return Event{Message: line}, true
+	}
+	return Event{Message: msg, Timestamp: ts}, true
+}
@@ -0,0 +1,138 @@
+package cwlogtail
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// containerRecord is the JSON event sent for container log lines.
+type containerRecord struct {
+	Stream string `json:"stream"`
+	Log    string `json:"log"`
+}
+
+// maxRecordBytes bounds reassembled partial lines. Records too large
+// once JSON encoded are split by splitRecord.
+const maxRecordBytes = maxLineBytes
+
+// containerParser reassembles partial container lines per stream.
+type containerParser struct {
+	split   func(line string) (stream, text string, ts time.Time, partial, ok bool)
+	pending map[string]*strings.Builder
+}
+
+// NewDockerParser parses Docker json-file lines like
+// {"log":"hello\n","stream":"stdout","time":"2024-05-31T22:14:15.1Z"}
+// into JSON events {"stream":"stdout","log":"hello"}, timestamped by the
+// "time" field. Lines split by Docker are reassembled.
+// Unparsable lines are sent as is. Records larger than one event once
+// JSON encoded are split into several events by the Tailer; Parse
+// returns the first of them.
+func NewDockerParser() Parser {
+	return &containerParser{split: splitDocker, pending: map[string]*strings.Builder{}}
+}
+
+// NewCRIParser parses Kubernetes CRI lines like
+// "2024-05-31T22:14:15.1Z stdout F hello" into JSON events
+// {"stream":"stdout","log":"hello"}, timestamped by the first field.
+// Partial (P) lines are reassembled. Unparsable lines are sent as is.
+// Large records are split as with NewDockerParser.
+func NewCRIParser() Parser {
+	return &containerParser{split: splitCRI, pending: map[string]*strings.Builder{}}
+}
+
+func (p *containerParser) Parse(line string) (Event, bool) {
+	events := p.parseSplit(line)
+	if len(events) == 0 {
+		return Event{}, false
+	}
+	return events[0], true
+}
+
+func (p *containerParser) parseSplit(line string) []Event {
+	stream, text, ts, partial, ok := p.split(line)
+	if !ok {
+		return []Event{{Message: line}}
+	}
+	b := p.pending[stream]
+	if b == nil {
+		b = &strings.Builder{}
+		p.pending[stream] = b
+	}
+	b.WriteString(text)
+	if partial && b.Len() < maxRecordBytes {
+		return nil
+	}
+	text = b.String()
+	b.Reset()
+	var events []Event
+	for _, msg := range splitRecord(stream, text) {
+		events = append(events, Event{Message: msg, Timestamp: ts})
+	}
+	return events
+}
+
+// splitRecord encodes text as containerRecord messages, split so that
+// each encoding fits maxLineBytes, since JSON escaping grows text up to
+// six times, like \u0001 for control characters.
+func splitRecord(stream, text string) []string {
+	var msgs []string
+	for {
+		msg := encodeRecord(stream, text)
+		if len(msg) <= maxLineBytes {
+			return append(msgs, msg)
+		}
+		// bisect the longest fitting prefix, which encodes to more
+		// bytes than its length
+		fits, overflows := 0, min(len(text), maxLineBytes)
+		for overflows-fits > 1 {
+			mid := (fits + overflows) / 2
+			if len(encodeRecord(stream, text[:mid])) <= maxLineBytes {
+				fits = mid
+			} else {
+				overflows = mid
+			}
+		}
+		cut := max(cwlog.RuneCut(text, fits), 1)
+		msgs = append(msgs, encodeRecord(stream, text[:cut]))
+		text = text[cut:]
+	}
+}
+
+func encodeRecord(stream, text string) string {
+	data, _ := json.Marshal(containerRecord{Stream: stream, Log: text})
+	return string(data)
+}
+
+func splitDocker(line string) (string, string, time.Time, bool, bool) {
+	var rec struct {
+		Log    string    `json:"log"`
+		Stream string    `json:"stream"`
+		Time   time.Time `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(line), &rec); err != nil || rec.Stream == "" {
+		return "", "", time.Time{}, false, false
+	}
+	text, complete := strings.CutSuffix(rec.Log, "\n")
+	return rec.Stream, text, rec.Time, !complete, true
+}
+
+func splitCRI(line string) (string, string, time.Time, bool, bool) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) < 3 {
+		return "", "", time.Time{}, false, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return "", "", time.Time{}, false, false
+	}
+	var text string
+	if len(fields) == 4 {
+		text = fields[3]
+	}
+	tag, _, _ := strings.Cut(fields[2], ":") // tags may carry more flags
+	return fields[1], text, ts, tag == "P", true
+}
@@ -0,0 +1,100 @@
+package cwlogtail
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestContainerParsers(t *testing.T) {
+	ts := time.Date(2024, 5, 31, 22, 14, 15, 100000000, time.UTC)
+
+	table := []struct {
+		name     string
+		parser   Parser
+		lines    []string
+		expected []Event
+	}{
+		{"docker", NewDockerParser(), []string{
+			`{"log":"hello\n","stream":"stdout","time":"2024-05-31T22:14:15.1Z"}`,
+			`{"log":"part 1 ","stream":"stderr","time":"2024-05-31T22:14:15.1Z"}`,
+			`{"log":"part 2\n","stream":"stderr","time":"2024-05-31T22:14:15.1Z"}`,
+			`not json`,
+		}, []Event{
+			{`{"stream":"stdout","log":"hello"}`, ts},
+			{`{"stream":"stderr","log":"part 1 part 2"}`, ts},
+			{Message: "not json"},
+		}},
+		{"cri", NewCRIParser(), []string{
+			"2024-05-31T22:14:15.1Z stdout F hello world",
+			"2024-05-31T22:14:15.1Z stderr P part 1 ",
+			"2024-05-31T22:14:15.1Z stderr F part 2",
+			"2024-05-31T22:14:15.1Z stdout F",
+			"garbage",
+		}, []Event{
+			{`{"stream":"stdout","log":"hello world"}`, ts},
+			{`{"stream":"stderr","log":"part 1 part 2"}`, ts},
+			{`{"stream":"stdout","log":""}`, ts},
+			{Message: "garbage"},
+		}},
+		{"access log", NewAccessLogParser(), []string{
+			`10.0.0.1 - - [31/May/2024:22:14:15 +0000] "GET / HTTP/1.1" 200 5 "-" "curl/8.0"`,
+			"garbage",
+		}, []Event{
+			{`{"remote_addr":"10.0.0.1","method":"GET","path":"/","protocol":"HTTP/1.1","status":200,"bytes":5,"user_agent":"curl/8.0"}`,
+				time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC)},
+			{Message: "garbage"},
+		}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			var got []Event
+			for _, line := range data.lines {
+				if ev, ok := data.parser.Parse(line); ok {
+					got = append(got, ev)
+				}
+			}
+			if len(got) != len(data.expected) {
+				t.Fatalf("expected=%v got=%v", data.expected, got)
+			}
+			for j, ev := range got {
+				if ev.Message != data.expected[j].Message || !ev.Timestamp.Equal(data.expected[j].Timestamp) {
+					t.Errorf("event %d: expected=%v got=%v", j, data.expected[j], ev)
+				}
+			}
+		})
+	}
+}
+
+func TestContainerParserEscaping(t *testing.T) {
+	// control characters grow six times once JSON encoded
+	text := strings.Repeat("\x01", maxLineBytes/2)
+	line := "2024-05-31T22:14:15.1Z stdout F " + text
+
+	f := &fileTail{parser: NewCRIParser(), options: &Options{Now: time.Now}}
+	events := f.appendEvent(nil, line)
+	if len(events) < 2 {
+		t.Fatalf("expected split record, got %d events", len(events))
+	}
+	var joined strings.Builder
+	for _, e := range events {
+		msg := aws.ToString(e.Message)
+		if len(msg) > maxLineBytes {
+			t.Errorf("event too large: %d", len(msg))
+		}
+		var rec containerRecord
+		if err := json.Unmarshal([]byte(msg), &rec); err != nil {
+			t.Fatal(err)
+		}
+		joined.WriteString(rec.Log)
+	}
+	if joined.String() != text {
+		t.Errorf("record data lost: %d of %d bytes", joined.Len(), len(text))
+	}
+}
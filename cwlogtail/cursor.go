@@ -0,0 +1,104 @@
+package cwlogtail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// Cursor is the read position of one file.
+type Cursor struct {
+	// Inode identifies the file, to detect rotation while stopped.
+	// Zero on platforms without inodes.
+	Inode uint64 `json:"inode,omitempty"`
+
+	// Offset is the end of the last line sent.
+	Offset int64 `json:"offset"`
+}
+
+// CursorStore persists cursors keyed by file path, so the tailer resumes
+// where it left off after restart.
+type CursorStore interface {
+	Load() (map[string]Cursor, error)
+	Save(cursors map[string]Cursor) error
+}
+
+// FileStore is a CursorStore keeping cursors in a local JSON file.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store backed by the file path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the cursors. A missing file holds no cursors.
+func (s *FileStore) Load() (map[string]Cursor, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Cursor{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cursor load error: %w", err)
+	}
+	cursors := map[string]Cursor{}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("cursor decode error: %s: %w", s.path, err)
+	}
+	return cursors, nil
+}
+
+// Save replaces the cursors atomically, by renaming a temporary file.
+func (s *FileStore) Save(cursors map[string]Cursor) error {
+	data, err := json.Marshal(cursors)
+	if err != nil {
+		return err
+	}
+	tmp, errTemp := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if errTemp != nil {
+		return fmt.Errorf("cursor save error: %w", errTemp)
+	}
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if err := errors.Join(errWrite, errClose); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cursor save error: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cursor save error: %w", err)
+	}
+	return nil
+}
+
+// checkpoint saves the cursors when they changed since the last save.
+// The Log is flushed first, so buffered lines are not lost on crash.
+func (t *Tailer) checkpoint() error {
+	if t.options.CursorStore == nil {
+		return nil
+	}
+	cursors := map[string]Cursor{}
+	for path, f := range t.files {
+		if f.file != nil {
+			cursors[path] = Cursor{Inode: inode(f.info), Offset: f.offset}
+		} else if c, found := t.cursors[path]; found {
+			cursors[path] = c // not reopened yet
+		}
+	}
+	if maps.Equal(cursors, t.cursors) {
+		return nil
+	}
+	if err := t.options.Log.Flush(); err != nil {
+		return err
+	}
+	if err := t.options.CursorStore.Save(cursors); err != nil {
+		return err
+	}
+	t.cursors = cursors
+	return nil
+}
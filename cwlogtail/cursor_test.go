@@ -0,0 +1,47 @@
+package cwlogtail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTailResume(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	store := NewFileStore(filepath.Join(dir, "cursors.json"))
+
+	tailer, client := newTestTailer(t, Options{Paths: []string{path}, CursorStore: store})
+	appendFile(t, path, "one\ntw")
+	poll(t, tailer)
+	tailer.close()
+
+	// restart: resumes at the incomplete line
+	tailer, client2 := newTestTailer(t, Options{Paths: []string{path}, CursorStore: store, StartAtEnd: true})
+	appendFile(t, path, "o\n")
+	poll(t, tailer)
+	tailer.close()
+
+	// replaced while stopped: read from the beginning
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "x\ny\n")
+	tailer, client3 := newTestTailer(t, Options{Paths: []string{path}, CursorStore: store})
+	poll(t, tailer)
+	tailer.close()
+
+	for i, data := range []struct {
+		got      []string
+		expected []string
+	}{
+		{client.Messages("/tail"), []string{"one"}},
+		{client2.Messages("/tail"), []string{"two"}},
+		{client3.Messages("/tail"), []string{"x", "y"}},
+	} {
+		if !reflect.DeepEqual(data.got, data.expected) {
+			t.Errorf("run %d: expected=%v got=%v", i+1, data.expected, data.got)
+		}
+	}
+}
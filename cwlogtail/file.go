@@ -0,0 +1,218 @@
+package cwlogtail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// maxLineBytes splits longer lines into several events.
+const maxLineBytes = cwlog.MaxEventSize - cwlog.EventOverhead
+
+// fileTail follows one path.
+type fileTail struct {
+	path    string
+	log     *cwlog.Log
+	options *Options
+	skip    bool    // StartAtEnd for the first open
+	resume  *Cursor // saved position for the first open
+	parser  Parser
+
+	file    *os.File
+	info    os.FileInfo // of the open file, to detect rotation
+	offset  int64       // end of the last complete line
+	partial []byte      // incomplete last line
+	buf     []byte
+}
+
+func newFileTail(path string, l *cwlog.Log, options *Options, skip bool) *fileTail {
+	f := &fileTail{path: path, log: l, options: options, skip: skip,
+		buf: make([]byte, 64*1024)}
+	if options.Parser != nil {
+		f.parser = options.Parser()
+	}
+	return f
+}
+
+// poll sends lines appended since the last poll, switching to the new
+// file after rotation and restarting from the beginning after truncation.
+func (f *fileTail) poll() error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+		if f.file == nil {
+			return nil // not there yet
+		}
+	}
+
+	info, errStat := os.Stat(f.path)
+	if errStat == nil && !os.SameFile(info, f.info) {
+		// rotated: drain the old file, then follow the new one
+		if err := f.read(); err != nil {
+			return err // drained again on the next poll
+		}
+		f.close()
+		return f.poll()
+	}
+
+	if cur, err := f.file.Stat(); err == nil && cur.Size() < f.offset+int64(len(f.partial)) {
+		// truncated: start over
+		f.offset = 0
+		f.partial = f.partial[:0]
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	return f.read()
+}
+
+func (f *fileTail) open() error {
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		f.skip = false // created later: read it all
+		f.resume = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info, errStat := file.Stat()
+	if errStat != nil {
+		file.Close()
+		return errStat
+	}
+	f.file, f.info = file, info
+	f.offset = 0
+	f.partial = f.partial[:0]
+	resume, skip := f.resume, f.skip
+	f.resume, f.skip = nil, false
+	switch {
+	case resume != nil && resume.Inode == inode(info) && resume.Offset <= info.Size():
+		f.offset, err = file.Seek(resume.Offset, io.SeekStart)
+	case resume != nil:
+		// replaced or truncated while stopped: read it all
+	case skip:
+		f.offset, err = file.Seek(0, io.SeekEnd)
+	}
+	return err
+}
+
+func (f *fileTail) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// read sends the complete lines available.
+func (f *fileTail) read() error {
+	for {
+		n, errRead := f.file.Read(f.buf)
+		if n > 0 {
+			if err := f.send(f.buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errRead == io.EOF {
+			return nil
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+}
+
+// send appends data to the partial line and sends the complete lines.
+// On transient failure the file is rewound to the last line sent, so
+// the lines are read again on the next poll. Lines failing permanently,
+// as classified by cwlog.ClassValidation, are skipped instead, reaching
+// the Log FallbackWriter if defined.
+func (f *fileTail) send(data []byte) error {
+	buf := append(f.partial, data...)
+	f.partial = buf
+	var events []types.InputLogEvent
+	var consumed int64
+	for {
+		i := bytes.IndexByte(f.partial, '\n')
+		if i < 0 && len(f.partial) <= maxLineBytes {
+			break
+		}
+		cut, next := i, i+1
+		if i < 0 || i > maxLineBytes {
+			cut = cwlog.RuneCut(f.partial, maxLineBytes)
+			next = cut
+		}
+		line := bytes.TrimSuffix(f.partial[:cut], []byte{'\r'})
+		if len(line) > 0 {
+			events = f.appendEvent(events, string(line))
+		}
+		f.partial = f.partial[next:]
+		consumed += int64(next)
+	}
+	f.partial = buf[:copy(buf, f.partial)]
+	if err := f.put(events); err != nil {
+		if cwlog.Classify(err) == cwlog.ClassValidation {
+			f.offset += consumed
+			return fmt.Errorf("skipped %d lines: %s: %w", len(events), f.path, err)
+		}
+		f.partial = f.partial[:0]
+		if _, errSeek := f.file.Seek(f.offset, io.SeekStart); errSeek != nil {
+			return errors.Join(err, errSeek)
+		}
+		return err
+	}
+	f.offset += consumed
+	return nil
+}
+
+func (f *fileTail) put(events []types.InputLogEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if f.options.Severity != nil {
+		return f.log.PutLogEventsSeverity(events, f.options.Severity)
+	}
+	return f.log.PutLogEvents(events)
+}
+
+// appendEvent appends the events for line, if complete.
+func (f *fileTail) appendEvent(events []types.InputLogEvent, line string) []types.InputLogEvent {
+	ev := Event{Message: line}
+	switch p := f.parser.(type) {
+	case splitParser:
+		for _, ev := range p.parseSplit(line) {
+			events = f.appendParsed(events, ev)
+		}
+		return events
+	case Parser:
+		var ok bool
+		if ev, ok = p.Parse(line); !ok {
+			return events
+		}
+	default:
+		if f.options.Timestamp != nil {
+			ev.Timestamp, _ = parseTimestamp(line, f.options)
+		}
+	}
+	return f.appendParsed(events, ev)
+}
+
+// appendParsed appends ev, timestamped now if undefined.
+func (f *fileTail) appendParsed(events []types.InputLogEvent, ev Event) []types.InputLogEvent {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = f.options.Now()
+	}
+	return append(events, types.InputLogEvent{
+		Message:   aws.String(ev.Message),
+		Timestamp: aws.Int64(ev.Timestamp.UnixMilli()),
+	})
+}
@@ -0,0 +1,26 @@
+package cwlogtail
+
+import "time"
+
+// Parser converts raw lines into events. One Parser is created per file,
+// hence it may keep state, like partial lines being reassembled.
+type Parser interface {
+	// Parse returns the event for line. ok is false when line does not
+	// complete an event yet.
+	Parse(line string) (event Event, ok bool)
+}
+
+// Event is a parsed line.
+type Event struct {
+	Message string
+
+	// Timestamp is optional. If zero, the time the line was read is used.
+	Timestamp time.Time
+}
+
+// splitParser is implemented by parsers turning one line into several
+// events, like the container parsers splitting records too large for
+// one event. The Tailer prefers it to Parse.
+type splitParser interface {
+	parseSplit(line string) []Event
+}
@@ -0,0 +1,164 @@
+// Package cwlogtail tails files into CloudWatch Logs through cwlog,
+// a lightweight alternative to the CloudWatch agent.
+package cwlogtail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Options define settings for Tailer.
+type Options struct {
+	// Log is required. Enable cwlog.Options.Async to batch lines
+	// across files.
+	Log *cwlog.Log
+
+	// Paths lists the files to tail. Missing files are picked up when
+	// they appear.
+	Paths []string
+
+	// Globs lists filepath.Match patterns, like "/var/log/app/*.log".
+	// Matching files are tailed as they appear, and dropped when they
+	// no longer exist.
+	Globs []string
+
+	// StreamName optionally sends each file to its own stream, mapping
+	// the file path into the LogStream field of the Log stream template,
+	// for example filepath.Base. If undefined, all files share the Log stream.
+	StreamName func(path string) string
+
+	// PollInterval is the delay between checks for new data.
+	// Defaults to 1s.
+	PollInterval time.Duration
+
+	// StartAtEnd skips data already present in files found at startup.
+	// Files created later are always read from the beginning.
+	StartAtEnd bool
+
+	// TimestampLayout optionally parses the event timestamp from the
+	// beginning of each line, using a time.Parse layout like
+	// time.RFC3339Nano or "2006-01-02 15:04:05". Lines without a
+	// matching timestamp get the time they were read.
+	TimestampLayout string
+
+	// Timestamp optionally extracts the event timestamp from each line,
+	// replacing TimestampLayout, like cwlog.RegexTimestamp, or
+	// cwlog.FirstTimestamp for files mixing formats.
+	Timestamp cwlog.TimestampExtractor
+
+	// Severity optionally sends every line as a structured event with
+	// the level found by Severity, like cwlog.KeywordSeverity, so plain
+	// text files get a level field and honor the Log level settings.
+	// See cwlog.Log.PutLogEventsSeverity.
+	Severity cwlog.SeverityParser
+
+	// Parser optionally creates the parser for each file, like
+	// NewDockerParser or NewCRIParser. TimestampLayout and Timestamp are
+	// ignored when a parser is defined.
+	Parser func() Parser
+
+	// Location is used for timestamps without time zone. Defaults to UTC.
+	Location *time.Location
+
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	// CursorStore optionally persists read offsets, so tailing resumes
+	// where it left off after restart, instead of honoring StartAtEnd.
+	// Offsets are saved after each poll that sent lines, flushing the
+	// Log first. See NewFileStore.
+	CursorStore CursorStore
+}
+
+// Tailer follows files, handling rotation by rename and truncation,
+// sending each complete line as one event.
+type Tailer struct {
+	options Options
+	files   map[string]*fileTail
+	static  map[string]bool // from Paths, kept even when missing
+	started bool
+	cursors map[string]Cursor // last saved
+}
+
+// New creates a tailer.
+func New(options Options) (*Tailer, error) {
+	if options.Log == nil {
+		return nil, errors.New("Log is required")
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.Timestamp == nil && options.TimestampLayout != "" {
+		options.Timestamp = cwlog.LayoutTimestamp(options.TimestampLayout, options.Location)
+	}
+	for _, g := range options.Globs {
+		if _, err := filepath.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("bad glob: %s: %w", g, err)
+		}
+	}
+	t := &Tailer{
+		options: options,
+		files:   map[string]*fileTail{},
+		static:  map[string]bool{},
+	}
+	for _, path := range options.Paths {
+		t.static[path] = true
+	}
+	if options.CursorStore != nil {
+		cursors, err := options.CursorStore.Load()
+		if err != nil {
+			return nil, err
+		}
+		t.cursors = cursors
+	}
+	return t, nil
+}
+
+// Run tails the files until ctx is done, then flushes the Log.
+// Incomplete last lines are left unsent. Errors do not stop Run,
+// failing files are retried on the next poll, except for lines rejected
+// permanently, which are skipped.
+func (t *Tailer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.options.PollInterval)
+	defer ticker.Stop()
+	defer t.close()
+	for {
+		t.Poll()
+		select {
+		case <-ctx.Done():
+			return errors.Join(t.options.Log.Flush(), t.checkpoint())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll looks for new files, then reads new data from all files once,
+// saving cursors if enabled. Run calls it periodically.
+func (t *Tailer) Poll() error {
+	errs := []error{t.discover()}
+	for _, path := range slices.Sorted(maps.Keys(t.files)) {
+		errs = append(errs, t.files[path].poll())
+	}
+	t.started = true
+	errs = append(errs, t.checkpoint())
+	return errors.Join(errs...)
+}
+
+func (t *Tailer) close() {
+	for _, f := range t.files {
+		f.close()
+	}
+}
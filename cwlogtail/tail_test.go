@@ -0,0 +1,235 @@
+package cwlogtail
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+)
+
+func newTestTailer(t *testing.T, options Options) (*Tailer, *cwlogtest.Client) {
+	t.Helper()
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/tail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Log = l
+	tailer, errTailer := New(options)
+	if errTailer != nil {
+		t.Fatal(errTailer)
+	}
+	return tailer, client
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func poll(t *testing.T, tailer *Tailer) {
+	t.Helper()
+	if err := tailer.Poll(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTailRotationAndTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tailer, client := newTestTailer(t, Options{Paths: []string{path}})
+	defer tailer.close()
+
+	poll(t, tailer) // missing file is fine
+
+	appendFile(t, path, "one\ntw")
+	poll(t, tailer)
+	appendFile(t, path, "o\n")
+	poll(t, tailer)
+
+	// rotation by rename: old data is drained before switching
+	appendFile(t, path, "three\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "four\n")
+	poll(t, tailer)
+
+	// truncation
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "5\n")
+	poll(t, tailer)
+
+	expected := []string{"one", "two", "three", "four", "5"}
+	if msgs := client.Messages("/tail"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+func TestTailStartAtEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old\n")
+
+	tailer, client := newTestTailer(t, Options{Paths: []string{path}, StartAtEnd: true})
+	defer tailer.close()
+
+	poll(t, tailer)
+	appendFile(t, path, "new\n")
+	poll(t, tailer)
+
+	expected := []string{"new"}
+	if msgs := client.Messages("/tail"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+func TestTailLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tailer, client := newTestTailer(t, Options{Paths: []string{path}})
+	defer tailer.close()
+
+	appendFile(t, path, strings.Repeat("é", maxLineBytes)+"\n")
+	poll(t, tailer)
+
+	msgs := client.Messages("/tail")
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(msgs))
+	}
+	for _, m := range msgs {
+		if len(m) > maxLineBytes || strings.ContainsRune(m, '�') {
+			t.Errorf("bad split: len=%d", len(m))
+		}
+	}
+	if len(msgs[0])+len(msgs[1]) != 2*maxLineBytes {
+		t.Errorf("data lost: %d", len(msgs[0])+len(msgs[1]))
+	}
+}
+
+func TestTailSeverity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tailer, client := newTestTailer(t, Options{Paths: []string{path}, Severity: cwlog.KeywordSeverity()})
+	defer tailer.close()
+
+	appendFile(t, path, "2024-05-31 ERROR disk full\nstarting\n")
+	poll(t, tailer)
+
+	expected := []string{
+		`{"level":"ERROR","msg":"2024-05-31 ERROR disk full"}`,
+		`{"level":"INFO","msg":"starting"}`,
+	}
+	if msgs := client.Messages("/tail"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+// failingClient fails PutLogEvents while fail is set, with a
+// validation error if invalid is set.
+type failingClient struct {
+	*cwlogtest.Client
+	fail    atomic.Bool
+	invalid atomic.Bool
+}
+
+func (c *failingClient) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if c.invalid.Load() {
+		return nil, &types.InvalidParameterException{Message: aws.String("bad event")}
+	}
+	if c.fail.Load() {
+		return nil, errors.New("put log denied")
+	}
+	return c.Client.PutLogEvents(ctx, params, optFns...)
+}
+
+func TestTailRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	client := &failingClient{Client: cwlogtest.NewClient()}
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/tail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tailer, err := New(Options{Paths: []string{path}, Log: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tailer.close()
+
+	appendFile(t, path, "one\n")
+	poll(t, tailer)
+
+	client.fail.Store(true)
+	appendFile(t, path, "two\nthr")
+	if err := tailer.Poll(); err == nil {
+		t.Fatal("expected delivery error")
+	}
+
+	client.fail.Store(false)
+	appendFile(t, path, "ee\n")
+	poll(t, tailer)
+
+	expected := []string{"one", "two", "three"}
+	if msgs := client.Messages("/tail"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
+
+func TestTailSkipsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	client := &failingClient{Client: cwlogtest.NewClient()}
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/tail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tailer, err := New(Options{Paths: []string{path}, Log: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tailer.close()
+
+	client.invalid.Store(true)
+	appendFile(t, path, "bad\n")
+	if err := tailer.Poll(); cwlog.Classify(err) != cwlog.ClassValidation {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	client.invalid.Store(false)
+	appendFile(t, path, "good\n")
+	poll(t, tailer)
+
+	expected := []string{"good"}
+	if msgs := client.Messages("/tail"); !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("expected=%v got=%v", expected, msgs)
+	}
+}
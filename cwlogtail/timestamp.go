@@ -0,0 +1,15 @@
+package cwlogtail
+
+import (
+	"time"
+)
+
+// parseTimestamp extracts the timestamp of line with options.Timestamp.
+// Layouts without year, like time.Stamp, get the current year.
+func parseTimestamp(line string, options *Options) (time.Time, bool) {
+	t, ok := options.Timestamp(line)
+	if ok && t.Year() == 0 {
+		t = t.AddDate(options.Now().In(options.Location).Year(), 0, 0)
+	}
+	return t, ok
+}
@@ -0,0 +1,41 @@
+package cwlogtail
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	options := &Options{Location: time.UTC, Now: func() time.Time { return now }}
+
+	table := []struct {
+		name   string
+		layout string
+		line   string
+		ok     bool
+		want   time.Time
+	}{
+		{"rfc3339nano", time.RFC3339Nano, "2024-05-31T22:14:15.5Z msg", true,
+			time.Date(2024, 5, 31, 22, 14, 15, 500000000, time.UTC)},
+		{"two fields", "2006-01-02 15:04:05", "2024-05-31 22:14:15 msg", true,
+			time.Date(2024, 5, 31, 22, 14, 15, 0, time.UTC)},
+		{"padded day", time.Stamp, "May  1 22:14:15 msg", true,
+			time.Date(2024, 5, 1, 22, 14, 15, 0, time.UTC)},
+		{"no match", time.RFC3339, "hello world", false, time.Time{}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			options.Timestamp = cwlog.LayoutTimestamp(data.layout, options.Location)
+			got, ok := parseTimestamp(data.line, options)
+			if ok != data.ok || !got.Equal(data.want) {
+				t.Errorf("expected=%v,%v got=%v,%v", data.want, data.ok, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cwlogtail
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// discover starts tails for new paths and glob matches, and drops tails
+// of glob matches no longer present, after draining them.
+func (t *Tailer) discover() error {
+	var errs []error
+	matches := map[string]bool{}
+	for _, g := range t.options.Globs {
+		paths, _ := filepath.Glob(g) // patterns validated in New
+		for _, path := range paths {
+			matches[path] = true
+		}
+	}
+
+	for path := range t.static {
+		errs = append(errs, t.watch(path))
+	}
+	for path := range matches {
+		errs = append(errs, t.watch(path))
+	}
+
+	for path, f := range t.files {
+		if t.static[path] || matches[path] {
+			continue
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, f.poll())
+			f.close()
+			delete(t.files, path)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// watch starts tailing path, if not yet tailed.
+// Files found before the first poll honor StartAtEnd.
+func (t *Tailer) watch(path string) error {
+	if _, found := t.files[path]; found {
+		return nil
+	}
+	l := t.options.Log
+	if t.options.StreamName != nil {
+		clone, err := l.Clone(cwlog.Options{LogStream: t.options.StreamName(path)})
+		if err != nil {
+			return err
+		}
+		l = clone
+	}
+	skip := t.options.StartAtEnd && !t.started
+	f := newFileTail(path, l, &t.options, skip)
+	if c, found := t.cursors[path]; found {
+		f.resume = &c
+	}
+	t.files[path] = f
+	return nil
+}
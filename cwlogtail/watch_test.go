@@ -0,0 +1,46 @@
+package cwlogtail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTailGlob(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "a.log"), "old a\n")
+
+	tailer, client := newTestTailer(t, Options{
+		Globs:      []string{filepath.Join(dir, "*.log")},
+		StartAtEnd: true,
+		StreamName: func(path string) string {
+			return strings.TrimSuffix(filepath.Base(path), ".log")
+		},
+	})
+	defer tailer.close()
+
+	poll(t, tailer)
+	appendFile(t, filepath.Join(dir, "a.log"), "new a\n")
+	appendFile(t, filepath.Join(dir, "b.log"), "b1\n") // appears later: read from start
+	appendFile(t, filepath.Join(dir, "c.txt"), "ignored\n")
+	poll(t, tailer)
+
+	if err := os.Remove(filepath.Join(dir, "b.log")); err != nil {
+		t.Fatal(err)
+	}
+	poll(t, tailer)
+	if _, found := tailer.files[filepath.Join(dir, "b.log")]; found {
+		t.Error("removed file still tailed")
+	}
+
+	for stream, expected := range map[string][]string{
+		"a-0001-01-01-00": {"new a"},
+		"b-0001-01-01-00": {"b1"},
+	} {
+		if msgs := client.StreamMessages("/tail", stream); !reflect.DeepEqual(msgs, expected) {
+			t.Errorf("stream %s: expected=%v got=%v", stream, expected, msgs)
+		}
+	}
+}
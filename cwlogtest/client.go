@@ -0,0 +1,265 @@
+package cwlogtest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// Client is an in-memory cwlog.CloudWatchLogClient that keeps every
+// event put, for tests of packages built on top of cwlog.
+//
+// Besides the write path it serves GetLogEvents, FilterLogEvents,
+// DescribeLogStreams and the delete calls from the stored events,
+// and simulates retention expiry with Expire.
+type Client struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	seq    int
+	groups map[string]*fakeGroup
+}
+
+type fakeGroup struct {
+	retentionInDays int32
+	streams         map[string]*fakeStream
+}
+
+type fakeStream struct {
+	creationTime int64
+	events       []storedEvent // arrival order
+}
+
+type storedEvent struct {
+	RecordedEvent
+	id            string
+	ingestionTime int64
+}
+
+// NewClient creates an empty in-memory client.
+func NewClient() *Client {
+	return &Client{
+		now:    time.Now,
+		groups: map[string]*fakeGroup{},
+	}
+}
+
+// SetNow replaces the clock used for stream creation, ingestion
+// times and retention expiry. Pass FakeClock.Now to travel in time.
+func (c *Client) SetNow(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// group returns the named group, creating it if missing.
+// Caller must hold c.mu.
+func (c *Client) group(name string) *fakeGroup {
+	g := c.groups[name]
+	if g == nil {
+		g = &fakeGroup{streams: map[string]*fakeStream{}}
+		c.groups[name] = g
+	}
+	return g
+}
+
+// stream returns the named stream, creating it if missing.
+// Caller must hold c.mu.
+func (c *Client) stream(group, name string) *fakeStream {
+	g := c.group(group)
+	s := g.streams[name]
+	if s == nil {
+		s = &fakeStream{creationTime: c.now().UnixMilli()}
+		g.streams[name] = s
+	}
+	return s
+}
+
+// sortedStreams returns the stream names of g in lexical order.
+func (g *fakeGroup) sortedStreams() []string {
+	names := make([]string, 0, len(g.streams))
+	for name := range g.streams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Messages returns the messages put into group, across all streams,
+// in the order they were received.
+func (c *Client) Messages(group string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil
+	}
+	var all []storedEvent
+	for _, s := range g.streams {
+		all = append(all, s.events...)
+	}
+	slices.SortFunc(all, func(a, b storedEvent) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	var msgs []string
+	for _, e := range all {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// StreamMessages returns the messages put into one stream of group,
+// in the order they were received.
+func (c *Client) StreamMessages(group, stream string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil
+	}
+	s := g.streams[stream]
+	if s == nil {
+		return nil
+	}
+	var msgs []string
+	for _, e := range s.events {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// RetentionInDays returns the retention set for group, or 0 when
+// events never expire.
+func (c *Client) RetentionInDays(group string) int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g := c.groups[group]; g != nil {
+		return g.retentionInDays
+	}
+	return 0
+}
+
+// Expire deletes the events older than the retention of their group,
+// as measured by the client clock, returning how many were deleted.
+// Like CloudWatch Logs, empty streams are kept.
+func (c *Client) Expire() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var expired int
+	for _, g := range c.groups {
+		if g.retentionInDays <= 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -int(g.retentionInDays)).UnixMilli()
+		for _, s := range g.streams {
+			before := len(s.events)
+			s.events = slices.DeleteFunc(s.events, func(e storedEvent) bool {
+				return e.Timestamp < cutoff
+			})
+			expired += before - len(s.events)
+		}
+	}
+	return expired
+}
+
+// CreateLogGroup always succeeds.
+func (c *Client) CreateLogGroup(_ context.Context,
+	params *cloudwatchlogs.CreateLogGroupInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	c.mu.Lock()
+	c.group(aws.ToString(params.LogGroupName))
+	c.mu.Unlock()
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+// PutRetentionPolicy records the retention used by Expire.
+func (c *Client) PutRetentionPolicy(_ context.Context,
+	params *cloudwatchlogs.PutRetentionPolicyInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	c.mu.Lock()
+	c.group(aws.ToString(params.LogGroupName)).retentionInDays = aws.ToInt32(params.RetentionInDays)
+	c.mu.Unlock()
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+// CreateLogStream always succeeds.
+func (c *Client) CreateLogStream(_ context.Context,
+	params *cloudwatchlogs.CreateLogStreamInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.mu.Lock()
+	c.stream(aws.ToString(params.LogGroupName), aws.ToString(params.LogStreamName))
+	c.mu.Unlock()
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+// PutLogEvents stores a copy of the events.
+func (c *Client) PutLogEvents(_ context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stream(aws.ToString(params.LogGroupName), aws.ToString(params.LogStreamName))
+	ingestion := c.now().UnixMilli()
+	for _, e := range params.LogEvents {
+		c.seq++
+		s.events = append(s.events, storedEvent{
+			RecordedEvent: RecordedEvent{
+				Timestamp: aws.ToInt64(e.Timestamp),
+				Message:   aws.ToString(e.Message),
+			},
+			id:            eventID(c.seq),
+			ingestionTime: ingestion,
+		})
+	}
+
+	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+}
+
+// DeleteLogStream removes a stream and its events.
+func (c *Client) DeleteLogStream(_ context.Context,
+	params *cloudwatchlogs.DeleteLogStreamInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	group, stream := aws.ToString(params.LogGroupName), aws.ToString(params.LogStreamName)
+	g := c.groups[group]
+	if g == nil || g.streams[stream] == nil {
+		return nil, notFound("stream", group+"/"+stream)
+	}
+	delete(g.streams, stream)
+	return &cloudwatchlogs.DeleteLogStreamOutput{}, nil
+}
+
+// DeleteLogGroup removes a group with all its streams.
+func (c *Client) DeleteLogGroup(_ context.Context,
+	params *cloudwatchlogs.DeleteLogGroupInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	group := aws.ToString(params.LogGroupName)
+	if c.groups[group] == nil {
+		return nil, notFound("group", group)
+	}
+	delete(c.groups, group)
+	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
+}
+
+func notFound(kind, name string) error {
+	return &types.ResourceNotFoundException{
+		Message: aws.String(fmt.Sprintf("The specified log %s does not exist: %s", kind, name)),
+	}
+}
+
+// eventID formats a sequence number as a fixed width event ID,
+// so IDs sort in arrival order.
+func eventID(seq int) string {
+	return fmt.Sprintf("%020d", seq)
+}
@@ -0,0 +1,220 @@
+package cwlogtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func putEvents(t *testing.T, c *Client, group, stream string, events ...RecordedEvent) {
+	t.Helper()
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(group),
+		LogStreamName: aws.String(stream),
+	}
+	for _, e := range events {
+		input.LogEvents = append(input.LogEvents, types.InputLogEvent{
+			Timestamp: aws.Int64(e.Timestamp),
+			Message:   aws.String(e.Message),
+		})
+	}
+	if _, err := c.PutLogEvents(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientGetLogEvents(t *testing.T) {
+	c := NewClient()
+	putEvents(t, c, "g", "s",
+		RecordedEvent{Timestamp: 3, Message: "c"},
+		RecordedEvent{Timestamp: 1, Message: "a"},
+		RecordedEvent{Timestamp: 2, Message: "b"},
+	)
+
+	get := func(input cloudwatchlogs.GetLogEventsInput) ([]string, string) {
+		t.Helper()
+		input.LogGroupName = aws.String("g")
+		input.LogStreamName = aws.String("s")
+		out, err := c.GetLogEvents(context.Background(), &input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msgs []string
+		for _, e := range out.Events {
+			msgs = append(msgs, aws.ToString(e.Message))
+		}
+		return msgs, aws.ToString(out.NextForwardToken)
+	}
+
+	if msgs, _ := get(cloudwatchlogs.GetLogEventsInput{Limit: aws.Int32(2)}); !reflect.DeepEqual(msgs, []string{"b", "c"}) {
+		t.Errorf("latest: %v", msgs)
+	}
+
+	msgs, token := get(cloudwatchlogs.GetLogEventsInput{Limit: aws.Int32(2), StartFromHead: aws.Bool(true)})
+	if !reflect.DeepEqual(msgs, []string{"a", "b"}) {
+		t.Errorf("head: %v", msgs)
+	}
+	msgs, next := get(cloudwatchlogs.GetLogEventsInput{Limit: aws.Int32(2), NextToken: aws.String(token)})
+	if !reflect.DeepEqual(msgs, []string{"c"}) {
+		t.Errorf("second page: %v", msgs)
+	}
+	if msgs, last := get(cloudwatchlogs.GetLogEventsInput{NextToken: aws.String(next)}); len(msgs) != 0 || last != next {
+		t.Errorf("end of stream: events=%v token=%s expected token=%s", msgs, last, next)
+	}
+
+	if msgs, _ := get(cloudwatchlogs.GetLogEventsInput{StartTime: aws.Int64(2), EndTime: aws.Int64(3)}); !reflect.DeepEqual(msgs, []string{"b"}) {
+		t.Errorf("time range: %v", msgs)
+	}
+
+	_, err := c.GetLogEvents(context.Background(), &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String("g"),
+		LogStreamName: aws.String("missing"),
+	})
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Errorf("missing stream: expected ResourceNotFoundException, got: %v", err)
+	}
+}
+
+func TestClientFilterLogEvents(t *testing.T) {
+	c := NewClient()
+	putEvents(t, c, "g", "app-1",
+		RecordedEvent{Timestamp: 1, Message: "ERROR disk full"},
+		RecordedEvent{Timestamp: 4, Message: "INFO started"},
+	)
+	putEvents(t, c, "g", "app-2",
+		RecordedEvent{Timestamp: 2, Message: "WARN disk slow"},
+		RecordedEvent{Timestamp: 3, Message: "ERROR timeout"},
+	)
+	putEvents(t, c, "g", "other",
+		RecordedEvent{Timestamp: 5, Message: "ERROR elsewhere"},
+	)
+
+	table := []struct {
+		name     string
+		input    cloudwatchlogs.FilterLogEventsInput
+		expected []string
+	}{
+		{"all", cloudwatchlogs.FilterLogEventsInput{},
+			[]string{"ERROR disk full", "WARN disk slow", "ERROR timeout", "INFO started", "ERROR elsewhere"}},
+		{"term", cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String("ERROR")},
+			[]string{"ERROR disk full", "ERROR timeout", "ERROR elsewhere"}},
+		{"all terms", cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String("ERROR disk")},
+			[]string{"ERROR disk full"}},
+		{"optional terms", cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String("?WARN ?INFO")},
+			[]string{"WARN disk slow", "INFO started"}},
+		{"excluded term", cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String("ERROR -disk")},
+			[]string{"ERROR timeout", "ERROR elsewhere"}},
+		{"phrase", cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String(`"disk slow"`)},
+			[]string{"WARN disk slow"}},
+		{"stream prefix", cloudwatchlogs.FilterLogEventsInput{LogStreamNamePrefix: aws.String("app-")},
+			[]string{"ERROR disk full", "WARN disk slow", "ERROR timeout", "INFO started"}},
+		{"stream names", cloudwatchlogs.FilterLogEventsInput{LogStreamNames: []string{"app-2", "other"}},
+			[]string{"WARN disk slow", "ERROR timeout", "ERROR elsewhere"}},
+		{"time range", cloudwatchlogs.FilterLogEventsInput{StartTime: aws.Int64(2), EndTime: aws.Int64(4)},
+			[]string{"WARN disk slow", "ERROR timeout", "INFO started"}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			input := data.input
+			input.LogGroupName = aws.String("g")
+			input.Limit = aws.Int32(2) // exercise paging
+			var msgs []string
+			pages := cloudwatchlogs.NewFilterLogEventsPaginator(c, &input)
+			for pages.HasMorePages() {
+				out, err := pages.NextPage(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range out.Events {
+					msgs = append(msgs, aws.ToString(e.Message))
+				}
+			}
+			if !reflect.DeepEqual(msgs, data.expected) {
+				t.Errorf("expected=%q got=%q", data.expected, msgs)
+			}
+		})
+	}
+
+	_, err := c.FilterLogEvents(context.Background(), &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String("g"),
+		FilterPattern: aws.String(`{ $.level = "ERROR" }`),
+	})
+	var invalid *types.InvalidParameterException
+	if !errors.As(err, &invalid) {
+		t.Errorf("JSON pattern: expected InvalidParameterException, got: %v", err)
+	}
+}
+
+func TestClientExpire(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	client := NewClient()
+	client.SetNow(clock.Now)
+
+	cw, err := cwlog.New(cwlog.Options{
+		Client:            client,
+		Clock:             clock,
+		LogGroup:          "group",
+		LogStream:         "stream",
+		LogStreamTemplate: "{{.LogStream}}", // no rotation
+		RetentionInDays:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("old"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(12 * time.Hour)
+	if err := cw.PutSimple("new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := client.Expire(); n != 0 {
+		t.Fatalf("expired before retention: %d", n)
+	}
+	clock.Advance(13 * time.Hour)
+	if n := client.Expire(); n != 1 {
+		t.Fatalf("expired: expected=1 got=%d", n)
+	}
+	if msgs := client.Messages("group"); !reflect.DeepEqual(msgs, []string{"new"}) {
+		t.Fatalf("remaining: %v", msgs)
+	}
+
+	out, err := client.DescribeLogStreams(context.Background(),
+		&cloudwatchlogs.DescribeLogStreamsInput{LogGroupName: aws.String("group")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.LogStreams) != 1 {
+		t.Fatalf("streams: %d", len(out.LogStreams))
+	}
+	s := out.LogStreams[0]
+	if aws.ToInt64(s.CreationTime) != start.UnixMilli() {
+		t.Errorf("creation time: %d", aws.ToInt64(s.CreationTime))
+	}
+	if last := start.Add(12 * time.Hour).UnixMilli(); aws.ToInt64(s.LastEventTimestamp) != last {
+		t.Errorf("last event: expected=%d got=%d", last, aws.ToInt64(s.LastEventTimestamp))
+	}
+
+	if _, err := client.DeleteLogStream(context.Background(), &cloudwatchlogs.DeleteLogStreamInput{
+		LogGroupName:  aws.String("group"),
+		LogStreamName: s.LogStreamName,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := client.Messages("group"); len(msgs) != 0 {
+		t.Errorf("messages after delete: %v", msgs)
+	}
+}
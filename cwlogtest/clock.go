@@ -0,0 +1,95 @@
+package cwlogtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// FakeClock is a manually driven cwlog.Clock.
+// Time only moves forward when Advance or Set are called,
+// firing any tickers whose period elapsed.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock creates a fake clock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker creates a ticker driven by the fake clock.
+// Like time.Ticker, it drops ticks for slow receivers.
+func (c *FakeClock) NewTicker(d time.Duration) cwlog.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{
+		clock:  c,
+		period: d,
+		next:   c.now.Add(d),
+		c:      make(chan time.Time, 1),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing tickers due up to t.
+// Moving backwards does not fire tickers.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	for _, tk := range c.tickers {
+		if tk.next.After(t) {
+			continue
+		}
+		select {
+		case tk.c <- t:
+		default:
+		}
+		for !tk.next.After(t) {
+			tk.next = tk.next.Add(tk.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, tk := range c.tickers {
+		if tk == t {
+			c.tickers = append(c.tickers[:i], c.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cwlogtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatal("unexpected tick")
+	default:
+	}
+
+	clock.Advance(600 * time.Millisecond)
+	select {
+	case got := <-ticker.C():
+		if !got.Equal(start.Add(1100 * time.Millisecond)) {
+			t.Fatalf("tick time: %v", got)
+		}
+	default:
+		t.Fatal("missing tick")
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Fatal("tick after stop")
+	default:
+	}
+}
+
+func TestFakeClockRotation(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC))
+	rec, err := NewRecorder(RecorderOptions{Path: t.TempDir() + "/golden", Update: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw, err := cwlog.New(cwlog.Options{
+		Client:            rec,
+		Clock:             clock,
+		LogGroup:          "group",
+		LogStream:         "stream",
+		LogStreamTemplate: "{{.LogStream}}-{{.DD}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.PutSimple("day 1"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if err := cw.PutSimple("day 2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.recorded) != 2 {
+		t.Fatalf("puts: expected=2 got=%d", len(rec.recorded))
+	}
+	if s := rec.recorded[0].Stream; s != "stream-01" {
+		t.Errorf("stream 1: %s", s)
+	}
+	if s := rec.recorded[1].Stream; s != "stream-02" {
+		t.Errorf("stream 2: %s", s)
+	}
+}
+
+func TestFakeClockFlushInterval(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewClient()
+	cw, err := cwlog.New(cwlog.Options{
+		Client:        client,
+		Clock:         clock,
+		LogGroup:      "group",
+		Async:         true,
+		FlushInterval: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cw.Close()
+
+	if err := cw.PutSimple("partial batch"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(4 * time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if msgs := client.Messages("group"); len(msgs) != 0 {
+		t.Fatalf("sent before interval: %v", msgs)
+	}
+
+	clock.Advance(time.Second)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.Messages("group")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch not sent after interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,103 @@
+//go:build integration
+
+package cwlogtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// Run with: go test -tags integration ./cwlogtest/
+
+func TestIntegrationCycle(t *testing.T) {
+	ls := StartLocalStack(t)
+	client := ls.Client()
+	ctx := context.Background()
+
+	group := fmt.Sprintf("/cwlog/integration/%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		client.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String(group)})
+	})
+
+	for _, async := range []bool{false, true} {
+		t.Run(fmt.Sprintf("async=%t", async), func(t *testing.T) {
+			stream := fmt.Sprintf("stream-%t", async)
+			cw, err := cwlog.New(cwlog.Options{
+				Client:            client,
+				LogGroup:          group,
+				LogStream:         stream,
+				LogStreamTemplate: "{{.LogStream}}",
+				RetentionInDays:   1,
+				Async:             async,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{"line 1", "line 2", "line 3"}
+			for _, line := range expected {
+				if err := cw.PutSimple(line); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := cw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := client.GetLogEvents(ctx, &cloudwatchlogs.GetLogEventsInput{
+				LogGroupName:  aws.String(group),
+				LogStreamName: aws.String(stream),
+				StartFromHead: aws.Bool(true),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range out.Events {
+				got = append(got, aws.ToString(e.Message))
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("GetLogEvents: expected=%q got=%q", expected, got)
+			}
+
+			filtered, err := client.FilterLogEvents(ctx, &cloudwatchlogs.FilterLogEventsInput{
+				LogGroupName:   aws.String(group),
+				LogStreamNames: []string{stream},
+				FilterPattern:  aws.String(`"line 2"`),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(filtered.Events) != 1 || aws.ToString(filtered.Events[0].Message) != "line 2" {
+				t.Errorf("FilterLogEvents: %v", filtered.Events)
+			}
+		})
+	}
+
+	groups, err := client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(group),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups.LogGroups) != 1 || aws.ToInt32(groups.LogGroups[0].RetentionInDays) != 1 {
+		t.Errorf("group retention: %v", groups.LogGroups)
+	}
+}
+
+func TestIntegrationMissingGroup(t *testing.T) {
+	ls := StartLocalStack(t)
+	client := ls.Client()
+
+	err := cwlog.EnsureStream(context.Background(), client,
+		fmt.Sprintf("/cwlog/integration/missing/%d", time.Now().UnixNano()), "stream")
+	if cwlog.Classify(err) != cwlog.ClassNotFound {
+		t.Fatalf("expected not found, got: %v", err)
+	}
+}
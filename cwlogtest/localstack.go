@@ -0,0 +1,148 @@
+package cwlogtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+const (
+	defaultLocalStackImage  = "localstack/localstack"
+	defaultLocalStackRegion = "us-east-1"
+	localStackStartTimeout  = 2 * time.Minute
+)
+
+// LocalStack is a LocalStack instance serving CloudWatch Logs.
+type LocalStack struct {
+	// Endpoint is the base URL, like http://127.0.0.1:4566.
+	Endpoint string
+
+	// Region is the region the clients are configured for.
+	Region string
+}
+
+// StartLocalStack provides a LocalStack instance for integration tests.
+//
+// If LOCALSTACK_ENDPOINT is set, that instance is used as is.
+// Otherwise a container from LOCALSTACK_IMAGE (default localstack/localstack)
+// is started with docker and removed when the test finishes.
+// The test is skipped when docker is not available.
+func StartLocalStack(tb testing.TB) *LocalStack {
+	tb.Helper()
+
+	ls := &LocalStack{
+		Endpoint: os.Getenv("LOCALSTACK_ENDPOINT"),
+		Region:   defaultLocalStackRegion,
+	}
+	if ls.Endpoint == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			tb.Skip("docker not found and LOCALSTACK_ENDPOINT not set")
+		}
+		ls.Endpoint = startLocalStackContainer(tb)
+	}
+
+	if err := waitLocalStack(ls.Endpoint, localStackStartTimeout); err != nil {
+		tb.Fatalf("localstack not ready: %s: %v", ls.Endpoint, err)
+	}
+	return ls
+}
+
+// Client creates a CloudWatch Logs client talking to the instance
+// with dummy credentials.
+func (ls *LocalStack) Client() *cloudwatchlogs.Client {
+	return cloudwatchlogs.New(cloudwatchlogs.Options{
+		Region:       ls.Region,
+		BaseEndpoint: aws.String(ls.Endpoint),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		}),
+	})
+}
+
+// startLocalStackContainer runs LocalStack on a random local port,
+// returning its endpoint.
+func startLocalStackContainer(tb testing.TB) string {
+	tb.Helper()
+
+	image := os.Getenv("LOCALSTACK_IMAGE")
+	if image == "" {
+		image = defaultLocalStackImage
+	}
+
+	id, err := docker("run", "-d", "--rm", "-p", "127.0.0.1::4566", "-e", "SERVICES=logs", image)
+	if err != nil {
+		tb.Fatalf("localstack start: %v", err)
+	}
+	tb.Cleanup(func() {
+		if _, err := docker("rm", "-f", id); err != nil {
+			tb.Logf("localstack stop: %v", err)
+		}
+	})
+
+	addr, err := docker("port", id, "4566/tcp")
+	if err != nil {
+		tb.Fatalf("localstack port: %v", err)
+	}
+	// docker may list one address per line, IPv4 first
+	addr, _, _ = strings.Cut(addr, "\n")
+	return "http://" + addr
+}
+
+// docker runs a docker command returning its trimmed output.
+func docker(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s error: %w: %s", args[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// waitLocalStack polls the health endpoint until the logs service
+// is up or the timeout expires.
+func waitLocalStack(endpoint string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := localStackHealthy(endpoint)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func localStackHealthy(endpoint string) error {
+	resp, err := http.Get(endpoint + "/_localstack/health")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Services map[string]string `json:"services"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return err
+	}
+	switch status := health.Services["logs"]; status {
+	case "available", "running":
+		return nil
+	default:
+		return fmt.Errorf("logs service status: %q", status)
+	}
+}
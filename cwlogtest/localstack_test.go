@@ -0,0 +1,38 @@
+package cwlogtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStartLocalStackEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_localstack/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"services":{"logs":"available","s3":"disabled"}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("LOCALSTACK_ENDPOINT", srv.URL)
+
+	ls := StartLocalStack(t)
+	if ls.Endpoint != srv.URL {
+		t.Errorf("endpoint: expected=%s got=%s", srv.URL, ls.Endpoint)
+	}
+	if ls.Client() == nil {
+		t.Error("nil client")
+	}
+}
+
+func TestLocalStackNotReady(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"services":{"logs":"disabled"}}`))
+	}))
+	defer srv.Close()
+
+	if err := waitLocalStack(srv.URL, 0); err == nil {
+		t.Error("expected error for disabled logs service")
+	}
+}
@@ -0,0 +1,361 @@
+package cwlogtest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	defaultGetLimit      = 10000
+	defaultFilterLimit   = 10000
+	defaultDescribeLimit = 50
+)
+
+// groupName resolves the group of a read request, which may be given
+// by name or by identifier.
+func groupName(name, identifier *string) string {
+	if name != nil {
+		return *name
+	}
+	return aws.ToString(identifier)
+}
+
+// GetLogEvents returns the events of one stream in timestamp order,
+// following the CloudWatch Logs paging semantics: without a token the
+// latest events are returned unless StartFromHead is set, and the
+// forward token repeats once the end of the stream is reached.
+func (c *Client) GetLogEvents(_ context.Context,
+	params *cloudwatchlogs.GetLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+
+	group := groupName(params.LogGroupName, params.LogGroupIdentifier)
+	stream := aws.ToString(params.LogStreamName)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil, notFound("group", group)
+	}
+	s := g.streams[stream]
+	if s == nil {
+		return nil, notFound("stream", group+"/"+stream)
+	}
+
+	var matched []storedEvent
+	for _, e := range s.events {
+		if params.StartTime != nil && e.Timestamp < *params.StartTime {
+			continue
+		}
+		if params.EndTime != nil && e.Timestamp >= *params.EndTime {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sortByTimestamp(matched)
+
+	limit := int(aws.ToInt32(params.Limit))
+	if limit <= 0 {
+		limit = defaultGetLimit
+	}
+
+	var from, to int
+	switch token := aws.ToString(params.NextToken); {
+	case strings.HasPrefix(token, "f/"):
+		n, err := parseToken(token[2:], len(matched))
+		if err != nil {
+			return nil, err
+		}
+		from, to = n, min(n+limit, len(matched))
+	case strings.HasPrefix(token, "b/"):
+		n, err := parseToken(token[2:], len(matched))
+		if err != nil {
+			return nil, err
+		}
+		from, to = max(n-limit, 0), n
+	case token != "":
+		return nil, invalidToken(token)
+	case aws.ToBool(params.StartFromHead):
+		from, to = 0, min(limit, len(matched))
+	default:
+		from, to = max(len(matched)-limit, 0), len(matched)
+	}
+
+	out := &cloudwatchlogs.GetLogEventsOutput{
+		Events:            make([]types.OutputLogEvent, 0, to-from),
+		NextForwardToken:  aws.String(fmt.Sprintf("f/%d", to)),
+		NextBackwardToken: aws.String(fmt.Sprintf("b/%d", from)),
+	}
+	for _, e := range matched[from:to] {
+		out.Events = append(out.Events, types.OutputLogEvent{
+			Timestamp:     aws.Int64(e.Timestamp),
+			Message:       aws.String(e.Message),
+			IngestionTime: aws.Int64(e.ingestionTime),
+		})
+	}
+	return out, nil
+}
+
+// FilterLogEvents returns the events of a group in timestamp order,
+// optionally restricted by stream, time range and filter pattern.
+// Only term patterns are supported: every term must appear in the
+// message, "?term" matches any of the optional terms, "-term" excludes
+// and double quotes group a phrase. JSON and space delimited patterns
+// are rejected.
+func (c *Client) FilterLogEvents(_ context.Context,
+	params *cloudwatchlogs.FilterLogEventsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+
+	group := groupName(params.LogGroupName, params.LogGroupIdentifier)
+	prefix := aws.ToString(params.LogStreamNamePrefix)
+	if prefix != "" && len(params.LogStreamNames) > 0 {
+		return nil, &types.InvalidParameterException{
+			Message: aws.String("LogStreamNames and LogStreamNamePrefix are mutually exclusive"),
+		}
+	}
+	match, err := compileFilter(aws.ToString(params.FilterPattern))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil, notFound("group", group)
+	}
+
+	type filtered struct {
+		storedEvent
+		stream string
+	}
+	var matched []filtered
+	for _, name := range g.sortedStreams() {
+		if len(params.LogStreamNames) > 0 && !slices.Contains(params.LogStreamNames, name) {
+			continue
+		}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		for _, e := range g.streams[name].events {
+			if params.StartTime != nil && e.Timestamp < *params.StartTime {
+				continue
+			}
+			if params.EndTime != nil && e.Timestamp > *params.EndTime {
+				continue
+			}
+			if !match(e.Message) {
+				continue
+			}
+			matched = append(matched, filtered{storedEvent: e, stream: name})
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b filtered) int {
+		return cmp.Or(cmp.Compare(a.Timestamp, b.Timestamp), cmp.Compare(a.id, b.id))
+	})
+
+	limit := int(aws.ToInt32(params.Limit))
+	if limit <= 0 {
+		limit = defaultFilterLimit
+	}
+	from, err := parseToken(aws.ToString(params.NextToken), len(matched))
+	if err != nil {
+		return nil, err
+	}
+	to := min(from+limit, len(matched))
+
+	out := &cloudwatchlogs.FilterLogEventsOutput{
+		Events: make([]types.FilteredLogEvent, 0, to-from),
+	}
+	if to < len(matched) {
+		out.NextToken = aws.String(strconv.Itoa(to))
+	}
+	for _, e := range matched[from:to] {
+		out.Events = append(out.Events, types.FilteredLogEvent{
+			EventId:       aws.String(e.id),
+			LogStreamName: aws.String(e.stream),
+			Timestamp:     aws.Int64(e.Timestamp),
+			Message:       aws.String(e.Message),
+			IngestionTime: aws.Int64(e.ingestionTime),
+		})
+	}
+	return out, nil
+}
+
+// DescribeLogStreams lists the streams of a group ordered by name,
+// reporting creation and first/last event times from the stored events.
+func (c *Client) DescribeLogStreams(_ context.Context,
+	params *cloudwatchlogs.DescribeLogStreamsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+
+	group := groupName(params.LogGroupName, params.LogGroupIdentifier)
+	prefix := aws.ToString(params.LogStreamNamePrefix)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g := c.groups[group]
+	if g == nil {
+		return nil, notFound("group", group)
+	}
+
+	var names []string
+	for _, name := range g.sortedStreams() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	if aws.ToBool(params.Descending) {
+		slices.Reverse(names)
+	}
+
+	limit := int(aws.ToInt32(params.Limit))
+	if limit <= 0 {
+		limit = defaultDescribeLimit
+	}
+	from, err := parseToken(aws.ToString(params.NextToken), len(names))
+	if err != nil {
+		return nil, err
+	}
+	to := min(from+limit, len(names))
+
+	out := &cloudwatchlogs.DescribeLogStreamsOutput{
+		LogStreams: make([]types.LogStream, 0, to-from),
+	}
+	if to < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(to))
+	}
+	for _, name := range names[from:to] {
+		s := g.streams[name]
+		ls := types.LogStream{
+			LogStreamName: aws.String(name),
+			CreationTime:  aws.Int64(s.creationTime),
+		}
+		if len(s.events) > 0 {
+			first, last := s.events[0].Timestamp, s.events[0].Timestamp
+			var ingestion int64
+			for _, e := range s.events {
+				first = min(first, e.Timestamp)
+				last = max(last, e.Timestamp)
+				ingestion = max(ingestion, e.ingestionTime)
+			}
+			ls.FirstEventTimestamp = aws.Int64(first)
+			ls.LastEventTimestamp = aws.Int64(last)
+			ls.LastIngestionTime = aws.Int64(ingestion)
+		}
+		out.LogStreams = append(out.LogStreams, ls)
+	}
+	return out, nil
+}
+
+func sortByTimestamp(events []storedEvent) {
+	slices.SortStableFunc(events, func(a, b storedEvent) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+}
+
+// parseToken decodes a paging offset, with empty meaning the start.
+func parseToken(token string, size int) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(token)
+	if err != nil || n < 0 || n > size {
+		return 0, invalidToken(token)
+	}
+	return n, nil
+}
+
+func invalidToken(token string) error {
+	return &types.InvalidParameterException{
+		Message: aws.String("invalid next token: " + token),
+	}
+}
+
+// compileFilter builds a matcher for a term filter pattern.
+func compileFilter(pattern string) (func(string) bool, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+	if strings.HasPrefix(pattern, "{") || strings.HasPrefix(pattern, "[") {
+		return nil, &types.InvalidParameterException{
+			Message: aws.String("unsupported filter pattern: " + pattern),
+		}
+	}
+
+	terms, err := splitTerms(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var required, optional, excluded []string
+	for _, term := range terms {
+		switch {
+		case strings.HasPrefix(term, "?"):
+			optional = append(optional, term[1:])
+		case strings.HasPrefix(term, "-"):
+			excluded = append(excluded, term[1:])
+		default:
+			required = append(required, term)
+		}
+	}
+
+	return func(msg string) bool {
+		for _, term := range excluded {
+			if strings.Contains(msg, term) {
+				return false
+			}
+		}
+		for _, term := range required {
+			if !strings.Contains(msg, term) {
+				return false
+			}
+		}
+		if len(optional) == 0 {
+			return true
+		}
+		for _, term := range optional {
+			if strings.Contains(msg, term) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// splitTerms splits a pattern on spaces, keeping double quoted phrases
+// together. Operators before a quote stay attached: -"a b" is "-a b".
+func splitTerms(pattern string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	var quoted bool
+	for _, r := range pattern {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, &types.InvalidParameterException{
+			Message: aws.String("unbalanced quotes in filter pattern: " + pattern),
+		}
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
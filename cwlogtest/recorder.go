@@ -0,0 +1,228 @@
+// Package cwlogtest provides helpers for testing code that uses cwlog.
+package cwlogtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+// RecorderOptions define settings for Recorder.
+type RecorderOptions struct {
+	// Path is required golden file.
+	Path string
+
+	// Update records requests into Path, replacing it.
+	// If false, requests are replayed and verified against Path.
+	// Usually driven by a test flag like -update.
+	Update bool
+
+	// Client optionally receives every call forwarded by the recorder.
+	// If undefined, calls succeed without side effects.
+	Client cwlog.CloudWatchLogClient
+
+	// IgnoreTimestamps skips timestamp comparison during replay.
+	IgnoreTimestamps bool
+}
+
+// RecordedEvent is one log event stored in the golden file.
+type RecordedEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// RecordedPut is one PutLogEvents request stored in the golden file.
+type RecordedPut struct {
+	Group  string          `json:"group"`
+	Stream string          `json:"stream"`
+	Events []RecordedEvent `json:"events"`
+}
+
+// Recorder wraps a CloudWatch Logs client recording PutLogEvents requests
+// into a golden file, or replaying and verifying them against it.
+type Recorder struct {
+	options  RecorderOptions
+	mu       sync.Mutex
+	recorded []RecordedPut
+	expected []RecordedPut
+	next     int
+}
+
+// NewRecorder creates a recorder.
+// In replay mode the golden file is loaded immediately.
+func NewRecorder(options RecorderOptions) (*Recorder, error) {
+	if options.Path == "" {
+		return nil, errors.New("Path is required")
+	}
+	r := &Recorder{options: options}
+	if !options.Update {
+		expected, err := loadGolden(options.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.expected = expected
+	}
+	return r, nil
+}
+
+func loadGolden(path string) ([]RecordedPut, error) {
+	f, errOpen := os.Open(path)
+	if errOpen != nil {
+		return nil, fmt.Errorf("golden file open error: %w", errOpen)
+	}
+	defer f.Close()
+	var list []RecordedPut
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 2*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var put RecordedPut
+		if err := json.Unmarshal(line, &put); err != nil {
+			return nil, fmt.Errorf("golden file decode error: %s: %w", path, err)
+		}
+		list = append(list, put)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("golden file read error: %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Close finishes the recording session.
+// In update mode it writes the golden file.
+// In replay mode it reports requests expected but never issued.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.options.Update {
+		if r.next < len(r.expected) {
+			return fmt.Errorf("golden replay: missing requests: expected=%d got=%d",
+				len(r.expected), r.next)
+		}
+		return nil
+	}
+
+	f, errCreate := os.Create(r.options.Path)
+	if errCreate != nil {
+		return fmt.Errorf("golden file create error: %w", errCreate)
+	}
+	enc := json.NewEncoder(f)
+	for _, put := range r.recorded {
+		if err := enc.Encode(put); err != nil {
+			f.Close()
+			return fmt.Errorf("golden file write error: %s: %w", r.options.Path, err)
+		}
+	}
+	return f.Close()
+}
+
+func (r *Recorder) check(put RecordedPut) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.options.Update {
+		r.recorded = append(r.recorded, put)
+		return nil
+	}
+
+	i := r.next
+	if i >= len(r.expected) {
+		return fmt.Errorf("golden replay: unexpected request %d: group=%s stream=%s",
+			i+1, put.Group, put.Stream)
+	}
+	r.next++
+
+	return comparePut(i+1, r.expected[i], put, r.options.IgnoreTimestamps)
+}
+
+func comparePut(n int, expected, got RecordedPut, ignoreTimestamps bool) error {
+	if expected.Group != got.Group || expected.Stream != got.Stream {
+		return fmt.Errorf("golden replay: request %d: destination: expected=%s:%s got=%s:%s",
+			n, expected.Group, expected.Stream, got.Group, got.Stream)
+	}
+	if len(expected.Events) != len(got.Events) {
+		return fmt.Errorf("golden replay: request %d: events: expected=%d got=%d",
+			n, len(expected.Events), len(got.Events))
+	}
+	for j, e := range expected.Events {
+		g := got.Events[j]
+		if e.Message != g.Message {
+			return fmt.Errorf("golden replay: request %d: event %d: message: expected=%q got=%q",
+				n, j+1, e.Message, g.Message)
+		}
+		if !ignoreTimestamps && e.Timestamp != g.Timestamp {
+			return fmt.Errorf("golden replay: request %d: event %d: timestamp: expected=%d got=%d",
+				n, j+1, e.Timestamp, g.Timestamp)
+		}
+	}
+	return nil
+}
+
+// CreateLogGroup forwards to the wrapped client.
+func (r *Recorder) CreateLogGroup(ctx context.Context,
+	params *cloudwatchlogs.CreateLogGroupInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if r.options.Client == nil {
+		return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+	}
+	return r.options.Client.CreateLogGroup(ctx, params, optFns...)
+}
+
+// PutRetentionPolicy forwards to the wrapped client.
+func (r *Recorder) PutRetentionPolicy(ctx context.Context,
+	params *cloudwatchlogs.PutRetentionPolicyInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	if r.options.Client == nil {
+		return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+	}
+	return r.options.Client.PutRetentionPolicy(ctx, params, optFns...)
+}
+
+// CreateLogStream forwards to the wrapped client.
+func (r *Recorder) CreateLogStream(ctx context.Context,
+	params *cloudwatchlogs.CreateLogStreamInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	if r.options.Client == nil {
+		return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+	}
+	return r.options.Client.CreateLogStream(ctx, params, optFns...)
+}
+
+// PutLogEvents records or verifies the request, then forwards it to the wrapped client.
+func (r *Recorder) PutLogEvents(ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+
+	put := RecordedPut{
+		Group:  aws.ToString(params.LogGroupName),
+		Stream: aws.ToString(params.LogStreamName),
+		Events: make([]RecordedEvent, 0, len(params.LogEvents)),
+	}
+	for _, e := range params.LogEvents {
+		put.Events = append(put.Events, RecordedEvent{
+			Timestamp: aws.ToInt64(e.Timestamp),
+			Message:   aws.ToString(e.Message),
+		})
+	}
+
+	if err := r.check(put); err != nil {
+		return nil, err
+	}
+
+	if r.options.Client == nil {
+		return &cloudwatchlogs.PutLogEventsOutput{}, nil
+	}
+	return r.options.Client.PutLogEvents(ctx, params, optFns...)
+}
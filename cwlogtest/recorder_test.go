@@ -0,0 +1,88 @@
+package cwlogtest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+)
+
+func putLines(t *testing.T, rec *Recorder, lines ...string) error {
+	t.Helper()
+	cw, err := cwlog.New(cwlog.Options{
+		Client:    rec,
+		Now:       func() time.Time { return time.Time{} },
+		LogGroup:  "/cloudwatchlogs/group",
+		LogStream: "/cloudwatchlogs/stream",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		if err := cw.PutSimple(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRecorderReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.jsonl")
+
+	rec, err := NewRecorder(RecorderOptions{Path: path, Update: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := putLines(t, rec, "line 1", "line 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, errReplay := NewRecorder(RecorderOptions{Path: path})
+	if errReplay != nil {
+		t.Fatal(errReplay)
+	}
+	if err := putLines(t, replay, "line 1", "line 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := replay.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecorderMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.jsonl")
+
+	rec, err := NewRecorder(RecorderOptions{Path: path, Update: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := putLines(t, rec, "line 1", "line 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, errReplay := NewRecorder(RecorderOptions{Path: path})
+	if errReplay != nil {
+		t.Fatal(errReplay)
+	}
+	if err := putLines(t, replay, "line 1", "changed"); err == nil {
+		t.Fatal("expected mismatch error")
+	}
+
+	missing, errMissing := NewRecorder(RecorderOptions{Path: path})
+	if errMissing != nil {
+		t.Fatal(errMissing)
+	}
+	if err := putLines(t, missing, "line 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.Close(); err == nil {
+		t.Fatal("expected missing requests error")
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/udhos/cloudwatchlog/cwlogtrace
+
+go 1.25.9 // minimum
+
+toolchain go1.26.2 // preferred
+
+require (
+	github.com/udhos/cloudwatchlog v0.0.0-20261016184442-114805276ca4
+	go.opentelemetry.io/otel/trace v1.43.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.41.6 // indirect
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.7.9 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.4.22 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.7.22 // indirect
+	github.com/aws/aws-sdk-go-v2/service/cloudwatch v1.56.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.69.1 // indirect
+	github.com/aws/smithy-go v1.25.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.43.0 // indirect
+)
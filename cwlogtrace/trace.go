@@ -0,0 +1,127 @@
+// Package cwlogtrace enriches cwlog events with the active trace context,
+// enabling log to trace correlation in the CloudWatch console.
+//
+// The trace context is taken, in order, from an OpenTelemetry span in the
+// context, from a W3C traceparent or X-Ray trace header stored in the
+// context by Middleware or NewContext, and finally from the Lambda
+// _X_AMZN_TRACE_ID environment variable.
+package cwlogtrace
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContext identifies a trace and, optionally, the current span.
+// X-Ray trace IDs keep the X-Ray format, like 1-5759e988-bd862e3fe1be46a994272793.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying tc.
+func NewContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// FromContext returns the active trace context.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return TraceContext{TraceID: sc.TraceID().String(), SpanID: sc.SpanID().String()}, true
+	}
+	if tc, ok := ctx.Value(contextKey{}).(TraceContext); ok {
+		return tc, true
+	}
+	return ParseXRayHeader(os.Getenv("_X_AMZN_TRACE_ID"))
+}
+
+// Fields returns the traceId and spanId fields for the active trace
+// context, or nil when there is none.
+func Fields(ctx context.Context) map[string]any {
+	tc, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	fields := map[string]any{"traceId": tc.TraceID}
+	if tc.SpanID != "" {
+		fields["spanId"] = tc.SpanID
+	}
+	return fields
+}
+
+// With returns a child of l that injects the traceId and spanId fields of
+// the active trace context into every structured event, as cwlog.Log.With.
+// l is returned unchanged when there is no trace context.
+func With(l *cwlog.Log, ctx context.Context) *cwlog.Log {
+	fields := Fields(ctx)
+	if fields == nil {
+		return l
+	}
+	return l.With(fields)
+}
+
+// Middleware stores the trace context received in the traceparent or
+// X-Amzn-Trace-Id request headers into the request context, unless the
+// context already holds an OpenTelemetry span.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if trace.SpanContextFromContext(r.Context()).IsValid() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tc, ok := ParseTraceparent(r.Header.Get("traceparent"))
+		if !ok {
+			tc, ok = ParseXRayHeader(r.Header.Get("X-Amzn-Trace-Id"))
+		}
+		if ok {
+			r = r.WithContext(NewContext(r.Context(), tc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseTraceparent parses a W3C traceparent header like
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(s string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return TraceContext{}, false
+	}
+	if !validHex(parts[1], 32) || !validHex(parts[2], 16) {
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: parts[1], SpanID: parts[2]}, true
+}
+
+// ParseXRayHeader parses an X-Ray trace header like
+// Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1.
+func ParseXRayHeader(s string) (TraceContext, bool) {
+	var tc TraceContext
+	for kv := range strings.SplitSeq(s, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		switch key {
+		case "Root":
+			tc.TraceID = value
+		case "Parent":
+			tc.SpanID = value
+		}
+	}
+	return tc, strings.HasPrefix(tc.TraceID, "1-")
+}
+
+// validHex reports whether s holds size non-zero hex digits.
+func validHex(s string, size int) bool {
+	if len(s) != size || strings.Trim(s, "0") == "" {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
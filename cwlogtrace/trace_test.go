@@ -0,0 +1,107 @@
+package cwlogtrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udhos/cloudwatchlog/cwlog"
+	"github.com/udhos/cloudwatchlog/cwlogtest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParse(t *testing.T) {
+	table := []struct {
+		name     string
+		parse    func(string) (TraceContext, bool)
+		header   string
+		ok       bool
+		expected TraceContext
+	}{
+		{"traceparent", ParseTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true,
+			TraceContext{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"}},
+		{"traceparent zero trace", ParseTraceparent, "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, TraceContext{}},
+		{"traceparent short", ParseTraceparent, "00-4bf92f35-00f067aa0ba902b7-01", false, TraceContext{}},
+		{"xray", ParseXRayHeader, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", true,
+			TraceContext{"1-5759e988-bd862e3fe1be46a994272793", "53995c3f42cd8ad8"}},
+		{"xray root only", ParseXRayHeader, "Root=1-5759e988-bd862e3fe1be46a994272793", true,
+			TraceContext{TraceID: "1-5759e988-bd862e3fe1be46a994272793"}},
+		{"xray empty", ParseXRayHeader, "", false, TraceContext{}},
+	}
+
+	for i, data := range table {
+		name := fmt.Sprintf("%02d of %02d: %s", i+1, len(table), data.name)
+		t.Run(name, func(t *testing.T) {
+			tc, ok := data.parse(data.header)
+			if ok != data.ok {
+				t.Fatalf("ok: expected=%t got=%t", data.ok, ok)
+			}
+			if ok && tc != data.expected {
+				t.Errorf("expected=%+v got=%+v", data.expected, tc)
+			}
+		})
+	}
+}
+
+func TestFromContextOTel(t *testing.T) {
+	t.Setenv("_X_AMZN_TRACE_ID", "Root=1-5759e988-bd862e3fe1be46a994272793")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = NewContext(ctx, TraceContext{TraceID: "ignored"})
+
+	tc, ok := FromContext(ctx)
+	if !ok || tc.TraceID != "01020300000000000000000000000000" || tc.SpanID != "0405060000000000" {
+		t.Errorf("otel span should win: %+v %t", tc, ok)
+	}
+
+	tc, _ = FromContext(context.Background())
+	if tc.TraceID != "1-5759e988-bd862e3fe1be46a994272793" {
+		t.Errorf("lambda env fallback: %+v", tc)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Setenv("_X_AMZN_TRACE_ID", "")
+	client := cwlogtest.NewClient()
+	l, err := cwlog.New(cwlog.Options{
+		Client:   client,
+		Now:      func() time.Time { return time.Time{} },
+		LogGroup: "/trace",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		With(l, r.Context()).Info("hello")
+	}))
+	for _, header := range []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("traceparent", header)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	msgs := client.Messages("/trace")
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %v", msgs)
+	}
+	if !strings.Contains(msgs[0], `"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`) ||
+		!strings.Contains(msgs[0], `"spanId":"00f067aa0ba902b7"`) {
+		t.Errorf("missing trace fields: %s", msgs[0])
+	}
+	if strings.Contains(msgs[1], "traceId") {
+		t.Errorf("unexpected trace fields: %s", msgs[1])
+	}
+}